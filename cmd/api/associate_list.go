@@ -0,0 +1,116 @@
+package main
+
+import (
+	"backend/internal/data"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultAssociatePageSize applies when only offset is given
+	defaultAssociatePageSize = 50
+	// maxAssociatePageSize caps limit
+	maxAssociatePageSize = 500
+)
+
+// associateQueryFields maps the filter and sort parameters of associate
+// listings to the Associate fields they reveal.
+var associateQueryFields = map[string]string{
+	"department":      "Department",
+	"office":          "Office",
+	"status":          "Status",
+	"empl_status":     "EmplStatus",
+	"manager_id":      "manager_id",
+	"start_date_from": "StartDate",
+	"start_date_to":   "StartDate",
+	"start_date":      "StartDate",
+	"title":           "Title",
+	"email":           "Email",
+}
+
+// errForbiddenField rejects filtering or sorting on a field the caller may
+// not see.
+var errForbiddenField = errors.New("forbidden")
+
+// associateQuery is a parsed associate listing request.
+type associateQuery struct {
+	Filter    data.AssociateFilter
+	Sort      []data.AssociateSort
+	Limit     int
+	Offset    int
+	Paginated bool
+}
+
+// parseAssociateQuery reads the filters, sort and page of an associate
+// listing. Callers other than HR may only filter and sort on fields the
+// field visibility policy shows everyone, so the results cannot reveal the
+// others.
+func (v *associateView) parseAssociateQuery(query url.Values) (*associateQuery, error) {
+	q := &associateQuery{}
+	f := &q.Filter
+	f.Department = query.Get("department")
+	f.Office = query.Get("office")
+	f.Status = query.Get("status")
+	f.EmplStatus = query.Get("empl_status")
+
+	var err error
+	if value := query.Get("manager_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, errors.New("manager_id must be a number")
+		}
+		f.ManagerID = &id
+	}
+	for param, target := range map[string]**time.Time{"start_date_from": &f.StartFrom, "start_date_to": &f.StartTo} {
+		if value := query.Get(param); value != "" {
+			date, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				return nil, errors.New(param + " must be a date in YYYY-MM-DD format")
+			}
+			*target = &date
+		}
+	}
+
+	q.Sort, err = data.ParseAssociateSort(query.Get("sort"))
+	if err != nil {
+		return nil, err
+	}
+
+	if !v.hr {
+		for param := range query {
+			field, ok := associateQueryFields[param]
+			if ok && v.policy[field] != "" && v.policy[field] != data.VisibilityPublic && query.Get(param) != "" {
+				return nil, fmt.Errorf("%w: you cannot filter by %s", errForbiddenField, param)
+			}
+		}
+		for _, s := range q.Sort {
+			field := associateQueryFields[s.Field]
+			if field != "" && v.policy[field] != "" && v.policy[field] != data.VisibilityPublic {
+				return nil, fmt.Errorf("%w: you cannot sort by %s", errForbiddenField, s.Field)
+			}
+		}
+	}
+
+	limit, offset := query.Get("limit"), query.Get("offset")
+	if limit == "" && offset == "" {
+		return q, nil
+	}
+	q.Paginated = true
+	q.Limit = defaultAssociatePageSize
+	if limit != "" {
+		q.Limit, err = strconv.Atoi(limit)
+		if err != nil || q.Limit < 1 || q.Limit > maxAssociatePageSize {
+			return nil, errors.New("limit must be between 1 and " + strconv.Itoa(maxAssociatePageSize))
+		}
+	}
+	if offset != "" {
+		q.Offset, err = strconv.Atoi(offset)
+		if err != nil || q.Offset < 0 {
+			return nil, errors.New("offset must be zero or more")
+		}
+	}
+	return q, nil
+}
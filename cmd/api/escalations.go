@@ -0,0 +1,163 @@
+package main
+
+import (
+	"backend/internal/data"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// escalationInterval is how often the scheduler looks for stale approvals
+	escalationInterval = 15 * time.Minute
+	// defaultEscalationSLAHours applies when approval_escalation_sla_hours is unset
+	defaultEscalationSLAHours = 72
+	// escalationLockName is the MySQL named lock held during a pass, so only
+	// one replica escalates at a time
+	escalationLockName = "workops_approval_escalations"
+)
+
+// escalationSLA reads how long an approval may wait on one approver
+// (approval_escalation_sla_hours); zero turns escalation off.
+func (app *Application) escalationSLA() time.Duration {
+	hours := defaultEscalationSLAHours
+	setting, _ := app.Models.AppSettings.Get("approval_escalation_sla_hours")
+	if setting != nil && setting.Value != "" {
+		if val, err := strconv.Atoi(strings.TrimSpace(setting.Value)); err == nil && val >= 0 {
+			hours = val
+		}
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// secondApproverID reads the second_approver_id setting.
+func (app *Application) secondApproverID() *int {
+	setting, _ := app.Models.AppSettings.Get("second_approver_id")
+	if setting == nil || setting.Value == "" {
+		return nil
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(setting.Value))
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+// nextApprover finds who an approval waiting on approverID escalates to: the
+// approver's manager, skipping the requester, or the second approver once
+// the chain runs out. It returns nil when there is nobody above.
+func (app *Application) nextApprover(approverID *int, requesterID int) (*int, error) {
+	seen := map[int]bool{}
+	if approverID != nil {
+		seen[*approverID] = true
+	}
+
+	current := approverID
+	for current != nil {
+		associate, err := app.Models.Associates.GetOne(*current)
+		if err != nil {
+			return nil, err
+		}
+		current = associate.ManagerID
+		if current == nil || seen[*current] {
+			// Top of the chain, or a loop in it
+			break
+		}
+		seen[*current] = true
+		if *current != requesterID {
+			return current, nil
+		}
+	}
+
+	second := app.secondApproverID()
+	if second == nil || seen[*second] || *second == requesterID {
+		return nil, nil
+	}
+	return second, nil
+}
+
+// escalateStaleApprovals runs one pass: every pending time-off request and
+// overtime entry that has waited on its approver longer than the SLA moves
+// one step up. Replicas that find the lock taken skip the pass, and each
+// step is applied at most once even if two passes overlap.
+func (app *Application) escalateStaleApprovals(ctx context.Context) (int, error) {
+	sla := app.escalationSLA()
+	if sla == 0 {
+		return 0, nil
+	}
+
+	release, err := app.Models.Escalations.TryLock(ctx, escalationLockName)
+	if err != nil || release == nil {
+		return 0, err
+	}
+	defer release()
+
+	stale, err := app.Models.Escalations.GetStale(time.Now().Add(-sla))
+	if err != nil {
+		return 0, err
+	}
+
+	escalated := 0
+	for _, s := range stale {
+		next, err := app.nextApprover(s.ApproverID, s.RequesterID)
+		if err != nil {
+			log.Printf("Cannot escalate %s %d: %v", s.SubjectType, s.SubjectID, err)
+			continue
+		}
+		if next == nil {
+			continue
+		}
+
+		comment := fmt.Sprintf("Escalated after %d hours without a decision", int(sla.Hours()))
+		ok, err := app.Models.Escalations.Escalate(s, *next, comment)
+		if err != nil {
+			log.Printf("Cannot escalate %s %d: %v", s.SubjectType, s.SubjectID, err)
+			continue
+		}
+		if ok {
+			escalated++
+		}
+	}
+
+	return escalated, nil
+}
+
+// runEscalations escalates stale approvals every escalationInterval until ctx
+// is done.
+func (app *Application) runEscalations(ctx context.Context) {
+	ticker := time.NewTicker(escalationInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := app.escalateStaleApprovals(ctx)
+		if err != nil {
+			log.Printf("Approval escalation failed: %v", err)
+		} else if n > 0 {
+			log.Printf("Escalated %d stale approval(s)", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// escalatedTo reports whether the approval is currently escalated to user,
+// directly or through a delegation, and on whose behalf in the latter case.
+func (app *Application) escalatedTo(user *data.Associate, escalatedTo *int, requesterID int) (bool, *int) {
+	if user == nil || escalatedTo == nil {
+		return false, nil
+	}
+	if *escalatedTo == user.ID && user.ID != requesterID {
+		return true, nil
+	}
+	if onBehalfOf := app.delegatedApprover(user, escalatedTo, requesterID); onBehalfOf != nil {
+		return true, onBehalfOf
+	}
+	return false, nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"backend/internal/data"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// fieldVisibility reads the associate field visibility policy. A stored
+// policy that no longer parses is logged and the defaults apply.
+func (app *Application) fieldVisibility() data.FieldVisibilityPolicy {
+	setting, _ := app.Models.AppSettings.Get(data.FieldVisibilitySetting)
+	if setting == nil {
+		return data.DefaultFieldVisibility()
+	}
+
+	policy, err := data.ParseFieldVisibility(setting.Value)
+	if err != nil {
+		log.Printf("Invalid %s setting, using the defaults: %v", data.FieldVisibilitySetting, err)
+	}
+	return policy
+}
+
+// isHR reports whether user sees every associate field.
+func (app *Application) isHR(user *data.Associate) bool {
+	return app.can(user, data.PermAssociatesViewSensitive) || app.can(user, data.PermAssociatesManage)
+}
+
+// associateView renders associates for one viewer under the field
+// visibility policy: fields the viewer may not see are left out, not zeroed.
+type associateView struct {
+	policy  data.FieldVisibilityPolicy
+	viewer  *data.Associate
+	hr      bool
+	reports map[int]bool
+}
+
+// newAssociateView prepares to render associates for the current user,
+// loading who reports to them, directly or not.
+func (app *Application) newAssociateView(r *http.Request) (*associateView, error) {
+	view := &associateView{
+		policy:  app.fieldVisibility(),
+		viewer:  app.currentUser(r),
+		reports: map[int]bool{},
+	}
+	if view.viewer == nil {
+		return view, nil
+	}
+
+	view.hr = app.isHR(view.viewer)
+	if view.hr {
+		return view, nil
+	}
+
+	links, err := app.Models.Associates.GetManagerLinks()
+	if err != nil {
+		return nil, err
+	}
+	view.reports = reportsUnder(view.viewer.ID, links)
+	return view, nil
+}
+
+// reportsUnder returns everyone below managerID in the manager chain.
+func reportsUnder(managerID int, links map[int]*int) map[int]bool {
+	reports := map[int]bool{}
+	for _, r := range data.NewOrgChart(links).Reports(managerID, 0) {
+		reports[r.ID] = true
+	}
+	return reports
+}
+
+func (v *associateView) audience(a data.Associate) data.Audience {
+	return data.Audience{
+		Self:    v.viewer != nil && v.viewer.ID == a.ID,
+		Manager: v.reports[a.ID],
+		HR:      v.hr,
+	}
+}
+
+// render returns the associate's JSON object without the hidden fields.
+func (v *associateView) render(a data.Associate) (map[string]json.RawMessage, error) {
+	encoded, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+
+	audience := v.audience(a)
+	for _, field := range data.RestrictableAssociateFields {
+		if !v.policy.Visible(field, audience) {
+			delete(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+func (v *associateView) renderAll(associates []data.Associate) ([]map[string]json.RawMessage, error) {
+	rendered := make([]map[string]json.RawMessage, 0, len(associates))
+	for _, a := range associates {
+		fields, err := v.render(a)
+		if err != nil {
+			return nil, err
+		}
+		rendered = append(rendered, fields)
+	}
+	return rendered, nil
+}
+
+// GetFieldVisibility returns the visibility of every restrictable field.
+func (app *Application) GetFieldVisibility(w http.ResponseWriter, r *http.Request) {
+	policy := app.fieldVisibility()
+
+	fields := map[string]string{}
+	for _, field := range data.RestrictableAssociateFields {
+		fields[field] = data.VisibilityPublic
+		if visibility, ok := policy[field]; ok {
+			fields[field] = visibility
+		}
+	}
+
+	out, _ := json.Marshal(fields)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+// UpdateFieldVisibility changes the visibility of the fields in the body,
+// an object of field name to public, manager, self or hr. Fields it leaves
+// out keep their visibility.
+func (app *Application) UpdateFieldVisibility(w http.ResponseWriter, r *http.Request) {
+	var changes data.FieldVisibilityPolicy
+	err := json.NewDecoder(r.Body).Decode(&changes)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	if len(changes) == 0 {
+		app.errorJSON(w, errors.New("name at least one field"))
+		return
+	}
+	if err := changes.Validate(); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	policy := app.fieldVisibility()
+	for field, visibility := range changes {
+		policy[field] = visibility
+	}
+
+	encoded, _ := json.Marshal(policy)
+	err = app.Models.AppSettings.Upsert(data.FieldVisibilitySetting, string(encoded))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Field visibility updated",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
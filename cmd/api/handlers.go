@@ -43,12 +43,7 @@ func (app *Application) CreateAssociate(w http.ResponseWriter, r *http.Request)
 
     // Set default password if not provided
     if associate.Password == "" {
-        defaultPwd, err := app.Models.AppSettings.Get("default_password")
-        if err == nil && defaultPwd != nil && defaultPwd.Value != "" {
-            associate.Password = defaultPwd.Value
-        } else {
-            associate.Password = "password"
-        }
+        associate.Password = app.defaultPassword()
     }
 
 	id, err := app.Models.Associates.Insert(associate)
@@ -56,6 +51,27 @@ func (app *Application) CreateAssociate(w http.ResponseWriter, r *http.Request)
 		app.errorJSON(w, err)
 		return
 	}
+	app.directory.invalidate()
+
+	// The starting salary opens the compensation history
+	if associate.Salary > 0 {
+		startDate := associate.StartDate
+		if startDate.IsZero() {
+			startDate = time.Now()
+		}
+		_, err = app.Models.Compensation.Insert(data.CompensationEntry{
+			AssociateID:   id,
+			Amount:        associate.Salary,
+			Currency:      data.DefaultCurrency,
+			EffectiveDate: startDate,
+			Reason:        "Starting salary",
+			CreatedBy:     &app.currentUser(r).ID,
+		})
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+	}
 
 	payload := struct {
 		ID      int    `json:"id"`
@@ -94,81 +110,84 @@ func (app *Application) UpdateAssociate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Get current user ID from header (set by frontend)
-	currentUserIDStr := r.Header.Get("X-User-ID")
-	if currentUserIDStr != "" {
-		currentUserID, err := strconv.Atoi(currentUserIDStr)
-		if err == nil && currentUserID == id {
-			// User is editing their own profile - check permissions
-			currentUser, err := app.Models.Associates.GetOne(currentUserID)
-			if err == nil {
-				// Get allowed roles
-				profileEditRolesSetting, _ := app.Models.AppSettings.Get("profile_edit_roles")
-				allowedRoles := "CEO,Head of People"
-				if profileEditRolesSetting != nil && profileEditRolesSetting.Value != "" {
-					allowedRoles = profileEditRolesSetting.Value
-				}
-
-				// Check if user has permission
-				roles := strings.Split(allowedRoles, ",")
-				hasPermission := false
-				for _, role := range roles {
-					if strings.EqualFold(strings.TrimSpace(role), currentUser.Title) {
-						hasPermission = true
-						break
-					}
-				}
-
-				// If no permission, check for restricted field changes
-				if !hasPermission {
-					changedFields := []string{}
-					
-					if updatedAssociate.FirstName != "" && updatedAssociate.FirstName != existingAssociate.FirstName {
-						changedFields = append(changedFields, "First Name")
-					}
-					if updatedAssociate.LastName != "" && updatedAssociate.LastName != existingAssociate.LastName {
-						changedFields = append(changedFields, "Last Name")
-					}
-					if updatedAssociate.Title != "" && updatedAssociate.Title != existingAssociate.Title {
-						changedFields = append(changedFields, "Title")
-					}
-					if updatedAssociate.Department != "" && updatedAssociate.Department != existingAssociate.Department {
-						changedFields = append(changedFields, "Department")
-					}
-					if updatedAssociate.Office != "" && updatedAssociate.Office != existingAssociate.Office {
-						changedFields = append(changedFields, "Office")
-					}
-					if updatedAssociate.EmplStatus != "" && updatedAssociate.EmplStatus != existingAssociate.EmplStatus {
-						changedFields = append(changedFields, "Employment Status")
-					}
-					if updatedAssociate.Email != "" && updatedAssociate.Email != existingAssociate.Email {
-						changedFields = append(changedFields, "Work Email")
-					}
-					if updatedAssociate.Salary != 0 && updatedAssociate.Salary != existingAssociate.Salary {
-						changedFields = append(changedFields, "Salary")
-					}
-					if !updatedAssociate.DOB.IsZero() && !updatedAssociate.DOB.Equal(existingAssociate.DOB) {
-						changedFields = append(changedFields, "Date of Birth")
-					}
-					if !updatedAssociate.StartDate.IsZero() && !updatedAssociate.StartDate.Equal(existingAssociate.StartDate) {
-						changedFields = append(changedFields, "Start Date")
-					}
-
-					if len(changedFields) > 0 {
-						errorMsg := "You don't have permission to edit: " + strings.Join(changedFields, ", ") + ". Contact People Team."
-						app.errorJSON(w, errors.New(errorMsg))
-						return
-					}
-				}
-			}
+	currentUser := app.currentUser(r)
+	if !app.can(currentUser, data.PermAssociatesManage) {
+		if currentUser == nil || currentUser.ID != id {
+			app.errorJSON(w, errors.New("forbidden: you can only edit your own profile"), http.StatusForbidden)
+			return
+		}
+
+		// User is editing their own profile - check for restricted field changes
+		changedFields := []string{}
+		
+		if updatedAssociate.FirstName != "" && updatedAssociate.FirstName != existingAssociate.FirstName {
+			changedFields = append(changedFields, "First Name")
+		}
+		if updatedAssociate.LastName != "" && updatedAssociate.LastName != existingAssociate.LastName {
+			changedFields = append(changedFields, "Last Name")
+		}
+		if updatedAssociate.Title != "" && updatedAssociate.Title != existingAssociate.Title {
+			changedFields = append(changedFields, "Title")
+		}
+		if updatedAssociate.Department != "" && updatedAssociate.Department != existingAssociate.Department {
+			changedFields = append(changedFields, "Department")
+		}
+		if updatedAssociate.Office != "" && updatedAssociate.Office != existingAssociate.Office {
+			changedFields = append(changedFields, "Office")
+		}
+		if updatedAssociate.EmplStatus != "" && updatedAssociate.EmplStatus != existingAssociate.EmplStatus {
+			changedFields = append(changedFields, "Employment Status")
+		}
+		if updatedAssociate.Email != "" && updatedAssociate.Email != existingAssociate.Email {
+			changedFields = append(changedFields, "Work Email")
+		}
+		if updatedAssociate.Salary != 0 && updatedAssociate.Salary != existingAssociate.Salary {
+			changedFields = append(changedFields, "Salary")
+		}
+		if !updatedAssociate.DOB.IsZero() && !updatedAssociate.DOB.Equal(existingAssociate.DOB) {
+			changedFields = append(changedFields, "Date of Birth")
+		}
+		if !updatedAssociate.StartDate.IsZero() && !updatedAssociate.StartDate.Equal(existingAssociate.StartDate) {
+			changedFields = append(changedFields, "Start Date")
+		}
+
+		if len(changedFields) > 0 {
+			errorMsg := "You don't have permission to edit: " + strings.Join(changedFields, ", ") + ". Contact People Team."
+			app.errorJSON(w, errors.New(errorMsg))
+			return
 		}
 	}
 
 	err = app.Models.Associates.Update(id, updatedAssociate)
 	if err != nil {
+		if errors.Is(err, data.ErrManagerCycle) {
+			app.errorJSON(w, err, http.StatusConflict)
+			return
+		}
 		app.errorJSON(w, err)
 		return
 	}
+	app.directory.invalidate()
+
+	// A new salary is recorded as a salary change so the history keeps it
+	if updatedAssociate.Salary != 0 && updatedAssociate.Salary != existingAssociate.Salary {
+		currency, err := app.Models.Compensation.CurrentCurrency(id)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+		_, _, err = app.Models.SalaryChanges.Insert(data.SalaryChange{
+			AssociateID: id,
+			NewSalary:   updatedAssociate.Salary,
+			Currency:    currency,
+			Reason:      "Changed on the associate's profile",
+			CreatedBy:   &currentUser.ID,
+		})
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+	}
 
 	payload := struct {
 		Message string `json:"message"`
@@ -196,6 +215,7 @@ func (app *Application) DeleteAssociate(w http.ResponseWriter, r *http.Request)
          app.errorJSON(w, err)
          return
     }
+    app.directory.invalidate()
 
     payload := struct {
         Message string `json:"message"`
@@ -225,20 +245,75 @@ func (app *Application) GetAssociate(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    out, _ := json.Marshal(associate)
+    // Only the fields the field visibility policy shows this caller
+    view, err := app.newAssociateView(r)
+    if err != nil {
+        app.errorJSON(w, err)
+        return
+    }
+    fields, err := view.render(*associate)
+    if err != nil {
+        app.errorJSON(w, err)
+        return
+    }
+
+    out, _ := json.Marshal(fields)
     w.Header().Set("Content-Type", "application/json")
     w.WriteHeader(http.StatusOK)
     w.Write(out)
 }
 
+// GetAllAssociates lists associates, filtered and sorted in SQL. With limit
+// or offset the response is a page with the total; without, it is the plain
+// array of every match, as before, and the total is in X-Total-Count.
 func (app *Application) GetAllAssociates(w http.ResponseWriter, r *http.Request) {
     log.Println("Hit GetAllAssociates handler")
-	associates, err := app.Models.Associates.GetAll()
+	// Only the fields the field visibility policy shows this caller
+	view, err := app.newAssociateView(r)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
-	out, _ := json.Marshal(associates)
+
+	query, err := view.parseAssociateQuery(r.URL.Query())
+	if err != nil {
+		if errors.Is(err, errForbiddenField) {
+			app.errorJSON(w, err, http.StatusForbidden)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	associates, total, err := app.Models.Associates.List(query.Filter, query.Sort, query.Limit, query.Offset)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	rendered, err := view.renderAll(associates)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	var out []byte
+	if query.Paginated {
+		out, _ = json.Marshal(struct {
+			Associates []map[string]json.RawMessage `json:"associates"`
+			Total      int                          `json:"total"`
+			Limit      int                          `json:"limit"`
+			Offset     int                          `json:"offset"`
+		}{
+			Associates: rendered,
+			Total:      total,
+			Limit:      query.Limit,
+			Offset:     query.Offset,
+		})
+	} else {
+		out, _ = json.Marshal(rendered)
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write(out)
@@ -291,26 +366,67 @@ func (app *Application) errorJSON(w http.ResponseWriter, err error, status ...in
 	w.Write(out)
 }
 
+// taskPayload is a task as clients send it. The approvers are resolved by the
+// server, so whatever a client sends for them is ignored.
+type taskPayload struct {
+	data.Task
+	Approvers     json.RawMessage `json:"approvers"`
+	EffectiveDate string          `json:"effective_date"`
+}
+
+// task returns the payload's task with its YYYY-MM-DD effective date parsed.
+func (p taskPayload) task() (data.Task, error) {
+	task := p.Task
+	if p.EffectiveDate != "" {
+		date, err := time.Parse("2006-01-02", p.EffectiveDate)
+		if err != nil {
+			return task, errors.New("effective_date must be YYYY-MM-DD")
+		}
+		task.EffectiveDate = &date
+	}
+	return task, nil
+}
+
 func (app *Application) CreateTask(w http.ResponseWriter, r *http.Request) {
-	var task data.Task
+	var input taskPayload
 
-	err := json.NewDecoder(r.Body).Decode(&task)
+	err := json.NewDecoder(r.Body).Decode(&input)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
+	task, err := input.task()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	// The caller is the requester; it decides who approves the task
+	task.RequesterID = app.currentUser(r).ID
 
-    // Validation for Salary Increase
-    if task.TaskName == "Salary Increase" {
-        // Check if Value is a valid number
-        var salary int
-        _, err := fmt.Sscan(task.Value, &salary)
-        if err != nil || salary < 0 {
-             app.errorJSON(w, errors.New("invalid salary value: must be a positive number"))
-             return
-        }
+    if err := app.validateTaskValue(task); err != nil {
+        app.errorJSON(w, err)
+        return
     }
 
+	requester, err := app.Models.Associates.GetOne(task.RequesterID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	// The task type's workflow decides who approves it
+	subject, err := app.taskSubject(task, requester)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	task.Approvers, err = app.resolveTaskSteps(requester, subject, task.TaskName)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	task.Status = data.TaskPending
+
 	id, err := app.Models.Tasks.Insert(task)
 	if err != nil {
 		app.errorJSON(w, err)
@@ -331,12 +447,55 @@ func (app *Application) CreateTask(w http.ResponseWriter, r *http.Request) {
 	w.Write(out)
 }
 
+// validateTaskValue checks the value of task types that carry a number. A
+// salary increase must also name an existing associate and raise their
+// salary.
+func (app *Application) validateTaskValue(task data.Task) error {
+    // Validation for Salary Increase
+    if task.TaskName == data.TaskSalaryIncrease {
+        // Check if Value is a valid number
+        salary, err := strconv.Atoi(strings.TrimSpace(task.Value))
+        if err != nil || salary <= 0 {
+             return errors.New("invalid salary value: must be a positive number")
+        }
+
+        associate, err := app.Models.Associates.GetOne(data.SalaryTaskAssociate(task))
+        if err != nil {
+            if errors.Is(err, sql.ErrNoRows) {
+                return errors.New("the associate in TargetValue does not exist")
+            }
+            return err
+        }
+        // Decreases are recorded through POST /associates/{id}/compensation
+        if salary <= associate.Salary {
+            return fmt.Errorf("a salary increase must be above the current salary of %d", associate.Salary)
+        }
+    } else if task.EffectiveDate != nil {
+        return errors.New("effective_date only applies to salary increases")
+    }
+    return nil
+}
+
+// GetTasks lists every task or, with ?awaiting_decision=true, the pending
+// tasks with a step waiting on the caller.
 func (app *Application) GetTasks(w http.ResponseWriter, r *http.Request) {
 	tasks, err := app.Models.Tasks.GetAll()
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
+
+	if r.URL.Query().Get("awaiting_decision") == "true" {
+		currentUser := app.currentUser(r)
+		awaiting := []data.Task{}
+		for _, task := range tasks {
+			if len(app.actionableTaskSteps(currentUser, &task, false)) > 0 {
+				awaiting = append(awaiting, task)
+			}
+		}
+		tasks = awaiting
+	}
+
 	out, _ := json.Marshal(tasks)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -380,6 +539,7 @@ func (app *Application) Register(w http.ResponseWriter, r *http.Request) {
          app.errorJSON(w, err)
          return
     }
+    app.directory.invalidate()
     
     associate.ID = id
 
@@ -413,8 +573,59 @@ func (app *Application) Login(w http.ResponseWriter, r *http.Request) {
         app.errorJSON(w, errors.New("invalid credentials"), http.StatusUnauthorized)
         return
     }
-    
-    out, _ := json.Marshal(associate)
+
+    tokens, err := app.generateTokenPair(associate.ID)
+    if err != nil {
+        app.errorJSON(w, err, http.StatusInternalServerError)
+        return
+    }
+
+    // Associate fields stay at the top level so existing clients keep working
+    response := struct {
+        *data.Associate
+        *TokenPair
+    }{
+        Associate: associate,
+        TokenPair: tokens,
+    }
+
+    out, _ := json.Marshal(response)
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    w.Write(out)
+}
+
+func (app *Application) RefreshToken(w http.ResponseWriter, r *http.Request) {
+    var payload struct {
+        RefreshToken string `json:"refresh_token"`
+    }
+
+    err := json.NewDecoder(r.Body).Decode(&payload)
+    if err != nil {
+        app.errorJSON(w, err)
+        return
+    }
+
+    claims, err := app.parseToken(payload.RefreshToken, tokenTypeRefresh)
+    if err != nil {
+        app.errorJSON(w, err, http.StatusUnauthorized)
+        return
+    }
+
+    // Make sure the associate still exists before handing out new tokens
+    _, err = app.Models.Associates.GetOne(claims.Subject)
+    if err != nil {
+        app.errorJSON(w, errors.New("invalid credentials"), http.StatusUnauthorized)
+        return
+    }
+
+    tokens, err := app.generateTokenPair(claims.Subject)
+    if err != nil {
+        app.errorJSON(w, err, http.StatusInternalServerError)
+        return
+    }
+
+    out, _ := json.Marshal(tokens)
     w.Header().Set("Content-Type", "application/json")
     w.WriteHeader(http.StatusOK)
     w.Write(out)
@@ -428,6 +639,7 @@ func (app *Application) CreateThank(w http.ResponseWriter, r *http.Request) {
 		app.errorJSON(w, err)
 		return
 	}
+	thank.FromID = app.currentUser(r).ID
 
 	id, err := app.Models.Thanks.Insert(thank)
 	if err != nil {
@@ -470,6 +682,16 @@ func (app *Application) CreateTimeOffRequest(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	// Associates file for themselves; only pto.manage may file for someone else
+	currentUser := app.currentUser(r)
+	if req.AssociateID == 0 {
+		req.AssociateID = currentUser.ID
+	}
+	if req.AssociateID != currentUser.ID && !app.can(currentUser, data.PermPTOManage) {
+		app.errorJSON(w, errors.New("forbidden: you can only request time off for yourself"), http.StatusForbidden)
+		return
+	}
+
 	// Get requester details
 	requester, err := app.Models.Associates.GetOne(req.AssociateID)
 	if err != nil {
@@ -477,79 +699,20 @@ func (app *Application) CreateTimeOffRequest(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Calculate requested days
-	requestedDays := req.EndDate.Sub(req.StartDate).Hours()/24 + 1
-
-	// Get PTO settings and calculate balance
-	ptoDaysPerYearSetting, _ := app.Models.AppSettings.Get("pto_days_per_year")
-	ptoAccrualMethodSetting, _ := app.Models.AppSettings.Get("pto_accrual_method")
-
-	ptoDaysPerYear := 15.0
-	if ptoDaysPerYearSetting != nil && ptoDaysPerYearSetting.Value != "" {
-		if val, err := strconv.ParseFloat(ptoDaysPerYearSetting.Value, 64); err == nil {
-			ptoDaysPerYear = val
-		}
-	}
-
-	accrualMethod := "immediate"
-	if ptoAccrualMethodSetting != nil && ptoAccrualMethodSetting.Value != "" {
-		accrualMethod = ptoAccrualMethodSetting.Value
-	}
-
-	// Calculate PTO allocated
-	now := time.Now()
-	currentYear := now.Year()
-	yearStart := time.Date(currentYear, 1, 1, 0, 0, 0, 0, time.UTC)
-	
-	var ptoAllocated float64
-	if accrualMethod == "immediate" {
-		if requester.StartDate.Before(yearStart) || requester.StartDate.Equal(yearStart) || requester.StartDate.Year() == currentYear {
-			ptoAllocated = ptoDaysPerYear
-		}
-	} else {
-		startDate := yearStart
-		if requester.StartDate.After(yearStart) {
-			startDate = requester.StartDate
-		}
-		daysInYear := 365.0
-		daysSinceStart := now.Sub(startDate).Hours() / 24
-		if daysSinceStart > 0 {
-			ptoAllocated = (ptoDaysPerYear / daysInYear) * daysSinceStart
-		}
-	}
-
-	// Calculate PTO used (approved requests in current year)
-	allTimeOff, err := app.Models.TimeOffRequests.GetByAssociateID(req.AssociateID)
+	check, status, err := app.validateTimeOff(&req, requester)
 	if err != nil {
-		app.errorJSON(w, err)
+		app.errorJSON(w, err, status)
 		return
 	}
 
-	var ptoUsed float64
-	for _, timeOff := range allTimeOff {
-		if timeOff.Status == "Approved" && timeOff.StartDate.Year() == currentYear {
-			days := timeOff.EndDate.Sub(timeOff.StartDate).Hours()/24 + 1
-			ptoUsed += days
-		}
-	}
-
-	ptoRemaining := ptoAllocated - ptoUsed
-
-	// Check if request exceeds remaining PTO
-	if requestedDays > ptoRemaining {
-		// Add a warning flag to the request but still allow it (manager can approve/reject)
-		// Optionally, you could reject it here with an error
-		// For now, we'll allow it but the manager will see it's over the limit
-		req.Status = "Pending" // Will require manager approval
-	}
-
 	// Determine approver based on hierarchy
-	// 1. If requester is CEO or Head of People -> auto-approve (no approver needed)
-	// 2. If requester is a manager (has direct reports) -> CEO or Head of People approves
-	// 3. Regular employee -> their manager approves
+	// 1. If requester holds timeoff.auto_approve -> auto-approve (no approver needed)
+	// 2. If the leave type needs no approval and the balance covers it -> auto-approve
+	// 3. If requester is a manager (has direct reports) -> someone with timeoff.approve_all approves
+	// 4. Regular employee -> their manager approves
 
-	isCEO := requester.Title == "CEO"
-	isHeadOfPeople := requester.Title == "Head of People"
+	isAutoApproved := app.can(requester, data.PermTimeOffAutoApprove) ||
+		(!check.LeaveType.RequiresApproval && !check.OverBalance)
 
     // Check AppSettings for other exempt titles
     exemptTitlesSetting, _ := app.Models.AppSettings.Get("time_off_exempt_titles")
@@ -564,8 +727,8 @@ func (app *Application) CreateTimeOffRequest(w http.ResponseWriter, r *http.Requ
         }
     }
 
-	if isCEO || isHeadOfPeople || isExemptTitle {
-		// Auto-approve for CEO, Head of People, and configured exempt titles
+	if isAutoApproved || isExemptTitle {
+		// Auto-approve for privileged associates and configured exempt titles
 		req.ApproverID = nil
 		req.Status = "Approved"
 	} else {
@@ -588,11 +751,17 @@ func (app *Application) CreateTimeOffRequest(w http.ResponseWriter, r *http.Requ
              }
              
              if isManager {
-                 // Requester is a manager but has no manager assigned -> Auto to CEO/Head of People
+                 // Requester is a manager but has no manager assigned -> route to an approver of last resort
+                 approverIDs, err := app.Models.Roles.GetAssociateIDsWithPermission(data.PermTimeOffApproveAll)
+                 if err != nil {
+                     app.errorJSON(w, err)
+                     return
+                 }
+
                  var approverID *int
-                 for _, assoc := range allAssociates {
-                     if assoc.Title == "CEO" || assoc.Title == "Head of People" {
-                         approverID = &assoc.ID
+                 for _, candidate := range approverIDs {
+                     if candidate != req.AssociateID {
+                         approverID = &candidate
                          break
                      }
                  }
@@ -602,7 +771,12 @@ func (app *Application) CreateTimeOffRequest(w http.ResponseWriter, r *http.Requ
 		req.Status = "Pending"
 	}
 
-	id, err := app.Models.TimeOffRequests.Insert(req)
+	comment := ""
+	if req.Status == data.TimeOffApproved {
+		comment = "Approved automatically"
+	}
+
+	id, err := app.Models.TimeOffRequests.Insert(req, currentUser.ID, comment)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
@@ -622,6 +796,44 @@ func (app *Application) CreateTimeOffRequest(w http.ResponseWriter, r *http.Requ
 	w.Write(out)
 }
 
+// listSubject reads the associate whose requests, entries or approval queue
+// a listing is for from the query parameter name. Callers may list their own,
+// those of anyone below them in the manager chain, or anyone's with one of
+// perms.
+func (app *Application) listSubject(w http.ResponseWriter, r *http.Request, name, value string, perms ...string) (int, bool) {
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid "+name+" parameter"))
+		return 0, false
+	}
+
+	currentUser := app.currentUser(r)
+	if id == currentUser.ID {
+		return id, true
+	}
+	for _, perm := range perms {
+		if app.can(currentUser, perm) {
+			return id, true
+		}
+	}
+
+	associate, err := app.Models.Associates.GetOne(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.errorJSON(w, errors.New("associate not found"), http.StatusNotFound)
+			return 0, false
+		}
+		app.errorJSON(w, err)
+		return 0, false
+	}
+	if !app.inManagerChain(currentUser.ID, associate) {
+		app.errorJSON(w, errors.New("forbidden: "+name+" must be you or one of your reports"), http.StatusForbidden)
+		return 0, false
+	}
+
+	return id, true
+}
+
 func (app *Application) GetAllTimeOffRequests(w http.ResponseWriter, r *http.Request) {
     // Check query parameters for filtering
     associateIDStr := r.URL.Query().Get("associate_id")
@@ -632,20 +844,30 @@ func (app *Application) GetAllTimeOffRequests(w http.ResponseWriter, r *http.Req
 
     if associateIDStr != "" {
         // Employee view: get my requests
-        var associateID int
-        _, err = fmt.Sscan(associateIDStr, &associateID)
-        if err == nil {
-             requests, err = app.Models.TimeOffRequests.GetByAssociateID(associateID)
+        associateID, ok := app.listSubject(w, r, "associate_id", associateIDStr, data.PermTimeOffApproveAll, data.PermPTOManage)
+        if !ok {
+            return
         }
+        requests, err = app.Models.TimeOffRequests.GetByAssociateID(associateID)
     } else if approverIDStr != "" {
-        // Manager view: get requests I need to approve
-        var approverID int
-        _, err = fmt.Sscan(approverIDStr, &approverID)
+        // Manager view: get requests I need to approve, including those of
+        // approvers who delegated to me
+        approverID, ok := app.listSubject(w, r, "approver_id", approverIDStr, data.PermTimeOffApproveAll, data.PermPTOManage)
+        if !ok {
+            return
+        }
+        var approverIDs []int
+        approverIDs, err = app.approverQueue(approverID)
         if err == nil {
-             requests, err = app.Models.TimeOffRequests.GetByApproverID(approverID)
+             requests, err = app.Models.TimeOffRequests.GetByApproverIDs(approverIDs)
         }
     } else {
         // Admin view: get all requests
+        currentUser := app.currentUser(r)
+        if !app.can(currentUser, data.PermTimeOffApproveAll) && !app.can(currentUser, data.PermPTOManage) {
+            app.errorJSON(w, errors.New("forbidden: pass associate_id or approver_id to list requests"), http.StatusForbidden)
+            return
+        }
         requests, err = app.Models.TimeOffRequests.GetAll()
     }
 
@@ -654,6 +876,12 @@ func (app *Application) GetAllTimeOffRequests(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Approvers see what each request charges, half days and hours included
+	if err := app.annotateTimeOffDurations(requests); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
 	out, _ := json.Marshal(requests)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -674,6 +902,15 @@ func (app *Application) GetTimeOffRequest(w http.ResponseWriter, r *http.Request
         app.errorJSON(w, err)
         return
     }
+    if len(app.timeOffActorRoles(app.currentUser(r), req)) == 0 {
+        app.errorJSON(w, errors.New("forbidden: you cannot view this request"), http.StatusForbidden)
+        return
+    }
+
+    if err := app.annotateTimeOffDurations([]*data.TimeOffRequest{req}); err != nil {
+        app.errorJSON(w, err)
+        return
+    }
 
     out, _ := json.Marshal(req)
     w.Header().Set("Content-Type", "application/json")
@@ -681,41 +918,129 @@ func (app *Application) GetTimeOffRequest(w http.ResponseWriter, r *http.Request
     w.Write(out)
 }
 
+// timeOffActorRoles lists the capacities in which user acts on req.
+func (app *Application) timeOffActorRoles(user *data.Associate, req *data.TimeOffRequest) []string {
+	var roles []string
+	if user == nil {
+		return roles
+	}
+	if user.ID == req.AssociateID {
+		// Nobody decides their own request, whatever else they may approve
+		return append(roles, data.TimeOffActorRequester)
+	}
+	if req.ApproverID != nil && *req.ApproverID == user.ID {
+		roles = append(roles, data.TimeOffActorApprover)
+	} else if app.delegatedApprover(user, req.ApproverID, req.AssociateID) != nil {
+		// The approver's delegate acts as the approver
+		roles = append(roles, data.TimeOffActorApprover)
+	} else if escalated, _ := app.escalatedTo(user, req.EscalatedTo, req.AssociateID); escalated {
+		// So does whoever a stale request was escalated to, and their delegate
+		roles = append(roles, data.TimeOffActorApprover)
+	}
+	if app.can(user, data.PermTimeOffApproveAll) {
+		roles = append(roles, data.TimeOffActorAdmin)
+	}
+	return roles
+}
+
+// UpdateTimeOffStatus moves a request through its lifecycle. Only the
+// transitions in data.TimeOffTransitions are accepted, and only from the
+// actors listed for them.
 func (app *Application) UpdateTimeOffStatus(w http.ResponseWriter, r *http.Request) {
-    idStr := chi.URLParam(r, "id")
-    var id int
-    _, err := fmt.Sscan(idStr, &id)
-    if err != nil {
-        app.errorJSON(w, err)
-        return
-    }
+	idStr := chi.URLParam(r, "id")
+	var id int
+	_, err := fmt.Sscan(idStr, &id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	var payload struct {
+		Status  string `json:"status"`
+		Comment string `json:"comment"`
+	}
+
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	req, err := app.Models.TimeOffRequests.GetOne(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	currentUser := app.currentUser(r)
+	allowed, exists := data.CanTransitionTimeOff(req.Status, payload.Status, app.timeOffActorRoles(currentUser, req))
+	if !exists {
+		app.errorJSON(w, fmt.Errorf("cannot move a request from %s to %s", req.Status, payload.Status), http.StatusConflict)
+		return
+	}
+	if !allowed {
+		app.errorJSON(w, fmt.Errorf("forbidden: you cannot move this request to %s", payload.Status), http.StatusForbidden)
+		return
+	}
+
+	onBehalfOf := app.delegatedApprover(currentUser, req.ApproverID, req.AssociateID)
+	if onBehalfOf == nil && (req.ApproverID == nil || *req.ApproverID != currentUser.ID) {
+		_, onBehalfOf = app.escalatedTo(currentUser, req.EscalatedTo, req.AssociateID)
+	}
+	err = app.Models.TimeOffRequests.Transition(id, req.Status, payload.Status, currentUser.ID, onBehalfOf, payload.Comment)
+	if err != nil {
+		if errors.Is(err, data.ErrStatusChanged) {
+			app.errorJSON(w, err, http.StatusConflict)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	response := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Status updated successfully",
+	}
+
+	out, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+// GetTimeOffHistory lists a request's status changes for its requester,
+// approver and admins.
+func (app *Application) GetTimeOffHistory(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	var id int
+	_, err := fmt.Sscan(idStr, &id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	req, err := app.Models.TimeOffRequests.GetOne(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
 
-    var payload struct {
-        Status string `json:"status"`
-    }
-    
-    err = json.NewDecoder(r.Body).Decode(&payload)
-    if err != nil {
-        app.errorJSON(w, err)
-        return
-    }
+	if len(app.timeOffActorRoles(app.currentUser(r), req)) == 0 {
+		app.errorJSON(w, errors.New("forbidden: you cannot view this request's history"), http.StatusForbidden)
+		return
+	}
 
-    err = app.Models.TimeOffRequests.UpdateStatus(id, payload.Status)
-    if err != nil {
-        app.errorJSON(w, err)
-        return
-    }
+	history, err := app.Models.TimeOffRequests.GetHistory(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
 
-    response := struct {
-        Message string `json:"message"`
-    }{
-        Message: "Status updated successfully",
-    }
-    
-    out, _ := json.Marshal(response)
-    w.Header().Set("Content-Type", "application/json")
-    w.WriteHeader(http.StatusOK)
-    w.Write(out)
+	out, _ := json.Marshal(history)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
 }
 
 func (app *Application) CreateOffice(w http.ResponseWriter, r *http.Request) {
@@ -897,6 +1222,25 @@ func (app *Application) DeleteDocumentCategory(w http.ResponseWriter, r *http.Re
     w.Write(out)
 }
 
+// canChangePendingTimeOff allows the requester or an admin to edit or delete
+// a request until it is decided. Decided requests change only through
+// UpdateTimeOffStatus so their history stays intact.
+func (app *Application) canChangePendingTimeOff(w http.ResponseWriter, r *http.Request, req *data.TimeOffRequest, action string) bool {
+	if req.Status != data.TimeOffPending {
+		app.errorJSON(w, fmt.Errorf("only pending requests can be %s", action), http.StatusConflict)
+		return false
+	}
+
+	for _, role := range app.timeOffActorRoles(app.currentUser(r), req) {
+		if role == data.TimeOffActorRequester || role == data.TimeOffActorAdmin {
+			return true
+		}
+	}
+
+	app.errorJSON(w, errors.New("forbidden: only the requester or an admin can change this request"), http.StatusForbidden)
+	return false
+}
+
 func (app *Application) UpdateTimeOffRequest(w http.ResponseWriter, r *http.Request) {
     idStr := chi.URLParam(r, "id")
     var id int
@@ -913,6 +1257,34 @@ func (app *Application) UpdateTimeOffRequest(w http.ResponseWriter, r *http.Requ
         return
     }
 
+    existing, err := app.Models.TimeOffRequests.GetOne(id)
+    if err != nil {
+        app.errorJSON(w, err)
+        return
+    }
+    if !app.canChangePendingTimeOff(w, r, existing, "edited") {
+        return
+    }
+
+    if req.LeaveType == "" {
+        req.LeaveType = existing.LeaveType
+    }
+
+    requester, err := app.Models.Associates.GetOne(existing.AssociateID)
+    if err != nil {
+        app.errorJSON(w, err)
+        return
+    }
+
+    // The edit is checked as a new request would be; it stays with its
+    // approver, who sees whether it now exceeds the balance
+    req.ID = id
+    check, status, err := app.validateTimeOff(&req, requester)
+    if err != nil {
+        app.errorJSON(w, err, status)
+        return
+    }
+
     err = app.Models.TimeOffRequests.Update(id, req)
     if err != nil {
         app.errorJSON(w, err)
@@ -920,9 +1292,11 @@ func (app *Application) UpdateTimeOffRequest(w http.ResponseWriter, r *http.Requ
     }
 
     payload := struct {
-        Message string `json:"message"`
+        OverBalance bool   `json:"over_balance"`
+        Message     string `json:"message"`
     }{
-        Message: "Time off request updated successfully",
+        OverBalance: check.OverBalance,
+        Message:     "Time off request updated successfully",
     }
     
     out, _ := json.Marshal(payload)
@@ -940,6 +1314,15 @@ func (app *Application) DeleteTimeOffRequest(w http.ResponseWriter, r *http.Requ
         return
     }
 
+    existing, err := app.Models.TimeOffRequests.GetOne(id)
+    if err != nil {
+        app.errorJSON(w, err)
+        return
+    }
+    if !app.canChangePendingTimeOff(w, r, existing, "deleted") {
+        return
+    }
+
     err = app.Models.TimeOffRequests.Delete(id)
     if err != nil {
         app.errorJSON(w, err)
@@ -988,13 +1371,77 @@ func (app *Application) UpdateTask(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    var task data.Task
-    err = json.NewDecoder(r.Body).Decode(&task)
+    var input taskPayload
+    err = json.NewDecoder(r.Body).Decode(&input)
+    if err != nil {
+        app.errorJSON(w, err)
+        return
+    }
+    task, err := input.task()
+    if err != nil {
+        app.errorJSON(w, err)
+        return
+    }
+
+    existing, err := app.Models.Tasks.GetOne(id)
     if err != nil {
         app.errorJSON(w, err)
         return
     }
 
+    // Only the requester or a task manager edits a task, and only until its first decision
+    currentUser := app.currentUser(r)
+    if currentUser.ID != existing.RequesterID && !app.can(currentUser, data.PermTasksManage) {
+        app.errorJSON(w, errors.New("forbidden: only the requester or a task manager can edit this task"), http.StatusForbidden)
+        return
+    }
+    if task.Status != "" && !strings.EqualFold(task.Status, existing.Status) {
+        app.errorJSON(w, errors.New("a task's status changes through POST /tasks/{id}/decision"))
+        return
+    }
+    if task.TaskName != "" && task.TaskName != existing.TaskName {
+        app.errorJSON(w, errors.New("a task's type cannot be changed"))
+        return
+    }
+    if !strings.EqualFold(existing.Status, data.TaskPending) {
+        app.errorJSON(w, errors.New("only pending tasks can be edited"), http.StatusConflict)
+        return
+    }
+    for _, step := range existing.Approvers {
+        if step.DecidedBy != nil {
+            app.errorJSON(w, errors.New("a task cannot be edited once a decision has been made"), http.StatusConflict)
+            return
+        }
+    }
+
+    task.TaskName = existing.TaskName
+    task.RequesterID = existing.RequesterID
+    if err := app.validateTaskValue(task); err != nil {
+        app.errorJSON(w, err)
+        return
+    }
+
+    // A salary increase moved to another associate goes to that associate's chain
+    if task.TaskName == data.TaskSalaryIncrease && data.SalaryTaskAssociate(task) != data.SalaryTaskAssociate(*existing) {
+        requester, err := app.Models.Associates.GetOne(existing.RequesterID)
+        if err != nil {
+            app.errorJSON(w, err)
+            return
+        }
+        subject, err := app.taskSubject(task, requester)
+        if err != nil {
+            app.errorJSON(w, err)
+            return
+        }
+        task.Approvers, err = app.resolveTaskSteps(requester, subject, task.TaskName)
+        if err != nil {
+            app.errorJSON(w, err)
+            return
+        }
+    } else {
+        task.Approvers = nil
+    }
+
     err = app.Models.Tasks.Update(id, task)
     if err != nil {
         app.errorJSON(w, err)
@@ -1022,8 +1469,39 @@ func (app *Application) DeleteTask(w http.ResponseWriter, r *http.Request) {
         return
     }
 
+    existing, err := app.Models.Tasks.GetOne(id)
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            app.errorJSON(w, errors.New("task not found"), http.StatusNotFound)
+            return
+        }
+        app.errorJSON(w, err)
+        return
+    }
+
+    // Like editing: the requester until the first decision, or a task manager
+    currentUser := app.currentUser(r)
+    if !app.can(currentUser, data.PermTasksManage) {
+        if currentUser.ID != existing.RequesterID {
+            app.errorJSON(w, errors.New("forbidden: only the requester or a task manager can delete this task"), http.StatusForbidden)
+            return
+        }
+        if !taskUndecided(existing) {
+            app.errorJSON(w, errors.New("forbidden: a task can only be deleted by its requester until the first decision"), http.StatusForbidden)
+            return
+        }
+    }
+
     err = app.Models.Tasks.Delete(id)
     if err != nil {
+        if errors.Is(err, data.ErrTaskApproved) {
+            app.errorJSON(w, err, http.StatusConflict)
+            return
+        }
+        if errors.Is(err, sql.ErrNoRows) {
+            app.errorJSON(w, errors.New("task not found"), http.StatusNotFound)
+            return
+        }
         app.errorJSON(w, err)
         return
     }
@@ -1153,16 +1631,7 @@ func (app *Application) LikeThank(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var payload struct {
-		AssociateID int `json:"associate_id"`
-	}
-	err = json.NewDecoder(r.Body).Decode(&payload)
-	if err != nil {
-		app.errorJSON(w, err)
-		return
-	}
-
-	err = app.Models.Thanks.Like(thankID, payload.AssociateID)
+	err = app.Models.Thanks.Like(thankID, app.currentUser(r).ID)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
@@ -1183,16 +1652,7 @@ func (app *Application) UnlikeThank(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var payload struct {
-		AssociateID int `json:"associate_id"`
-	}
-	err = json.NewDecoder(r.Body).Decode(&payload)
-	if err != nil {
-		app.errorJSON(w, err)
-		return
-	}
-
-	err = app.Models.Thanks.Unlike(thankID, payload.AssociateID)
+	err = app.Models.Thanks.Unlike(thankID, app.currentUser(r).ID)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
@@ -1214,8 +1674,7 @@ func (app *Application) AddCommentToThank(w http.ResponseWriter, r *http.Request
 	}
 
 	var payload struct {
-		AssociateID int    `json:"associate_id"`
-		Comment     string `json:"comment"`
+		Comment string `json:"comment"`
 	}
 	err = json.NewDecoder(r.Body).Decode(&payload)
 	if err != nil {
@@ -1223,7 +1682,7 @@ func (app *Application) AddCommentToThank(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	id, err := app.Models.Thanks.AddComment(thankID, payload.AssociateID, payload.Comment)
+	id, err := app.Models.Thanks.AddComment(thankID, app.currentUser(r).ID, payload.Comment)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
@@ -1334,8 +1793,34 @@ func (app *Application) DeleteMenuPermission(w http.ResponseWriter, r *http.Requ
 	w.Write(out)
 }
 
+// publicSettings may be read by every associate; other settings need
+// settings.manage.
+var publicSettings = map[string]bool{
+	"working_hours_per_day":         true,
+	"approval_escalation_sla_hours": true,
+	"time_off_coverage_scope":       true,
+	"time_off_coverage_max_out":     true,
+	"sidebar_order":                 true,
+	"dashboard_order":               true,
+}
+
+// writeOnlySettings are never read back through the API, not even by
+// settings managers.
+var writeOnlySettings = map[string]bool{
+	"default_password": true,
+}
+
 func (app *Application) GetSetting(w http.ResponseWriter, r *http.Request) {
 	key := chi.URLParam(r, "key")
+	if writeOnlySettings[key] {
+		app.errorJSON(w, errors.New("forbidden: "+key+" cannot be read"), http.StatusForbidden)
+		return
+	}
+	if !publicSettings[key] && !app.can(app.currentUser(r), data.PermSettingsManage) {
+		app.errorJSON(w, errors.New("forbidden: missing permission "+data.PermSettingsManage), http.StatusForbidden)
+		return
+	}
+
 	setting, err := app.Models.AppSettings.Get(key)
     
 	if err != nil {
@@ -1402,6 +1887,12 @@ func (app *Application) ChangePassword(w http.ResponseWriter, r *http.Request) {
         return
     }
 
+    currentUser := app.currentUser(r)
+    if currentUser.ID != id && !app.can(currentUser, data.PermAssociatesManage) {
+        app.errorJSON(w, errors.New("forbidden: you can only change your own password"), http.StatusForbidden)
+        return
+    }
+
     var payload struct {
         Password string `json:"password"`
     }
@@ -1442,6 +1933,8 @@ func (app *Application) CreateTimeEntry(w http.ResponseWriter, r *http.Request)
         app.errorJSON(w, err)
         return
     }
+    // Associates log their own hours
+    entry.AssociateID = app.currentUser(r).ID
 
     // Calculate overtime and set status
     if entry.Hours > 8 {
@@ -1451,7 +1944,7 @@ func (app *Application) CreateTimeEntry(w http.ResponseWriter, r *http.Request)
         user, err := app.Models.Associates.GetOne(entry.AssociateID)
         isExempt := false
         if err == nil {
-             if user.Title == "CEO" {
+             if app.can(user, data.PermOvertimeExempt) {
                  isExempt = true
              } else {
                  // Check AppSettings for other exempt titles
@@ -1508,19 +2001,27 @@ func (app *Application) GetTimeEntries(w http.ResponseWriter, r *http.Request) {
     var err error
 
     if associateIDStr != "" {
-        var associateID int
-        _, err = fmt.Sscan(associateIDStr, &associateID)
-        if err == nil {
-             entries, err = app.Models.TimeEntries.GetByAssociateID(associateID)
+        associateID, ok := app.listSubject(w, r, "associate_id", associateIDStr, data.PermTimeEntryApproveAll)
+        if !ok {
+            return
         }
+        entries, err = app.Models.TimeEntries.GetByAssociateID(associateID)
     } else if managerIDStr != "" {
-        var managerID int
-        _, err = fmt.Sscan(managerIDStr, &managerID)
+        managerID, ok := app.listSubject(w, r, "manager_id", managerIDStr, data.PermTimeEntryApproveAll)
+        if !ok {
+            return
+        }
+        var managerIDs []int
+        managerIDs, err = app.approverQueue(managerID)
         if err == nil {
-             entries, err = app.Models.TimeEntries.GetByManagerID(managerID)
+             entries, err = app.Models.TimeEntries.GetByManagerIDs(managerIDs)
         }
     } else {
         // Admin view or all entries
+        if !app.can(app.currentUser(r), data.PermTimeEntryApproveAll) {
+            app.errorJSON(w, errors.New("forbidden: pass associate_id or manager_id to list time entries"), http.StatusForbidden)
+            return
+        }
         entries, err = app.Models.TimeEntries.GetAll()
     }
 
@@ -1554,6 +2055,29 @@ func (app *Application) DeleteTimeEntry(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	timeEntry, err := app.Models.TimeEntries.GetOne(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.errorJSON(w, errors.New("time entry not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	// The owner until the entry is decided, or whoever may approve any entry
+	currentUser := app.currentUser(r)
+	if !app.can(currentUser, data.PermTimeEntryApproveAll) {
+		if currentUser.ID != timeEntry.AssociateID {
+			app.errorJSON(w, errors.New("forbidden: only the owner or an admin can delete this time entry"), http.StatusForbidden)
+			return
+		}
+		if timeEntry.Status != data.TimeEntryPending {
+			app.errorJSON(w, errors.New("forbidden: a time entry can only be deleted by its owner while it is pending"), http.StatusForbidden)
+			return
+		}
+	}
+
 	err = app.Models.TimeEntries.Delete(id)
 	if err != nil {
 		app.errorJSON(w, err)
@@ -1605,70 +2129,115 @@ func (app *Application) ApproveTimeEntry(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Get current user ID from header
-	currentUserIDStr := r.Header.Get("X-User-ID")
-	if currentUserIDStr == "" {
-		app.errorJSON(w, errors.New("user authentication required"))
+	currentUser := app.currentUser(r)
+	if currentUser == nil {
+		app.errorJSON(w, errors.New("user authentication required"), http.StatusUnauthorized)
 		return
 	}
+	currentUserID := currentUser.ID
 
-	currentUserID, err := strconv.Atoi(currentUserIDStr)
+	// Get the associate who created the time entry
+	associate, err := app.Models.Associates.GetOne(timeEntry.AssociateID)
 	if err != nil {
-		app.errorJSON(w, errors.New("invalid user id"))
+		app.errorJSON(w, err)
 		return
 	}
 
-	// Get current user
-	currentUser, err := app.Models.Associates.GetOne(currentUserID)
-	if err != nil {
-		app.errorJSON(w, err)
+	canApprove, onBehalfOf := app.timeEntryApprover(currentUser, timeEntry, associate)
+	if !canApprove {
+		app.errorJSON(w, errors.New("unauthorized: only the manager, second approver, escalation approver, their delegates, or admin can approve this time entry"))
 		return
 	}
 
-	// Get the associate who created the time entry
-	associate, err := app.Models.Associates.GetOne(timeEntry.AssociateID)
+	err = app.Models.TimeEntries.UpdateStatus(id, payload.Status, currentUserID, onBehalfOf)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	// Check if user has permission to approve
-	// 1. User is the associate's manager
-	isManager := associate.ManagerID != nil && *associate.ManagerID == currentUserID
-	
-	// 2. User is admin (CEO or Head of People)
-	isAdmin := currentUser.Title == "CEO" || currentUser.Title == "Head of People"
+	response := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Time entry status updated",
+	}
 	
+	out, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+// timeEntryApprover reports whether user may decide timeEntry, filed by
+// associate, and on whose behalf when they act as a delegate.
+func (app *Application) timeEntryApprover(user *data.Associate, timeEntry *data.TimeEntry, associate *data.Associate) (bool, *int) {
+	// 1. User is the associate's manager
+	isManager := associate.ManagerID != nil && *associate.ManagerID == user.ID
+
+	// 2. User can approve any time entry
+	isAdmin := app.can(user, data.PermTimeEntryApproveAll)
+
 	// 3. For overtime, check if user is second approver
 	isSecondApprover := false
+	var secondApproverID *int
 	if timeEntry.OvertimeHours > 0 {
-		secondApproverSetting, _ := app.Models.AppSettings.Get("second_approver_id")
-		if secondApproverSetting != nil && secondApproverSetting.Value != "" {
-			secondApproverID, err := strconv.Atoi(secondApproverSetting.Value)
-			if err == nil && secondApproverID == currentUserID {
-				isSecondApprover = true
-			}
+		secondApproverID = app.secondApproverID()
+		isSecondApprover = secondApproverID != nil && *secondApproverID == user.ID
+	}
+
+	// 4. The entry was escalated to the user, or to someone they stand in for
+	isEscalatedTo, onBehalfOf := app.escalatedTo(user, timeEntry.EscalatedTo, associate.ID)
+
+	// 5. User is the delegate of the manager or second approver, and decides on their behalf
+	if !isManager && !isAdmin && !isSecondApprover && !isEscalatedTo {
+		onBehalfOf = app.delegatedApprover(user, associate.ManagerID, associate.ID)
+		if onBehalfOf == nil {
+			onBehalfOf = app.delegatedApprover(user, secondApproverID, associate.ID)
 		}
 	}
 
-	if !isManager && !isAdmin && !isSecondApprover {
-		app.errorJSON(w, errors.New("unauthorized: only the manager, second approver, or admin can approve this time entry"))
+	return isManager || isAdmin || isSecondApprover || isEscalatedTo || onBehalfOf != nil, onBehalfOf
+}
+
+// GetTimeEntryEscalations lists the steps an overtime entry was escalated up
+// the chain while it waited for approval. Only the entry's owner and those
+// who may approve it see them.
+func (app *Application) GetTimeEntryEscalations(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
 		return
 	}
 
-	err = app.Models.TimeEntries.UpdateStatus(id, payload.Status)
+	timeEntry, err := app.Models.TimeEntries.GetOne(id)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	response := struct {
-		Message string `json:"message"`
-	}{
-		Message: "Time entry status updated",
+	currentUser := app.currentUser(r)
+	if currentUser == nil {
+		app.errorJSON(w, errors.New("user authentication required"), http.StatusUnauthorized)
+		return
 	}
-	
-	out, _ := json.Marshal(response)
+	if currentUser.ID != timeEntry.AssociateID {
+		associate, err := app.Models.Associates.GetOne(timeEntry.AssociateID)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+		if canApprove, _ := app.timeEntryApprover(currentUser, timeEntry, associate); !canApprove {
+			app.errorJSON(w, errors.New("forbidden: only the entry's owner and its approvers can see its escalations"), http.StatusForbidden)
+			return
+		}
+	}
+
+	escalations, err := app.Models.Escalations.GetBySubject(data.EscalationTimeEntry, id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	out, _ := json.Marshal(escalations)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write(out)
@@ -1676,6 +2245,10 @@ func (app *Application) ApproveTimeEntry(w http.ResponseWriter, r *http.Request)
 
 // Holiday Handlers
 
+// GetHolidays lists the holidays entered one by one or, with ?year=, every
+// holiday observed that year including those generated from holiday rules.
+// The year view resolves holidays for ?office_id= (default: the caller's
+// office; 0 for the company-wide calendar).
 func (app *Application) GetHolidays(w http.ResponseWriter, r *http.Request) {
 	yearStr := r.URL.Query().Get("year")
 	
@@ -1688,7 +2261,12 @@ func (app *Application) GetHolidays(w http.ResponseWriter, r *http.Request) {
 			app.errorJSON(w, errors.New("invalid year parameter"))
 			return
 		}
-		holidays, err = app.Models.Holidays.GetByYear(year)
+		officeID, officeErr := app.holidayOfficeParam(r)
+		if officeErr != nil {
+			app.errorJSON(w, officeErr)
+			return
+		}
+		holidays, err = app.Models.Holidays.Materialize(year, officeID)
 	} else {
 		holidays, err = app.Models.Holidays.GetAll()
 	}
@@ -1705,34 +2283,14 @@ func (app *Application) GetHolidays(w http.ResponseWriter, r *http.Request) {
 }
 
 func (app *Application) CreateHoliday(w http.ResponseWriter, r *http.Request) {
-	// Check admin permission
-	currentUserIDStr := r.Header.Get("X-User-ID")
-	if currentUserIDStr == "" {
-		app.errorJSON(w, errors.New("user authentication required"))
-		return
-	}
-	
-	currentUserID, err := strconv.Atoi(currentUserIDStr)
-	if err != nil {
-		app.errorJSON(w, errors.New("invalid user id"))
-		return
-	}
-	
-	currentUser, err := app.Models.Associates.GetOne(currentUserID)
+	var holiday data.Holiday
+	err := json.NewDecoder(r.Body).Decode(&holiday)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
-	
-	isAdmin := currentUser.Title == "CEO" || currentUser.Title == "Head of People"
-	if !isAdmin {
-		app.errorJSON(w, errors.New("unauthorized: only admin can create holidays"))
-		return
-	}
-	
-	var holiday data.Holiday
-	err = json.NewDecoder(r.Body).Decode(&holiday)
-	if err != nil {
+
+	if err := app.validateOfficeIDs(holiday.OfficeIDs); err != nil {
 		app.errorJSON(w, err)
 		return
 	}
@@ -1758,34 +2316,9 @@ func (app *Application) CreateHoliday(w http.ResponseWriter, r *http.Request) {
 }
 
 func (app *Application) UpdateHoliday(w http.ResponseWriter, r *http.Request) {
-	// Check admin permission
-	currentUserIDStr := r.Header.Get("X-User-ID")
-	if currentUserIDStr == "" {
-		app.errorJSON(w, errors.New("user authentication required"))
-		return
-	}
-	
-	currentUserID, err := strconv.Atoi(currentUserIDStr)
-	if err != nil {
-		app.errorJSON(w, errors.New("invalid user id"))
-		return
-	}
-	
-	currentUser, err := app.Models.Associates.GetOne(currentUserID)
-	if err != nil {
-		app.errorJSON(w, err)
-		return
-	}
-	
-	isAdmin := currentUser.Title == "CEO" || currentUser.Title == "Head of People"
-	if !isAdmin {
-		app.errorJSON(w, errors.New("unauthorized: only admin can update holidays"))
-		return
-	}
-	
 	idStr := chi.URLParam(r, "id")
 	var id int
-	_, err = fmt.Sscan(idStr, &id)
+	_, err := fmt.Sscan(idStr, &id)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
@@ -1797,6 +2330,11 @@ func (app *Application) UpdateHoliday(w http.ResponseWriter, r *http.Request) {
 		app.errorJSON(w, err)
 		return
 	}
+
+	if err := app.validateOfficeIDs(holiday.OfficeIDs); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
 	
 	err = app.Models.Holidays.Update(id, holiday)
 	if err != nil {
@@ -1817,34 +2355,9 @@ func (app *Application) UpdateHoliday(w http.ResponseWriter, r *http.Request) {
 }
 
 func (app *Application) DeleteHoliday(w http.ResponseWriter, r *http.Request) {
-	// Check admin permission
-	currentUserIDStr := r.Header.Get("X-User-ID")
-	if currentUserIDStr == "" {
-		app.errorJSON(w, errors.New("user authentication required"))
-		return
-	}
-	
-	currentUserID, err := strconv.Atoi(currentUserIDStr)
-	if err != nil {
-		app.errorJSON(w, errors.New("invalid user id"))
-		return
-	}
-	
-	currentUser, err := app.Models.Associates.GetOne(currentUserID)
-	if err != nil {
-		app.errorJSON(w, err)
-		return
-	}
-	
-	isAdmin := currentUser.Title == "CEO" || currentUser.Title == "Head of People"
-	if !isAdmin {
-		app.errorJSON(w, errors.New("unauthorized: only admin can delete holidays"))
-		return
-	}
-	
 	idStr := chi.URLParam(r, "id")
 	var id int
-	_, err = fmt.Sscan(idStr, &id)
+	_, err := fmt.Sscan(idStr, &id)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
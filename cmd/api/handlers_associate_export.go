@@ -0,0 +1,259 @@
+package main
+
+import (
+	"backend/internal/data"
+	"backend/internal/xlsx"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportColumn is a column of an associate export, named by its Associate
+// JSON field.
+type exportColumn struct {
+	name  string
+	value func(a data.Associate) any
+}
+
+func exportDate(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Format("2006-01-02")
+}
+
+// associateExportColumns are the columns an export may carry, in the order
+// they are written when ?columns= does not choose.
+var associateExportColumns = []exportColumn{
+	{"id", func(a data.Associate) any { return a.ID }},
+	{"FirstName", func(a data.Associate) any { return a.FirstName }},
+	{"LastName", func(a data.Associate) any { return a.LastName }},
+	{"Email", func(a data.Associate) any { return a.Email }},
+	{"Title", func(a data.Associate) any { return a.Title }},
+	{"Department", func(a data.Associate) any { return a.Department }},
+	{"Office", func(a data.Associate) any { return a.Office }},
+	{"Status", func(a data.Associate) any { return a.Status }},
+	{"EmplStatus", func(a data.Associate) any { return a.EmplStatus }},
+	{"StartDate", func(a data.Associate) any { return exportDate(a.StartDate) }},
+	{"manager_id", func(a data.Associate) any {
+		if a.ManagerID == nil {
+			return nil
+		}
+		return *a.ManagerID
+	}},
+	{"Salary", func(a data.Associate) any { return a.Salary }},
+	{"DOB", func(a data.Associate) any { return exportDate(a.DOB) }},
+	{"PhoneNumber", func(a data.Associate) any { return a.PhoneNumber }},
+	{"PrivateEmail", func(a data.Associate) any { return a.PrivateEmail }},
+	{"Gender", func(a data.Associate) any { return a.Gender }},
+	{"profile_picture", func(a data.Associate) any { return a.ProfilePicture }},
+}
+
+// hrOnlyExportFields leave in exports made by HR only, whatever the field
+// visibility policy shows in the app.
+var hrOnlyExportFields = map[string]bool{
+	"Salary":       true,
+	"DOB":          true,
+	"PhoneNumber":  true,
+	"PrivateEmail": true,
+}
+
+// exportColumns reads ?columns=, a comma-separated list of fields, defaulting
+// to every field the caller may export. Callers other than HR may export
+// the fields the visibility policy shows everyone, except hrOnlyExportFields.
+func (v *associateView) exportColumns(value string) ([]exportColumn, error) {
+	allowed := func(field string) bool {
+		return v.hr || (!hrOnlyExportFields[field] && v.policy.Visible(field, data.Audience{}))
+	}
+
+	var columns []exportColumn
+	if strings.TrimSpace(value) == "" {
+		for _, c := range associateExportColumns {
+			if allowed(c.name) {
+				columns = append(columns, c)
+			}
+		}
+		return columns, nil
+	}
+
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		var column *exportColumn
+		for i := range associateExportColumns {
+			if strings.EqualFold(associateExportColumns[i].name, name) {
+				column = &associateExportColumns[i]
+			}
+		}
+		if column == nil {
+			return nil, errors.New("cannot export unknown column " + name)
+		}
+		if !allowed(column.name) {
+			return nil, fmt.Errorf("%w: you cannot export %s", errForbiddenField, column.name)
+		}
+		columns = append(columns, *column)
+	}
+	return columns, nil
+}
+
+// ExportAssociates streams the associates matching the list filters as
+// ?format=csv (default), xlsx or jsonl, with the ?columns= chosen. Rows are
+// written as they are read, so the export is never held in memory.
+func (app *Application) ExportAssociates(w http.ResponseWriter, r *http.Request) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = "csv"
+	}
+	contentTypes := map[string]string{
+		"csv":   "text/csv; charset=utf-8",
+		"xlsx":  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		"jsonl": "application/x-ndjson",
+	}
+	if contentTypes[format] == "" {
+		app.errorJSON(w, errors.New("format must be csv, xlsx or jsonl"))
+		return
+	}
+
+	view, err := app.newAssociateView(r)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	query, err := view.parseAssociateQuery(r.URL.Query())
+	if err != nil {
+		if errors.Is(err, errForbiddenField) {
+			app.errorJSON(w, err, http.StatusForbidden)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+	columns, err := view.exportColumns(r.URL.Query().Get("columns"))
+	if err != nil {
+		if errors.Is(err, errForbiddenField) {
+			app.errorJSON(w, err, http.StatusForbidden)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	app.streamAssociateExport(w, format, contentTypes[format], query, columns)
+}
+
+// streamAssociateExport writes the export. Once the first bytes are out the
+// status cannot change, so a failure part way aborts the response rather
+// than end it as though the export were complete.
+func (app *Application) streamAssociateExport(w http.ResponseWriter, format, contentType string, query *associateQuery, columns []exportColumn) {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="associates-`+time.Now().Format("2006-01-02")+`.`+format+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	var writeRow func(values []any) error
+	var finish func() error
+	switch format {
+	case "xlsx":
+		sheet, err := xlsx.NewWriter(w, "Associates")
+		if err != nil {
+			abortExport(err)
+		}
+		header := make([]any, len(names))
+		for i, name := range names {
+			header[i] = name
+		}
+		if err := sheet.WriteRow(header); err != nil {
+			abortExport(err)
+		}
+		writeRow, finish = sheet.WriteRow, sheet.Close
+
+	case "jsonl":
+		out := bufio.NewWriter(w)
+		writeRow = func(values []any) error {
+			out.WriteByte('{')
+			for i, value := range values {
+				if i > 0 {
+					out.WriteByte(',')
+				}
+				key, _ := json.Marshal(names[i])
+				encoded, err := json.Marshal(value)
+				if err != nil {
+					return err
+				}
+				out.Write(key)
+				out.WriteByte(':')
+				out.Write(encoded)
+			}
+			_, err := out.WriteString("}\n")
+			return err
+		}
+		finish = out.Flush
+
+	default:
+		out := csv.NewWriter(w)
+		if err := out.Write(names); err != nil {
+			abortExport(err)
+		}
+		writeRow = func(values []any) error {
+			record := make([]string, len(values))
+			for i, value := range values {
+				switch v := value.(type) {
+				case int:
+					record[i] = strconv.Itoa(v)
+				case string:
+					record[i] = v
+				}
+			}
+			return out.Write(record)
+		}
+		finish = func() error {
+			out.Flush()
+			return out.Error()
+		}
+	}
+
+	values := make([]any, len(columns))
+	err := app.Models.Associates.Each(query.Filter, query.Sort, query.Limit, query.Offset, func(a data.Associate) error {
+		for i, c := range columns {
+			values[i] = c.value(a)
+			if text, ok := values[i].(string); ok && format != "jsonl" {
+				values[i] = spreadsheetText(text)
+			}
+		}
+		return writeRow(values)
+	})
+	if err == nil {
+		err = finish()
+	}
+	if err != nil {
+		abortExport(err)
+	}
+}
+
+// spreadsheetText keeps text a spreadsheet would read as a formula, such as
+// a name starting with "=", from being evaluated when the export is opened
+// by prefixing it with an apostrophe.
+func spreadsheetText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// abortExport drops the connection of an export that failed part way, so
+// the client sees an error instead of a short file.
+func abortExport(err error) {
+	log.Printf("Associate export failed: %v", err)
+	panic(http.ErrAbortHandler)
+}
@@ -0,0 +1,488 @@
+package main
+
+import (
+	"backend/internal/data"
+	"backend/internal/xlsx"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// maxAssociateImportBytes bounds the file accepted by POST /associates/import
+	maxAssociateImportBytes = 5 << 20
+	maxAssociateImportRows  = 5000
+)
+
+// associateImportFields are the fields an import fills: Associate JSON
+// fields, and ManagerEmail for the manager, who is either on file already
+// or created by the same import.
+var associateImportFields = []string{
+	"FirstName", "LastName", "Email", "Title", "Department", "Office", "Status", "StartDate",
+	"EmplStatus", "Salary", "DOB", "PhoneNumber", "Gender", "PrivateEmail", "ManagerEmail",
+}
+
+type associateImportRow struct {
+	// Row is the line of the CSV file or the row of the spreadsheet
+	Row   int    `json:"row"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	// Status is new or error
+	Status string   `json:"status"`
+	Errors []string `json:"errors,omitempty"`
+	ID     *int     `json:"id,omitempty"`
+}
+
+// sheetLine is one non-blank line of an uploaded file.
+type sheetLine struct {
+	Number int
+	Cells  []string
+}
+
+// defaultPassword is the password of accounts created without one.
+func (app *Application) defaultPassword() string {
+	setting, err := app.Models.AppSettings.Get("default_password")
+	if err == nil && setting != nil && setting.Value != "" {
+		return setting.Value
+	}
+	return "password"
+}
+
+// readAssociateUpload returns the uploaded file, its format and the column
+// mapping. The file is the "file" field of a multipart form or the raw
+// request body; the format comes from ?format=, the file name, the content
+// type, or the file itself. The mapping is the "mapping" form field or
+// ?mapping=.
+func readAssociateUpload(r *http.Request) ([]byte, string, string, error) {
+	var content []byte
+	var filename string
+	contentType := r.Header.Get("Content-Type")
+	mapping := r.URL.Query().Get("mapping")
+
+	if strings.HasPrefix(contentType, "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, "", "", errors.New("the upload must be in a form field named file")
+		}
+		defer file.Close()
+
+		content, err = io.ReadAll(file)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename = strings.ToLower(header.Filename)
+		contentType = header.Header.Get("Content-Type")
+		if value := r.PostFormValue("mapping"); value != "" {
+			mapping = value
+		}
+	} else {
+		var err error
+		content, err = io.ReadAll(r.Body)
+		if err != nil {
+			return nil, "", "", err
+		}
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		switch {
+		case strings.HasSuffix(filename, ".xlsx"), strings.HasPrefix(contentType, "application/vnd.openxmlformats-officedocument.spreadsheetml"):
+			format = "xlsx"
+		case strings.HasSuffix(filename, ".csv"), strings.HasPrefix(contentType, "text/csv"):
+			format = "csv"
+		case bytes.HasPrefix(content, []byte("PK\x03\x04")):
+			format = "xlsx"
+		default:
+			format = "csv"
+		}
+	}
+	if format != "csv" && format != "xlsx" {
+		return nil, "", "", errors.New("format must be csv or xlsx")
+	}
+
+	return content, format, mapping, nil
+}
+
+// readSheetLines returns the non-blank lines of a CSV or XLSX file.
+func readSheetLines(content []byte, format string) ([]sheetLine, error) {
+	var lines []sheetLine
+	add := func(number int, cells []string) {
+		for _, c := range cells {
+			if strings.TrimSpace(c) != "" {
+				lines = append(lines, sheetLine{Number: number, Cells: cells})
+				return
+			}
+		}
+	}
+
+	if format == "xlsx" {
+		rows, err := xlsx.ReadRows(content)
+		if err != nil {
+			return nil, err
+		}
+		for i, cells := range rows {
+			add(i+1, cells)
+		}
+		return lines, nil
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(lines) == 0 {
+			record[0] = strings.TrimPrefix(record[0], "\ufeff")
+		}
+		line, _ := reader.FieldPos(0)
+		add(line, record)
+	}
+}
+
+// headerKey folds a column header or field name so "First Name",
+// "first_name" and "FirstName" are the same column. Only letters and digits
+// count.
+func headerKey(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// importColumns finds the column of each field. Columns are matched to the
+// fields by header, and mapping, an object of field name to header, names
+// the columns whose headers do not match.
+func importColumns(header []string, mappingJSON string) (map[string]int, error) {
+	positions := map[string]int{}
+	for i, name := range header {
+		if key := headerKey(name); key != "" {
+			if _, ok := positions[key]; !ok {
+				positions[key] = i
+			}
+		}
+	}
+
+	mapping := map[string]string{}
+	if mappingJSON != "" {
+		if err := json.Unmarshal([]byte(mappingJSON), &mapping); err != nil {
+			return nil, errors.New("mapping must be a JSON object of field name to column header")
+		}
+	}
+
+	columns := map[string]int{}
+	for field, column := range mapping {
+		known := false
+		for _, f := range associateImportFields {
+			known = known || f == field
+		}
+		if !known {
+			return nil, fmt.Errorf("cannot map unknown field %s", field)
+		}
+		i, ok := positions[headerKey(column)]
+		if !ok {
+			return nil, fmt.Errorf("the file has no %q column for %s", column, field)
+		}
+		columns[field] = i
+	}
+	for _, field := range associateImportFields {
+		if _, mapped := columns[field]; mapped {
+			continue
+		}
+		if i, ok := positions[headerKey(field)]; ok {
+			columns[field] = i
+		}
+	}
+
+	var missing []string
+	for _, field := range []string{"FirstName", "LastName", "Email"} {
+		if _, ok := columns[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no column found for %s; name them in mapping", strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+// parseImportDate reads YYYY-MM-DD, or the serial day number a spreadsheet
+// stores a date cell as.
+func parseImportDate(value, format string) (time.Time, bool) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, true
+	}
+	if format == "xlsx" {
+		return xlsx.SerialDate(value)
+	}
+	return time.Time{}, false
+}
+
+// ImportAssociates creates associates from a CSV or XLSX file with a header
+// row. Departments and offices must already exist, and ManagerEmail names
+// an associate on file or on another row. With ?dry_run=true nothing is
+// saved; otherwise a file with any invalid row is rejected, and a valid one
+// is created in one transaction, with the default_password setting as the
+// password.
+func (app *Application) ImportAssociates(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxAssociateImportBytes)
+
+	content, format, mapping, err := readAssociateUpload(r)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	lines, err := readSheetLines(content, format)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	if len(lines) == 0 {
+		app.errorJSON(w, errors.New("the file is empty"))
+		return
+	}
+	if len(lines)-1 > maxAssociateImportRows {
+		app.errorJSON(w, fmt.Errorf("the file has more than %d rows", maxAssociateImportRows))
+		return
+	}
+
+	header := lines[0].Cells
+	columns, err := importColumns(header, mapping)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	departments, err := app.Models.Departments.GetAll()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	offices, err := app.Models.Offices.GetAll()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	existing, err := app.Models.Associates.GetEmailIDs()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	departmentNames := map[string]string{}
+	for _, d := range departments {
+		departmentNames[strings.ToLower(d.Name)] = d.Name
+	}
+	officeNames := map[string]string{}
+	for _, o := range offices {
+		officeNames[strings.ToLower(o.Name)] = o.Name
+	}
+
+	lines = lines[1:]
+	rows := make([]associateImportRow, len(lines))
+	imports := make([]data.AssociateImport, len(lines))
+	managerEmails := make([]string, len(lines))
+	fileEmails := map[string]int{} // email to index into rows
+
+	for i, line := range lines {
+		row := &rows[i]
+		row.Row = line.Number
+		field := func(name string) string {
+			if col, ok := columns[name]; ok && col < len(line.Cells) {
+				return strings.TrimSpace(line.Cells[col])
+			}
+			return ""
+		}
+		fail := func(format string, args ...any) {
+			row.Errors = append(row.Errors, fmt.Sprintf(format, args...))
+		}
+
+		a := data.Associate{
+			FirstName:    field("FirstName"),
+			LastName:     field("LastName"),
+			Email:        field("Email"),
+			Title:        field("Title"),
+			Status:       field("Status"),
+			EmplStatus:   field("EmplStatus"),
+			PhoneNumber:  field("PhoneNumber"),
+			Gender:       field("Gender"),
+			PrivateEmail: field("PrivateEmail"),
+		}
+		row.Email = a.Email
+		row.Name = strings.TrimSpace(a.FirstName + " " + a.LastName)
+
+		if a.FirstName == "" {
+			fail("FirstName is required")
+		}
+		if a.LastName == "" {
+			fail("LastName is required")
+		}
+		email := strings.ToLower(a.Email)
+		switch address, err := mail.ParseAddress(a.Email); {
+		case a.Email == "":
+			fail("Email is required")
+		case err != nil || address.Address != a.Email:
+			fail("Email is not a valid address")
+		default:
+			if _, ok := existing[email]; ok {
+				fail("an associate with email %s already exists", a.Email)
+			} else if first, ok := fileEmails[email]; ok {
+				fail("email %s is also on row %d", a.Email, rows[first].Row)
+			} else {
+				fileEmails[email] = i
+			}
+		}
+		if a.PrivateEmail != "" {
+			if address, err := mail.ParseAddress(a.PrivateEmail); err != nil || address.Address != a.PrivateEmail {
+				fail("PrivateEmail is not a valid address")
+			}
+		}
+
+		if value := field("Department"); value != "" {
+			if name, ok := departmentNames[strings.ToLower(value)]; ok {
+				a.Department = name
+			} else {
+				fail("unknown department: %s", value)
+			}
+		}
+		if value := field("Office"); value != "" {
+			if name, ok := officeNames[strings.ToLower(value)]; ok {
+				a.Office = name
+			} else {
+				fail("unknown office: %s", value)
+			}
+		}
+
+		if a.Status == "" {
+			a.Status = "Active"
+		}
+		a.StartDate = time.Now()
+		if value := field("StartDate"); value != "" {
+			date, ok := parseImportDate(value, format)
+			if !ok {
+				fail("StartDate must be a date in YYYY-MM-DD format")
+			}
+			a.StartDate = date
+		}
+		if value := field("DOB"); value != "" {
+			date, ok := parseImportDate(value, format)
+			if !ok {
+				fail("DOB must be a date in YYYY-MM-DD format")
+			}
+			a.DOB = date
+		}
+		if value := strings.NewReplacer(",", "", " ", "").Replace(field("Salary")); value != "" {
+			salary, err := strconv.ParseFloat(value, 64)
+			if err != nil || salary < 0 || salary != float64(int(salary)) {
+				fail("Salary must be a whole number")
+			}
+			a.Salary = int(salary)
+		}
+
+		managerEmails[i] = strings.ToLower(field("ManagerEmail"))
+		imports[i].Associate = a
+	}
+
+	// Managers resolve once every row's email is known
+	for i := range rows {
+		manager := managerEmails[i]
+		if manager == "" {
+			continue
+		}
+		if id, ok := existing[manager]; ok {
+			imports[i].Associate.ManagerID = &id
+		} else if j, ok := fileEmails[manager]; ok && j != i {
+			imports[i].ManagerRow = &j
+		} else if ok {
+			rows[i].Errors = append(rows[i].Errors, "an associate cannot manage themselves")
+		} else {
+			rows[i].Errors = append(rows[i].Errors, "unknown manager: "+manager)
+		}
+	}
+	for i := range rows {
+		seen := map[int]bool{i: true}
+		for j := imports[i].ManagerRow; j != nil; j = imports[*j].ManagerRow {
+			if seen[*j] {
+				rows[i].Errors = append(rows[i].Errors, "the managers on this file report to each other in a loop")
+				break
+			}
+			seen[*j] = true
+		}
+	}
+
+	counts := map[string]int{}
+	for i := range rows {
+		rows[i].Status = "new"
+		if len(rows[i].Errors) > 0 {
+			rows[i].Status = "error"
+		}
+		counts[rows[i].Status]++
+	}
+
+	status := http.StatusOK
+	imported := 0
+	switch {
+	case dryRun:
+	case counts["error"] > 0:
+		status = http.StatusUnprocessableEntity
+	case len(imports) > 0:
+		ids, err := app.Models.Associates.ImportMany(imports, app.defaultPassword(), &app.currentUser(r).ID)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+		app.directory.invalidate()
+		for i, id := range ids {
+			rows[i].ID = &id
+		}
+		imported = len(ids)
+		status = http.StatusCreated
+	}
+
+	mapped := map[string]string{}
+	for field, col := range columns {
+		mapped[field] = strings.TrimSpace(header[col])
+	}
+
+	payload := struct {
+		DryRun   bool                 `json:"dry_run"`
+		Format   string               `json:"format"`
+		Columns  map[string]string    `json:"columns"`
+		New      int                  `json:"new"`
+		Errors   int                  `json:"errors"`
+		Imported int                  `json:"imported"`
+		Rows     []associateImportRow `json:"rows"`
+	}{
+		DryRun:   dryRun,
+		Format:   format,
+		Columns:  mapped,
+		New:      counts["new"],
+		Errors:   counts["error"],
+		Imported: imported,
+		Rows:     rows,
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(out)
+}
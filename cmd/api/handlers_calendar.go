@@ -0,0 +1,404 @@
+package main
+
+import (
+	"backend/internal/calendar"
+	"backend/internal/data"
+	"backend/internal/ical"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxCalendarDays bounds the range GET /calendar will expand day by day.
+const maxCalendarDays = 366
+
+type calendarAbsence struct {
+	AssociateID int    `json:"associate_id"`
+	Name        string `json:"name"`
+	RequestID   int    `json:"request_id"`
+	Status      string `json:"status"`
+	DayPortion  string `json:"day_portion"`
+}
+
+type calendarDay struct {
+	Date    string            `json:"date"`
+	Weekend bool              `json:"weekend"`
+	Holiday *string           `json:"holiday"`
+	Out     []calendarAbsence `json:"out"`
+}
+
+// GetCalendar merges approved time off and holidays into one entry per day
+// from ?from= to ?to= (default: the next seven days), optionally limited to a
+// ?department= or the direct reports of ?manager_id=. Holidays are those of
+// ?office_id=, or the caller's office.
+func (app *Application) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	today := calendar.Date(time.Now())
+	from, err := parseDateParam(r, "from", today)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	to, err := parseDateParam(r, "to", from.AddDate(0, 0, 6))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	if to.Before(from) {
+		app.errorJSON(w, errors.New("to must not be before from"))
+		return
+	}
+	if to.Sub(from) > maxCalendarDays*24*time.Hour {
+		app.errorJSON(w, fmt.Errorf("the calendar can show at most %d days", maxCalendarDays))
+		return
+	}
+
+	filter := data.AbsenceFilter{Department: r.URL.Query().Get("department")}
+	if managerIDStr := r.URL.Query().Get("manager_id"); managerIDStr != "" {
+		managerID, err := strconv.Atoi(managerIDStr)
+		if err != nil {
+			app.errorJSON(w, errors.New("invalid manager_id parameter"))
+			return
+		}
+		filter.ManagerID = &managerID
+	}
+
+	booked, err := app.Models.TimeOffRequests.GetBooked(from, to, filter)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	officeID, err := app.holidayOfficeParam(r)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	cal := calendar.New(&app.Models.Holidays, officeID)
+	days := []calendarDay{}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		holidays, err := cal.HolidaysForYear(day.Year())
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+
+		key := day.Format("2006-01-02")
+		entry := calendarDay{
+			Date:    key,
+			Weekend: day.Weekday() == time.Saturday || day.Weekday() == time.Sunday,
+			Out:     []calendarAbsence{},
+		}
+		if h, ok := holidays[key]; ok {
+			entry.Holiday = &h.Name
+		}
+
+		for _, req := range booked {
+			if day.Before(calendar.Date(req.StartDate)) || day.After(calendar.Date(req.EndDate)) {
+				continue
+			}
+			entry.Out = append(entry.Out, calendarAbsence{
+				AssociateID: req.AssociateID,
+				Name:        req.EmployeeName,
+				RequestID:   req.ID,
+				Status:      req.Status,
+				DayPortion:  req.DayPortion,
+			})
+		}
+
+		days = append(days, entry)
+	}
+
+	response := struct {
+		From string        `json:"from"`
+		To   string        `json:"to"`
+		Days []calendarDay `json:"days"`
+	}{
+		From: from.Format("2006-01-02"),
+		To:   to.Format("2006-01-02"),
+		Days: days,
+	}
+
+	out, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// canSubscribe reports whether owner may follow feed: their own time off or
+// team, that of anyone below them in the chain, or their own department.
+// Holders of timeoff.approve_all or pto.manage may follow any scope.
+func (app *Application) canSubscribe(owner *data.Associate, feed data.CalendarFeed) (bool, error) {
+	if app.can(owner, data.PermTimeOffApproveAll) || app.can(owner, data.PermPTOManage) {
+		return true, nil
+	}
+
+	switch feed.Scope {
+	case data.FeedScopeAssociate, data.FeedScopeManager:
+		id, err := strconv.Atoi(feed.ScopeValue)
+		if err != nil {
+			return false, errors.New("scope_value must be an associate id")
+		}
+		if id == owner.ID {
+			return true, nil
+		}
+		subject, err := app.Models.Associates.GetOne(id)
+		if err != nil {
+			return false, err
+		}
+		return app.inManagerChain(owner.ID, subject), nil
+	case data.FeedScopeDepartment:
+		return feed.ScopeValue != "" && feed.ScopeValue == owner.Department, nil
+	}
+	return false, nil
+}
+
+// CreateCalendarFeed issues a subscription URL for one associate, a manager's
+// direct reports, or a department, within what canSubscribe allows the
+// caller. The token is only returned here.
+func (app *Application) CreateCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	var feed data.CalendarFeed
+	err := json.NewDecoder(r.Body).Decode(&feed)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	switch feed.Scope {
+	case data.FeedScopeAssociate, data.FeedScopeManager:
+		id, err := strconv.Atoi(feed.ScopeValue)
+		if err != nil {
+			app.errorJSON(w, errors.New("scope_value must be an associate id"))
+			return
+		}
+		if _, err := app.Models.Associates.GetOne(id); err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+	case data.FeedScopeDepartment:
+		if feed.ScopeValue == "" {
+			app.errorJSON(w, errors.New("scope_value must name a department"))
+			return
+		}
+	default:
+		app.errorJSON(w, errors.New("scope must be associate, manager or department"))
+		return
+	}
+
+	currentUser := app.currentUser(r)
+	allowed, err := app.canSubscribe(currentUser, feed)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	if !allowed {
+		app.errorJSON(w, errors.New("forbidden: you can only subscribe to yourself, your reports, or your department"), http.StatusForbidden)
+		return
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		app.errorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+	token := hex.EncodeToString(raw)
+
+	feed.OwnerID = currentUser.ID
+	id, err := app.Models.CalendarFeeds.Insert(feed, hashFeedToken(token))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		ID    int    `json:"id"`
+		Token string `json:"token"`
+		URL   string `json:"url"`
+	}{
+		ID:    id,
+		Token: token,
+		URL:   "/calendar-feeds/" + token + ".ics",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(out)
+}
+
+func (app *Application) GetCalendarFeeds(w http.ResponseWriter, r *http.Request) {
+	feeds, err := app.Models.CalendarFeeds.GetByOwner(app.currentUser(r).ID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	out, _ := json.Marshal(feeds)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+func (app *Application) DeleteCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	err = app.Models.CalendarFeeds.Delete(id, app.currentUser(r).ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.errorJSON(w, errors.New("feed not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Calendar feed revoked",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+// GetCalendarFeedICS serves a feed to calendar clients. It is reached without
+// a bearer token; the unguessable token in the URL is the credential. A feed
+// whose owner has left, or may no longer follow its scope, is gone.
+func (app *Application) GetCalendarFeedICS(w http.ResponseWriter, r *http.Request) {
+	feed, err := app.Models.CalendarFeeds.GetByTokenHash(hashFeedToken(chi.URLParam(r, "token")))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.errorJSON(w, errors.New("feed not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	owner, err := app.Models.Associates.GetOne(feed.OwnerID)
+	if err != nil {
+		app.errorJSON(w, errors.New("feed not found"), http.StatusNotFound)
+		return
+	}
+	if allowed, err := app.canSubscribe(owner, *feed); err != nil || !allowed {
+		app.errorJSON(w, errors.New("feed not found"), http.StatusNotFound)
+		return
+	}
+
+	var filter data.AbsenceFilter
+	var officeID *int
+	name := "WorkOps"
+	switch feed.Scope {
+	case data.FeedScopeAssociate, data.FeedScopeManager:
+		id, _ := strconv.Atoi(feed.ScopeValue)
+		associate, err := app.Models.Associates.GetOne(id)
+		if err != nil {
+			app.errorJSON(w, errors.New("feed not found"), http.StatusNotFound)
+			return
+		}
+		fullName := associate.FirstName + " " + associate.LastName
+		// Holidays follow the associate's, or the manager's, office
+		officeID, err = app.officeIDByName(associate.Office)
+		if err != nil {
+			app.errorJSON(w, err, http.StatusInternalServerError)
+			return
+		}
+		if feed.Scope == data.FeedScopeAssociate {
+			filter.AssociateID = &associate.ID
+			name += " - " + fullName
+		} else {
+			filter.ManagerID = &associate.ID
+			name += " - " + fullName + "'s team"
+		}
+	case data.FeedScopeDepartment:
+		filter.Department = feed.ScopeValue
+		name += " - " + feed.ScopeValue
+	}
+
+	// Clients poll the feed, so a bounded window keeps it small
+	today := calendar.Date(time.Now())
+	from, to := today.AddDate(0, -3, 0), today.AddDate(1, 0, 0)
+
+	booked, err := app.Models.TimeOffRequests.GetBooked(from, to, filter)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	var events []ical.Event
+	for _, req := range booked {
+		summary := req.EmployeeName + " - Out of office"
+		switch req.DayPortion {
+		case data.DayPortionAM:
+			summary += " (morning)"
+		case data.DayPortionPM:
+			summary += " (afternoon)"
+		case data.DayPortionHours:
+			if req.Hours != nil {
+				summary += fmt.Sprintf(" (%g hours)", *req.Hours)
+			}
+		}
+		events = append(events, ical.Event{
+			UID:        fmt.Sprintf("time-off-%d@workops", req.ID),
+			Summary:    summary,
+			Start:      calendar.Date(req.StartDate),
+			End:        calendar.Date(req.EndDate),
+			Categories: "Time Off",
+		})
+	}
+
+	cal := calendar.New(&app.Models.Holidays, officeID)
+	for year := from.Year(); year <= to.Year(); year++ {
+		holidays, err := cal.HolidaysForYear(year)
+		if err != nil {
+			app.errorJSON(w, err, http.StatusInternalServerError)
+			return
+		}
+		for key, h := range holidays {
+			date := calendar.Date(h.Date)
+			if date.Before(from) || date.After(to) {
+				continue
+			}
+			events = append(events, ical.Event{
+				UID:        "holiday-" + key + "@workops",
+				Summary:    h.Name,
+				Start:      date,
+				End:        date,
+				Categories: "Holiday",
+			})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].Start.Equal(events[j].Start) {
+			return events[i].Start.Before(events[j].Start)
+		}
+		return events[i].UID < events[j].UID
+	})
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	ical.Write(w, name, events)
+}
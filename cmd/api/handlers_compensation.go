@@ -0,0 +1,481 @@
+package main
+
+import (
+	"backend/internal/data"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// compensationSubject loads the associate named in the URL and checks that
+// the current user may see their pay: themselves, anyone above them in the
+// manager chain, or compensation.view.
+func (app *Application) compensationSubject(w http.ResponseWriter, r *http.Request) (*data.Associate, bool) {
+	associateID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return nil, false
+	}
+
+	associate, err := app.Models.Associates.GetOne(associateID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.errorJSON(w, errors.New("associate not found"), http.StatusNotFound)
+			return nil, false
+		}
+		app.errorJSON(w, err)
+		return nil, false
+	}
+
+	currentUser := app.currentUser(r)
+	isSelf := currentUser != nil && currentUser.ID == associate.ID
+	isManager := currentUser != nil && app.inManagerChain(currentUser.ID, associate)
+	if !isSelf && !isManager && !app.can(currentUser, data.PermCompensationView) {
+		app.errorJSON(w, errors.New("forbidden: you cannot view this associate's compensation"), http.StatusForbidden)
+		return nil, false
+	}
+
+	return associate, true
+}
+
+// GetCompensation returns an associate's compensation timeline: every salary
+// they have had, the changes still to take effect, and where the current
+// salary sits in the band for their title and office.
+func (app *Application) GetCompensation(w http.ResponseWriter, r *http.Request) {
+	associate, ok := app.compensationSubject(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := app.Models.Compensation.GetByAssociate(associate.ID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	scheduled, err := app.Models.SalaryChanges.GetScheduled(associate.ID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	currency, err := app.Models.Compensation.CurrentCurrency(associate.ID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	ranges, err := app.Models.SalaryRanges.GetAll()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	band := data.MatchSalaryRange(ranges, associate.Title, associate.Office)
+
+	var compaRatio *float64
+	if band != nil {
+		compaRatio = band.CompaRatio(associate.Salary, currency)
+	}
+
+	response := struct {
+		AssociateID int                      `json:"associate_id"`
+		Salary      int                      `json:"salary"`
+		Currency    string                   `json:"currency"`
+		SalaryRange *data.SalaryRange        `json:"salary_range"`
+		CompaRatio  *float64                 `json:"compa_ratio"`
+		History     []data.CompensationEntry `json:"history"`
+		Scheduled   []data.SalaryChange      `json:"scheduled"`
+	}{
+		AssociateID: associate.ID,
+		Salary:      associate.Salary,
+		Currency:    currency,
+		SalaryRange: band,
+		CompaRatio:  compaRatio,
+		History:     history,
+		Scheduled:   scheduled,
+	}
+
+	out, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+// CreateCompensationChange records a salary change outside the task
+// workflow. It takes effect at once unless it is dated later.
+func (app *Application) CreateCompensationChange(w http.ResponseWriter, r *http.Request) {
+	associateID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	var payload struct {
+		Amount        int    `json:"amount"`
+		Currency      string `json:"currency"`
+		EffectiveDate string `json:"effective_date"`
+		Reason        string `json:"reason"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if payload.Amount <= 0 {
+		app.errorJSON(w, errors.New("amount must be a positive number"))
+		return
+	}
+	if strings.TrimSpace(payload.Reason) == "" {
+		app.errorJSON(w, errors.New("a reason for the change is required"))
+		return
+	}
+
+	if _, err := app.Models.Associates.GetOne(associateID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.errorJSON(w, errors.New("associate not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	currency := payload.Currency
+	if currency == "" {
+		currency, err = app.Models.Compensation.CurrentCurrency(associateID)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+	}
+	currency, ok := data.NormalizeCurrency(currency)
+	if !ok {
+		app.errorJSON(w, errors.New("currency must be a three-letter ISO 4217 code"))
+		return
+	}
+
+	var effectiveDate time.Time
+	if payload.EffectiveDate != "" {
+		effectiveDate, err = time.Parse("2006-01-02", payload.EffectiveDate)
+		if err != nil {
+			app.errorJSON(w, errors.New("effective_date must be a date in YYYY-MM-DD format"))
+			return
+		}
+	}
+
+	currentUser := app.currentUser(r)
+	id, applied, err := app.Models.SalaryChanges.Insert(data.SalaryChange{
+		AssociateID:   associateID,
+		NewSalary:     payload.Amount,
+		Currency:      currency,
+		EffectiveDate: effectiveDate,
+		Reason:        strings.TrimSpace(payload.Reason),
+		CreatedBy:     &currentUser.ID,
+	})
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	status := data.SalaryChangeScheduled
+	if applied {
+		status = data.SalaryChangeApplied
+	}
+
+	response := struct {
+		ID      int    `json:"id"`
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}{
+		ID:      id,
+		Status:  status,
+		Message: "Salary change recorded",
+	}
+
+	out, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(out)
+}
+
+// CancelSalaryChange withdraws a scheduled salary change, whether entered by
+// hand or made by an approved task, before its effective date.
+func (app *Application) CancelSalaryChange(w http.ResponseWriter, r *http.Request) {
+	associateID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+	changeID, err := strconv.Atoi(chi.URLParam(r, "changeId"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid changeId parameter"))
+		return
+	}
+
+	err = app.Models.SalaryChanges.Cancel(associateID, changeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.errorJSON(w, errors.New("salary change not found"), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, data.ErrSalaryChangeNotScheduled) {
+			app.errorJSON(w, err, http.StatusConflict)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Salary change cancelled",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+// departmentCompaRatios is one department of the compa-ratio report.
+type departmentCompaRatios struct {
+	Department string `json:"department"`
+	Associates int    `json:"associates"`
+	// Compared counts the associates with a band in their salary's currency
+	Compared          int                   `json:"compared"`
+	BelowRange        int                   `json:"below_range"`
+	InRange           int                   `json:"in_range"`
+	AboveRange        int                   `json:"above_range"`
+	AverageCompaRatio *float64              `json:"average_compa_ratio"`
+	Members           []associateCompaRatio `json:"members"`
+}
+
+type associateCompaRatio struct {
+	AssociateID int               `json:"associate_id"`
+	Name        string            `json:"name"`
+	Title       string            `json:"title"`
+	Office      string            `json:"office"`
+	Salary      int               `json:"salary"`
+	Currency    string            `json:"currency"`
+	SalaryRange *data.SalaryRange `json:"salary_range"`
+	CompaRatio  *float64          `json:"compa_ratio"`
+}
+
+// GetCompaRatioReport compares salaries with the midpoint of their band, per
+// department (?department= for one).
+func (app *Application) GetCompaRatioReport(w http.ResponseWriter, r *http.Request) {
+	salaries, err := app.Models.Compensation.GetSalaries(r.URL.Query().Get("department"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	ranges, err := app.Models.SalaryRanges.GetAll()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	departments := []departmentCompaRatios{}
+	sums := []float64{}
+	for _, s := range salaries {
+		if len(departments) == 0 || departments[len(departments)-1].Department != s.Department {
+			departments = append(departments, departmentCompaRatios{Department: s.Department, Members: []associateCompaRatio{}})
+			sums = append(sums, 0)
+		}
+		dept := &departments[len(departments)-1]
+
+		member := associateCompaRatio{
+			AssociateID: s.AssociateID,
+			Name:        s.Name,
+			Title:       s.Title,
+			Office:      s.Office,
+			Salary:      s.Salary,
+			Currency:    s.Currency,
+			SalaryRange: data.MatchSalaryRange(ranges, s.Title, s.Office),
+		}
+		if member.SalaryRange != nil {
+			member.CompaRatio = member.SalaryRange.CompaRatio(s.Salary, s.Currency)
+		}
+		if member.CompaRatio != nil {
+			dept.Compared++
+			sums[len(sums)-1] += float64(s.Salary) / float64(member.SalaryRange.MidAmount)
+			switch {
+			case s.Salary < member.SalaryRange.MinAmount:
+				dept.BelowRange++
+			case s.Salary > member.SalaryRange.MaxAmount:
+				dept.AboveRange++
+			default:
+				dept.InRange++
+			}
+		}
+
+		dept.Associates++
+		dept.Members = append(dept.Members, member)
+	}
+
+	for i := range departments {
+		if departments[i].Compared > 0 {
+			avg := math.Round(sums[i]/float64(departments[i].Compared)*100) / 100
+			departments[i].AverageCompaRatio = &avg
+		}
+	}
+
+	out, _ := json.Marshal(departments)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+func (app *Application) GetSalaryRanges(w http.ResponseWriter, r *http.Request) {
+	ranges, err := app.Models.SalaryRanges.GetAll()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	out, _ := json.Marshal(ranges)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+// validateSalaryRange normalizes a band and checks that its office exists
+// and that no other band covers the same title and office.
+func (app *Application) validateSalaryRange(band *data.SalaryRange, id int) (int, error) {
+	band.Title = strings.TrimSpace(band.Title)
+	if err := band.Validate(); err != nil {
+		return http.StatusBadRequest, err
+	}
+	band.Currency, _ = data.NormalizeCurrency(band.Currency)
+
+	if band.OfficeID != nil {
+		offices, err := app.Models.Offices.GetAll()
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		known := false
+		for _, o := range offices {
+			known = known || o.ID == *band.OfficeID
+		}
+		if !known {
+			return http.StatusBadRequest, errors.New("unknown office_id: " + strconv.Itoa(*band.OfficeID))
+		}
+	}
+
+	existing, err := app.Models.SalaryRanges.Find(band.Title, band.OfficeID)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if existing != nil && existing.ID != id {
+		return http.StatusConflict, errors.New("a salary range for this title and office already exists")
+	}
+	return 0, nil
+}
+
+func (app *Application) CreateSalaryRange(w http.ResponseWriter, r *http.Request) {
+	var band data.SalaryRange
+	err := json.NewDecoder(r.Body).Decode(&band)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if status, err := app.validateSalaryRange(&band, 0); err != nil {
+		app.errorJSON(w, err, status)
+		return
+	}
+
+	id, err := app.Models.SalaryRanges.Insert(band)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		ID      int    `json:"id"`
+		Message string `json:"message"`
+	}{
+		ID:      id,
+		Message: "Salary range created successfully",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(out)
+}
+
+func (app *Application) UpdateSalaryRange(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	var band data.SalaryRange
+	err = json.NewDecoder(r.Body).Decode(&band)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if status, err := app.validateSalaryRange(&band, id); err != nil {
+		app.errorJSON(w, err, status)
+		return
+	}
+
+	err = app.Models.SalaryRanges.Update(id, band)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.errorJSON(w, errors.New("salary range not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Salary range updated successfully",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+func (app *Application) DeleteSalaryRange(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	err = app.Models.SalaryRanges.Delete(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Salary range deleted successfully",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
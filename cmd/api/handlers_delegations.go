@@ -0,0 +1,216 @@
+package main
+
+import (
+	"backend/internal/calendar"
+	"backend/internal/data"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// delegatedApprover returns approverID when user may act on approverID's
+// behalf today as their delegate, and nil otherwise. Nobody acts as a
+// delegate on their own request.
+func (app *Application) delegatedApprover(user *data.Associate, approverID *int, requesterID int) *int {
+	if user == nil || approverID == nil || *approverID == user.ID || user.ID == requesterID {
+		return nil
+	}
+
+	ok, err := app.Models.Delegations.IsDelegate(*approverID, user.ID, calendar.Date(time.Now()))
+	if err != nil {
+		log.Printf("Delegation check failed for associate %d: %v", user.ID, err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	return approverID
+}
+
+// approverQueue lists approverID followed by everyone who has delegated
+// their approvals to approverID today, whose queues approverID also works.
+func (app *Application) approverQueue(approverID int) ([]int, error) {
+	delegators, err := app.Models.Delegations.ActiveDelegators(approverID, calendar.Date(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	return append([]int{approverID}, delegators...), nil
+}
+
+// GetDelegations lists the current and upcoming delegations the caller gave
+// or received, or those of ?associate_id= for holders of delegations.manage.
+func (app *Application) GetDelegations(w http.ResponseWriter, r *http.Request) {
+	currentUser := app.currentUser(r)
+	associateID := currentUser.ID
+	if idStr := r.URL.Query().Get("associate_id"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			app.errorJSON(w, errors.New("invalid associate_id parameter"))
+			return
+		}
+		if id != currentUser.ID && !app.can(currentUser, data.PermDelegationsManage) {
+			app.errorJSON(w, errors.New("forbidden: you can only view your own delegations"), http.StatusForbidden)
+			return
+		}
+		associateID = id
+	}
+
+	delegations, err := app.Models.Delegations.GetForAssociate(associateID, calendar.Date(time.Now()))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	out, _ := json.Marshal(delegations)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+// CreateDelegation hands the caller's approvals, or with delegations.manage
+// anyone's, to a delegate for a date range. An approver has at most one
+// delegate on any day.
+func (app *Application) CreateDelegation(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		DelegatorID *int   `json:"delegator_id"`
+		DelegateID  int    `json:"delegate_id"`
+		StartDate   string `json:"start_date"`
+		EndDate     string `json:"end_date"`
+		Reason      string `json:"reason"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	currentUser := app.currentUser(r)
+	delegation := data.Delegation{
+		DelegatorID: currentUser.ID,
+		DelegateID:  payload.DelegateID,
+		Reason:      strings.TrimSpace(payload.Reason),
+		CreatedBy:   &currentUser.ID,
+	}
+	if payload.DelegatorID != nil && *payload.DelegatorID != currentUser.ID {
+		if !app.can(currentUser, data.PermDelegationsManage) {
+			app.errorJSON(w, errors.New("forbidden: you can only delegate your own approvals"), http.StatusForbidden)
+			return
+		}
+		delegation.DelegatorID = *payload.DelegatorID
+	}
+
+	delegation.StartDate, err = time.Parse("2006-01-02", payload.StartDate)
+	if err != nil {
+		app.errorJSON(w, errors.New("start_date must be a date in YYYY-MM-DD format"))
+		return
+	}
+	delegation.EndDate, err = time.Parse("2006-01-02", payload.EndDate)
+	if err != nil {
+		app.errorJSON(w, errors.New("end_date must be a date in YYYY-MM-DD format"))
+		return
+	}
+	if delegation.EndDate.Before(delegation.StartDate) {
+		app.errorJSON(w, errors.New("end_date must not be before start_date"))
+		return
+	}
+	if delegation.EndDate.Before(calendar.Date(time.Now())) {
+		app.errorJSON(w, errors.New("the delegation would already have ended"))
+		return
+	}
+
+	if delegation.DelegateID == delegation.DelegatorID {
+		app.errorJSON(w, errors.New("an approver cannot delegate to themselves"))
+		return
+	}
+	for _, id := range []int{delegation.DelegatorID, delegation.DelegateID} {
+		if _, err := app.Models.Associates.GetOne(id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err = errors.New("unknown associate: " + strconv.Itoa(id))
+			}
+			app.errorJSON(w, err)
+			return
+		}
+	}
+
+	overlapping, err := app.Models.Delegations.GetOverlapping(delegation.DelegatorID, delegation.StartDate, delegation.EndDate)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	if len(overlapping) > 0 {
+		o := overlapping[0]
+		app.errorJSON(w, fmt.Errorf("overlaps the delegation to %s from %s to %s", o.DelegateName,
+			o.StartDate.Format("2006-01-02"), o.EndDate.Format("2006-01-02")), http.StatusConflict)
+		return
+	}
+
+	id, err := app.Models.Delegations.Insert(delegation)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	response := struct {
+		ID      int    `json:"id"`
+		Message string `json:"message"`
+	}{
+		ID:      id,
+		Message: "Delegation created successfully",
+	}
+
+	out, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(out)
+}
+
+// DeleteDelegation revokes a delegation. Decisions already taken under it
+// keep their on-behalf-of record.
+func (app *Application) DeleteDelegation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	delegation, err := app.Models.Delegations.GetOne(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.errorJSON(w, errors.New("delegation not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	currentUser := app.currentUser(r)
+	if delegation.DelegatorID != currentUser.ID && !app.can(currentUser, data.PermDelegationsManage) {
+		app.errorJSON(w, errors.New("forbidden: only the delegator can revoke this delegation"), http.StatusForbidden)
+		return
+	}
+
+	err = app.Models.Delegations.Delete(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Delegation revoked",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
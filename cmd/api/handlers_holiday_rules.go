@@ -0,0 +1,131 @@
+package main
+
+import (
+	"backend/internal/data"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Holiday Rules Handlers
+func (app *Application) GetAllHolidayRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := app.Models.HolidayRules.GetAll()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	if rules == nil {
+		rules = []data.HolidayRule{}
+	}
+
+	out, _ := json.Marshal(rules)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+func (app *Application) CreateHolidayRule(w http.ResponseWriter, r *http.Request) {
+	rule := data.HolidayRule{Observed: data.ObservedNone}
+	err := json.NewDecoder(r.Body).Decode(&rule)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if err := rule.Validate(); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	if err := app.validateOfficeIDs(rule.OfficeIDs); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	id, err := app.Models.HolidayRules.Insert(rule)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		ID      int    `json:"id"`
+		Message string `json:"message"`
+	}{
+		ID:      id,
+		Message: "Holiday rule created successfully",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(out)
+}
+
+func (app *Application) UpdateHolidayRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	rule := data.HolidayRule{Observed: data.ObservedNone}
+	err = json.NewDecoder(r.Body).Decode(&rule)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if err := rule.Validate(); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	if err := app.validateOfficeIDs(rule.OfficeIDs); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	err = app.Models.HolidayRules.Update(id, rule)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Holiday rule updated successfully",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+func (app *Application) DeleteHolidayRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	err = app.Models.HolidayRules.Delete(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Holiday rule deleted successfully",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
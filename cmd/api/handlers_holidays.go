@@ -0,0 +1,501 @@
+package main
+
+import (
+	"backend/internal/calendar"
+	"backend/internal/data"
+	"backend/internal/ical"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// officeIDByName resolves an associate's Office to its id. Associates with no
+// office, or one that is not in Offices, get nil: the company-wide calendar.
+func (app *Application) officeIDByName(name string) (*int, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	office, err := app.Models.Offices.GetByName(name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &office.ID, nil
+}
+
+// holidayCalendar returns a working calendar with the holidays of the
+// associate's office.
+func (app *Application) holidayCalendar(associate *data.Associate) (*calendar.WorkingCalendar, error) {
+	officeID, err := app.officeIDByName(associate.Office)
+	if err != nil {
+		return nil, err
+	}
+
+	return calendar.New(&app.Models.Holidays, officeID), nil
+}
+
+// holidayOfficeParam reads ?office_id=, defaulting to the caller's own
+// office. office_id=0 selects the company-wide calendar alone.
+func (app *Application) holidayOfficeParam(r *http.Request) (*int, error) {
+	officeIDStr := r.URL.Query().Get("office_id")
+	if officeIDStr == "" {
+		user := app.currentUser(r)
+		if user == nil {
+			return nil, nil
+		}
+		return app.officeIDByName(user.Office)
+	}
+
+	officeID, err := strconv.Atoi(officeIDStr)
+	if err != nil {
+		return nil, errors.New("invalid office_id parameter")
+	}
+	if officeID == 0 {
+		return nil, nil
+	}
+
+	return &officeID, nil
+}
+
+// validateOfficeIDs checks that a holiday or holiday rule is only assigned
+// to existing offices.
+func (app *Application) validateOfficeIDs(officeIDs []int) error {
+	if len(officeIDs) == 0 {
+		return nil
+	}
+
+	offices, err := app.Models.Offices.GetAll()
+	if err != nil {
+		return err
+	}
+
+	known := map[int]bool{}
+	for _, o := range offices {
+		known[o.ID] = true
+	}
+	for _, id := range officeIDs {
+		if !known[id] {
+			return fmt.Errorf("unknown office id %d", id)
+		}
+	}
+
+	return nil
+}
+
+// maxHolidayImportBytes bounds the file accepted by POST /holidays/import.
+const maxHolidayImportBytes = 5 << 20
+
+type holidayImportRow struct {
+	// Row is the CSV line or the position of the event in the iCalendar file
+	Row       int    `json:"row"`
+	Name      string `json:"name"`
+	Date      string `json:"date"`
+	OfficeIDs []int  `json:"office_ids"`
+	// Status is new, duplicate or error
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	ID     *int   `json:"id,omitempty"`
+}
+
+// readHolidayUpload returns the uploaded file and its format. The file is the
+// "file" field of a multipart form or the raw request body; the format comes
+// from ?format=, the file name, or the content type.
+func readHolidayUpload(r *http.Request) ([]byte, string, error) {
+	var content []byte
+	var filename string
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.HasPrefix(contentType, "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, "", errors.New("the upload must be in a form field named file")
+		}
+		defer file.Close()
+
+		content, err = io.ReadAll(file)
+		if err != nil {
+			return nil, "", err
+		}
+		filename = header.Filename
+		contentType = header.Header.Get("Content-Type")
+	} else {
+		var err error
+		content, err = io.ReadAll(r.Body)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		switch {
+		case strings.HasSuffix(strings.ToLower(filename), ".ics"), strings.HasPrefix(contentType, "text/calendar"):
+			format = "ics"
+		case strings.HasSuffix(strings.ToLower(filename), ".csv"), strings.HasPrefix(contentType, "text/csv"):
+			format = "csv"
+		}
+	}
+	if format != "ics" && format != "csv" {
+		return nil, "", errors.New("format must be ics or csv")
+	}
+
+	return content, format, nil
+}
+
+// parseHolidayCSV reads name,date[,offices] rows with a header line. Offices
+// are office names separated by semicolons, as written by the export.
+func parseHolidayCSV(content []byte, officeIDs map[string]int, defaultOffices []int) ([]holidayImportRow, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("the CSV file is empty")
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	nameCol, hasName := columns["name"]
+	dateCol, hasDate := columns["date"]
+	officesCol, hasOffices := columns["offices"]
+	if !hasName || !hasDate {
+		return nil, errors.New("the CSV header must have name and date columns")
+	}
+
+	var rows []holidayImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(col int) string {
+			if col < len(record) {
+				return strings.TrimSpace(record[col])
+			}
+			return ""
+		}
+
+		row := holidayImportRow{Row: line, Name: field(nameCol), Date: field(dateCol), OfficeIDs: defaultOffices}
+		if hasOffices && field(officesCol) != "" {
+			row.OfficeIDs = nil
+			for _, office := range strings.Split(field(officesCol), ";") {
+				office = strings.TrimSpace(office)
+				id, ok := officeIDs[strings.ToLower(office)]
+				if !ok {
+					row.Status, row.Error = "error", "unknown office: "+office
+					break
+				}
+				row.OfficeIDs = append(row.OfficeIDs, id)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseHolidayICS turns each event into one holiday per day it covers.
+func parseHolidayICS(content []byte, defaultOffices []int) ([]holidayImportRow, error) {
+	events, err := ical.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []holidayImportRow
+	for i, e := range events {
+		if e.Recurring {
+			rows = append(rows, holidayImportRow{
+				Row:       i + 1,
+				Name:      e.Summary,
+				Date:      e.Start.Format("2006-01-02"),
+				OfficeIDs: defaultOffices,
+				Status:    "error",
+				Error:     "recurring events are not imported; define a holiday rule instead",
+			})
+			continue
+		}
+		for day := e.Start; !day.After(e.End); day = day.AddDate(0, 0, 1) {
+			rows = append(rows, holidayImportRow{
+				Row:       i + 1,
+				Name:      e.Summary,
+				Date:      day.Format("2006-01-02"),
+				OfficeIDs: defaultOffices,
+			})
+		}
+	}
+
+	return rows, nil
+}
+
+func holidayKey(name string, date time.Time) string {
+	return strings.ToLower(strings.TrimSpace(name)) + "|" + date.Format("2006-01-02")
+}
+
+// ImportHolidays adds holidays from an iCalendar or CSV file. Rows matching an
+// existing holiday, or a holiday rule's date, by name and date are skipped as
+// duplicates. With ?dry_run=true nothing is saved; otherwise any invalid row
+// rejects the whole file. ?office_id= (repeatable) assigns offices to rows
+// that do not name their own.
+func (app *Application) ImportHolidays(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxHolidayImportBytes)
+
+	content, format, err := readHolidayUpload(r)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	var defaultOffices []int
+	for _, value := range r.URL.Query()["office_id"] {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			app.errorJSON(w, errors.New("invalid office_id parameter"))
+			return
+		}
+		defaultOffices = append(defaultOffices, id)
+	}
+	if err := app.validateOfficeIDs(defaultOffices); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	offices, err := app.Models.Offices.GetAll()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	officeIDs := map[string]int{}
+	for _, o := range offices {
+		officeIDs[strings.ToLower(o.Name)] = o.ID
+	}
+
+	var rows []holidayImportRow
+	if format == "ics" {
+		rows, err = parseHolidayICS(content, defaultOffices)
+	} else {
+		rows, err = parseHolidayCSV(content, officeIDs, defaultOffices)
+	}
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	existing, err := app.Models.Holidays.GetAll()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	rules, err := app.Models.HolidayRules.GetAll()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, h := range existing {
+		seen[holidayKey(h.Name, h.Date)] = true
+	}
+	ruleYears := map[int]bool{}
+
+	var toCreate []data.Holiday
+	var created []int // indexes into rows
+	counts := map[string]int{}
+	for i := range rows {
+		row := &rows[i]
+		if row.Status == "" {
+			date, err := time.Parse("2006-01-02", row.Date)
+			switch {
+			case row.Name == "":
+				row.Status, row.Error = "error", "name is required"
+			case err != nil:
+				row.Status, row.Error = "error", "date must be in YYYY-MM-DD format"
+			default:
+				// Rule dates count as existing holidays, in their observed form too
+				if !ruleYears[date.Year()] {
+					ruleYears[date.Year()] = true
+					for _, rule := range rules {
+						if actual, ok := rule.Occurrence(date.Year()); ok {
+							seen[holidayKey(rule.Name, actual)] = true
+						}
+						for _, h := range data.MaterializeHolidays(date.Year(), nil, []data.HolidayRule{rule}) {
+							seen[holidayKey(h.Name, h.Date)] = true
+						}
+					}
+				}
+
+				key := holidayKey(row.Name, date)
+				if seen[key] {
+					row.Status = "duplicate"
+				} else {
+					seen[key] = true
+					row.Status = "new"
+					toCreate = append(toCreate, data.Holiday{Name: row.Name, Date: date, Year: date.Year(), OfficeIDs: row.OfficeIDs})
+					created = append(created, i)
+				}
+			}
+		}
+		if row.OfficeIDs == nil {
+			row.OfficeIDs = []int{}
+		}
+		counts[row.Status]++
+	}
+
+	status := http.StatusOK
+	imported := 0
+	switch {
+	case dryRun:
+	case counts["error"] > 0:
+		status = http.StatusUnprocessableEntity
+	case len(toCreate) > 0:
+		ids, err := app.Models.Holidays.InsertMany(toCreate)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+		for i, id := range ids {
+			rows[created[i]].ID = &id
+		}
+		imported = len(ids)
+		status = http.StatusCreated
+	}
+
+	if rows == nil {
+		rows = []holidayImportRow{}
+	}
+	payload := struct {
+		DryRun     bool               `json:"dry_run"`
+		Format     string             `json:"format"`
+		New        int                `json:"new"`
+		Duplicates int                `json:"duplicates"`
+		Errors     int                `json:"errors"`
+		Imported   int                `json:"imported"`
+		Rows       []holidayImportRow `json:"rows"`
+	}{
+		DryRun:     dryRun,
+		Format:     format,
+		New:        counts["new"],
+		Duplicates: counts["duplicate"],
+		Errors:     counts["error"],
+		Imported:   imported,
+		Rows:       rows,
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(out)
+}
+
+// ExportHolidays writes holidays as ?format=ics or csv. With ?year= it exports
+// the holidays observed that year by ?office_id= (default: the caller's
+// office), rule dates included; without it, every one-off holiday.
+func (app *Application) ExportHolidays(w http.ResponseWriter, r *http.Request) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = "csv"
+	}
+	if format != "ics" && format != "csv" {
+		app.errorJSON(w, errors.New("format must be ics or csv"))
+		return
+	}
+
+	var holidays []data.Holiday
+	filename := "holidays"
+	if yearStr := r.URL.Query().Get("year"); yearStr != "" {
+		year, err := strconv.Atoi(yearStr)
+		if err != nil {
+			app.errorJSON(w, errors.New("invalid year parameter"))
+			return
+		}
+		officeID, err := app.holidayOfficeParam(r)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+		holidays, err = app.Models.Holidays.Materialize(year, officeID)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+		filename += "-" + yearStr
+	} else {
+		var err error
+		holidays, err = app.Models.Holidays.GetAll()
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+	}
+
+	offices, err := app.Models.Offices.GetAll()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	officeNames := map[int]string{}
+	for _, o := range offices {
+		officeNames[o.ID] = o.Name
+	}
+
+	if format == "ics" {
+		var events []ical.Event
+		for _, h := range holidays {
+			date := calendar.Date(h.Date)
+			event := ical.Event{
+				UID:        fmt.Sprintf("holiday-%d@workops", h.ID),
+				Summary:    h.Name,
+				Start:      date,
+				End:        date,
+				Categories: "Holiday",
+			}
+			if h.RuleID != nil {
+				event.UID = fmt.Sprintf("holiday-rule-%d-%s@workops", *h.RuleID, date.Format("20060102"))
+			}
+			if h.ActualDate != nil {
+				event.Description = "Observed; the holiday falls on " + h.ActualDate.Format("2006-01-02")
+			}
+			events = append(events, event)
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.ics"`)
+		w.WriteHeader(http.StatusOK)
+		ical.Write(w, "WorkOps holidays", events)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"name", "date", "offices"})
+	for _, h := range holidays {
+		var names []string
+		for _, id := range h.OfficeIDs {
+			names = append(names, officeNames[id])
+		}
+		writer.Write([]string{h.Name, h.Date.Format("2006-01-02"), strings.Join(names, ";")})
+	}
+	writer.Flush()
+}
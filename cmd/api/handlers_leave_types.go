@@ -0,0 +1,146 @@
+package main
+
+import (
+	"backend/internal/data"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Leave Types Handlers
+func (app *Application) GetAllLeaveTypes(w http.ResponseWriter, r *http.Request) {
+	leaveTypes, err := app.Models.LeaveTypes.GetAll()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	out, _ := json.Marshal(leaveTypes)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+func validateLeaveType(lt data.LeaveType) error {
+	if lt.Name == "" {
+		return errors.New("leave type name is required")
+	}
+	if lt.DaysPerYear != nil && *lt.DaysPerYear < 0 {
+		return errors.New("days_per_year must not be negative")
+	}
+	if lt.AccrualMethod != "immediate" && lt.AccrualMethod != "accrual" {
+		return errors.New("accrual_method must be immediate or accrual")
+	}
+	if lt.CarryOverCap < 0 {
+		return errors.New("carry_over_cap must not be negative")
+	}
+	if m := lt.CarryOverExpiryMonths; m != nil && (*m < 1 || *m > 12) {
+		return errors.New("carry_over_expiry_months must be between 1 and 12")
+	}
+	return nil
+}
+
+func (app *Application) CreateLeaveType(w http.ResponseWriter, r *http.Request) {
+	lt := data.LeaveType{AccrualMethod: "immediate", RequiresApproval: true}
+	err := json.NewDecoder(r.Body).Decode(&lt)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if lt.Code == "" {
+		app.errorJSON(w, errors.New("leave type code is required"))
+		return
+	}
+	if err := validateLeaveType(lt); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	id, err := app.Models.LeaveTypes.Insert(lt)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		ID      int    `json:"id"`
+		Message string `json:"message"`
+	}{
+		ID:      id,
+		Message: "Leave type created successfully",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(out)
+}
+
+// UpdateLeaveType changes a leave type's policy. The code is immutable
+// because time-off requests reference it.
+func (app *Application) UpdateLeaveType(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	var lt data.LeaveType
+	err = json.NewDecoder(r.Body).Decode(&lt)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if err := validateLeaveType(lt); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	err = app.Models.LeaveTypes.Update(id, lt)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Leave type updated successfully",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+func (app *Application) DeleteLeaveType(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	// Requests of this type keep the row alive through the foreign key
+	err = app.Models.LeaveTypes.Delete(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Leave type deleted successfully",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"backend/internal/data"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// evaluateMenu resolves the menu_permissions rules for an associate.
+func (app *Application) evaluateMenu(associate *data.Associate) ([]data.MenuAccess, error) {
+	rules, err := app.Models.MenuPermissions.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := app.roleNames(associate.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.EvaluateMenu(rules, *associate, roles), nil
+}
+
+func (app *Application) roleNames(associateID int) ([]string, error) {
+	roles, err := app.Models.Roles.GetByAssociateID(associateID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	return names, nil
+}
+
+// GetMyMenu returns the menu items visible to the authenticated associate
+func (app *Application) GetMyMenu(w http.ResponseWriter, r *http.Request) {
+	items, err := app.evaluateMenu(app.currentUser(r))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	visible := []string{}
+	for _, item := range items {
+		if item.Visible {
+			visible = append(visible, item.MenuItem)
+		}
+	}
+
+	out, _ := json.Marshal(visible)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+// GetEffectivePermissions explains which menu items and permissions an associate ends up with
+func (app *Application) GetEffectivePermissions(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	associate, err := app.Models.Associates.GetOne(id)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusNotFound)
+		return
+	}
+
+	items, err := app.evaluateMenu(associate)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	roles, err := app.roleNames(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	permissions, err := app.Models.Roles.GetPermissionsForAssociate(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	response := struct {
+		AssociateID int               `json:"associate_id"`
+		Department  string            `json:"department"`
+		Title       string            `json:"title"`
+		Roles       []string          `json:"roles"`
+		Permissions []string          `json:"permissions"`
+		Menu        []data.MenuAccess `json:"menu"`
+	}{
+		AssociateID: id,
+		Department:  associate.Department,
+		Title:       associate.Title,
+		Roles:       roles,
+		Permissions: permissions,
+		Menu:        items,
+	}
+
+	out, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"backend/internal/data"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// orgChartView renders the reporting tree for the current user.
+type orgChartView struct {
+	view       *associateView
+	chart      *data.OrgChart
+	associates map[int]data.Associate
+}
+
+// orgChart loads every associate and their reporting lines. The chart shows
+// who manages whom, so callers other than HR need manager_id to be public.
+func (app *Application) orgChart(w http.ResponseWriter, r *http.Request) (*orgChartView, bool) {
+	view, err := app.newAssociateView(r)
+	if err != nil {
+		app.errorJSON(w, err)
+		return nil, false
+	}
+	if !view.hr && !view.policy.Visible("manager_id", data.Audience{}) {
+		app.errorJSON(w, errors.New("forbidden: reporting lines are not public"), http.StatusForbidden)
+		return nil, false
+	}
+
+	associates, _, err := app.Models.Associates.List(data.AssociateFilter{}, nil, 0, 0)
+	if err != nil {
+		app.errorJSON(w, err)
+		return nil, false
+	}
+
+	byID := make(map[int]data.Associate, len(associates))
+	links := make(map[int]*int, len(associates))
+	for _, a := range associates {
+		byID[a.ID] = a
+		links[a.ID] = a.ManagerID
+	}
+
+	return &orgChartView{view: view, chart: data.NewOrgChart(links), associates: byID}, true
+}
+
+// orgChartSubject reads the associate id in the URL and checks they exist.
+func (app *Application) orgChartSubject(w http.ResponseWriter, r *http.Request, o *orgChartView) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return 0, false
+	}
+	if !o.chart.Has(id) {
+		app.errorJSON(w, errors.New("associate not found"), http.StatusNotFound)
+		return 0, false
+	}
+	return id, true
+}
+
+// node renders id with report_count, their number of direct reports, and
+// reports, their subtrees, while depth is under maxDepth (below 0 means no
+// limit). placed keeps a loop saved before cycles were rejected from
+// recursing forever.
+func (o *orgChartView) node(id, depth, maxDepth int, placed map[int]bool) (map[string]json.RawMessage, error) {
+	placed[id] = true
+	fields, err := o.view.render(o.associates[id])
+	if err != nil {
+		return nil, err
+	}
+
+	direct := o.chart.DirectReports(id)
+	fields["report_count"], _ = json.Marshal(len(direct))
+	if maxDepth >= 0 && depth >= maxDepth {
+		return fields, nil
+	}
+
+	reports := []map[string]json.RawMessage{}
+	for _, report := range direct {
+		if placed[report] {
+			continue
+		}
+		child, err := o.node(report, depth+1, maxDepth, placed)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, child)
+	}
+	fields["reports"], err = json.Marshal(reports)
+	return fields, err
+}
+
+// list renders associates with their level: how many steps they are below
+// or above the associate asked about.
+func (o *orgChartView) list(entries []data.OrgEntry) ([]map[string]json.RawMessage, error) {
+	rendered := make([]map[string]json.RawMessage, 0, len(entries))
+	for _, e := range entries {
+		fields, err := o.view.render(o.associates[e.ID])
+		if err != nil {
+			return nil, err
+		}
+		fields["level"], _ = json.Marshal(e.Level)
+		rendered = append(rendered, fields)
+	}
+	return rendered, nil
+}
+
+// GetOrgChart returns the reporting tree: every top-level associate with
+// their reports nested under them, or with ?root= the subtree under one
+// associate. ?depth= limits how many levels below the top are expanded.
+func (app *Application) GetOrgChart(w http.ResponseWriter, r *http.Request) {
+	o, ok := app.orgChart(w, r)
+	if !ok {
+		return
+	}
+
+	maxDepth := -1
+	if value := r.URL.Query().Get("depth"); value != "" {
+		var err error
+		maxDepth, err = strconv.Atoi(value)
+		if err != nil || maxDepth < 0 {
+			app.errorJSON(w, errors.New("depth must be zero or more"))
+			return
+		}
+	}
+
+	placed := map[int]bool{}
+	var out []byte
+	if value := r.URL.Query().Get("root"); value != "" {
+		root, err := strconv.Atoi(value)
+		if err != nil {
+			app.errorJSON(w, errors.New("root must be a number"))
+			return
+		}
+		if !o.chart.Has(root) {
+			app.errorJSON(w, errors.New("associate not found"), http.StatusNotFound)
+			return
+		}
+
+		tree, err := o.node(root, 0, maxDepth, placed)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+		out, _ = json.Marshal(tree)
+	} else {
+		trees := []map[string]json.RawMessage{}
+		for _, root := range o.chart.Roots() {
+			tree, err := o.node(root, 0, maxDepth, placed)
+			if err != nil {
+				app.errorJSON(w, err)
+				return
+			}
+			trees = append(trees, tree)
+		}
+		out, _ = json.Marshal(trees)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+// GetAssociateReports lists an associate's direct reports, or with
+// ?recursive=true everyone below them, level by level.
+func (app *Application) GetAssociateReports(w http.ResponseWriter, r *http.Request) {
+	o, ok := app.orgChart(w, r)
+	if !ok {
+		return
+	}
+	id, ok := app.orgChartSubject(w, r, o)
+	if !ok {
+		return
+	}
+
+	maxDepth := 1
+	if value := r.URL.Query().Get("recursive"); value != "" {
+		recursive, err := strconv.ParseBool(value)
+		if err != nil {
+			app.errorJSON(w, errors.New("recursive must be true or false"))
+			return
+		}
+		if recursive {
+			maxDepth = 0
+		}
+	}
+
+	reports, err := o.list(o.chart.Reports(id, maxDepth))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	out, _ := json.Marshal(reports)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+// GetAssociateChain lists an associate's management chain, from their
+// manager up to the top of the chart.
+func (app *Application) GetAssociateChain(w http.ResponseWriter, r *http.Request) {
+	o, ok := app.orgChart(w, r)
+	if !ok {
+		return
+	}
+	id, ok := app.orgChartSubject(w, r, o)
+	if !ok {
+		return
+	}
+
+	var chain []data.OrgEntry
+	for i, manager := range o.chart.Chain(id) {
+		chain = append(chain, data.OrgEntry{ID: manager, Level: i + 1})
+	}
+
+	managers, err := o.list(chain)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	out, _ := json.Marshal(managers)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
@@ -1,107 +1,583 @@
 package main
 
 import (
+	"backend/internal/calendar"
+	"backend/internal/data"
+	"backend/internal/pto"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 )
 
-// GetPTOBalance calculates and returns PTO balance for an associate
+// ptoSubject loads the associate named in the URL and checks that the
+// current user may see their PTO: themselves, their manager, or pto.manage.
+func (app *Application) ptoSubject(w http.ResponseWriter, r *http.Request) (*data.Associate, bool) {
+	associateID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return nil, false
+	}
+
+	associate, err := app.Models.Associates.GetOne(associateID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return nil, false
+	}
+
+	currentUser := app.currentUser(r)
+	isSelf := currentUser != nil && currentUser.ID == associate.ID
+	isManager := currentUser != nil && associate.ManagerID != nil && *associate.ManagerID == currentUser.ID
+	if !isSelf && !isManager && !app.can(currentUser, data.PermPTOManage) {
+		app.errorJSON(w, errors.New("forbidden: you cannot view this associate's PTO"), http.StatusForbidden)
+		return nil, false
+	}
+
+	return associate, true
+}
+
+// defaultHoursPerDay is the working day length used when the
+// working_hours_per_day setting is not set.
+const defaultHoursPerDay = 8.0
+
+// hoursPerDay is the length of a working day, used to convert hours requests
+// into fractional days and balances into hours.
+func (app *Application) hoursPerDay() float64 {
+	setting, _ := app.Models.AppSettings.Get("working_hours_per_day")
+	if setting != nil && setting.Value != "" {
+		if val, err := strconv.ParseFloat(strings.TrimSpace(setting.Value), 64); err == nil && val > 0 {
+			return val
+		}
+	}
+	return defaultHoursPerDay
+}
+
+// validateDayPortion checks a request's day_portion and hours, defaulting to
+// a full day. Half days and hours requests cover a single day.
+func validateDayPortion(req *data.TimeOffRequest, hoursPerDay float64) error {
+	req.DayPortion = strings.ToLower(strings.TrimSpace(req.DayPortion))
+	if req.DayPortion == "" {
+		req.DayPortion = data.DayPortionFull
+	}
+
+	switch req.DayPortion {
+	case data.DayPortionFull, data.DayPortionAM, data.DayPortionPM:
+		req.Hours = nil
+	case data.DayPortionHours:
+		if req.Hours == nil || *req.Hours <= 0 || *req.Hours > hoursPerDay {
+			return fmt.Errorf("hours must be more than 0 and at most %g", hoursPerDay)
+		}
+	default:
+		return errors.New("day_portion must be full, am, pm or hours")
+	}
+
+	if req.IsPartialDay() && !calendar.Date(req.StartDate).Equal(calendar.Date(req.EndDate)) {
+		return errors.New("half-day and hours requests must start and end on the same day")
+	}
+	return nil
+}
+
+// timeOffCheck is what validateTimeOff learns about a request.
+type timeOffCheck struct {
+	LeaveType *data.LeaveType
+	// OverBalance is set when some year's share of the request exceeds what
+	// is left of that year's balance
+	OverBalance bool
+}
+
+// validateTimeOff checks a new or edited request of requester: its dates,
+// day portion and leave type, that it covers working days, and that it
+// overlaps none of their other requests. It sets the coverage warning and
+// compares the request with the balance; requests over the balance are
+// allowed but always go to an approver. On failure it returns the status to
+// answer with.
+func (app *Application) validateTimeOff(req *data.TimeOffRequest, requester *data.Associate) (*timeOffCheck, int, error) {
+	if req.EndDate.Before(req.StartDate) {
+		return nil, http.StatusBadRequest, errors.New("end date must not be before start date")
+	}
+	hoursPerDay := app.hoursPerDay()
+	if err := validateDayPortion(req, hoursPerDay); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	if req.LeaveType == "" {
+		req.LeaveType = data.DefaultLeaveType
+	}
+	leaveType, err := app.Models.LeaveTypes.GetByCode(req.LeaveType)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = errors.New("unknown leave type: " + req.LeaveType)
+		}
+		return nil, http.StatusBadRequest, err
+	}
+
+	// Working days are counted against the requester's office holidays, split by year
+	cal, err := app.holidayCalendar(requester)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	requestedByYear, err := timeOffDaysByYear(req, cal, hoursPerDay)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if len(requestedByYear) == 0 {
+		return nil, http.StatusBadRequest, errors.New("the requested period contains no working days")
+	}
+
+	conflicts, err := app.findTimeOffConflicts(req, requester, cal)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if len(conflicts.Overlapping) > 0 {
+		return nil, http.StatusConflict, overlapError(conflicts.Overlapping)
+	}
+	// Too many teammates out is left to the approver to judge
+	req.CoverageWarning = conflicts.ExceedsLimit
+
+	check := &timeOffCheck{LeaveType: leaveType}
+	if !leaveType.Unlimited() {
+		for year, requestedDays := range requestedByYear {
+			remaining, err := app.remainingPTO(requester, leaveType, year, cal)
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			if requestedDays > remaining {
+				check.OverBalance = true
+			}
+		}
+	}
+
+	return check, 0, nil
+}
+
+// timeOffDaysByYear is the working time a request charges, in fractional
+// days split by the year each day falls in.
+func timeOffDaysByYear(req *data.TimeOffRequest, cal *calendar.WorkingCalendar, hoursPerDay float64) (map[int]float64, error) {
+	byYear, err := cal.WorkingDaysByYear(req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	fraction := req.DayFraction(hoursPerDay)
+	for year := range byYear {
+		byYear[year] *= fraction
+	}
+	return byYear, nil
+}
+
+// annotateTimeOffDurations fills in the working time each request charges,
+// using the holidays of the requester's office.
+func (app *Application) annotateTimeOffDurations(requests []*data.TimeOffRequest) error {
+	if len(requests) == 0 {
+		return nil
+	}
+
+	associates, err := app.Models.Associates.GetAll()
+	if err != nil {
+		return err
+	}
+	offices := map[int]string{}
+	for _, a := range associates {
+		offices[a.ID] = a.Office
+	}
+
+	hoursPerDay := app.hoursPerDay()
+	calendars := map[string]*calendar.WorkingCalendar{}
+	for _, req := range requests {
+		office := offices[req.AssociateID]
+		cal, ok := calendars[office]
+		if !ok {
+			cal, err = app.holidayCalendar(&data.Associate{Office: office})
+			if err != nil {
+				return err
+			}
+			calendars[office] = cal
+		}
+
+		byYear, err := timeOffDaysByYear(req, cal, hoursPerDay)
+		if err != nil {
+			return err
+		}
+		var days float64
+		for _, d := range byYear {
+			days += d
+		}
+		hours := math.Round(days*hoursPerDay*100) / 100
+		req.DurationDays = &days
+		req.DurationHours = &hours
+	}
+
+	return nil
+}
+
+// parseDateParam reads an optional YYYY-MM-DD query parameter.
+func parseDateParam(r *http.Request, name string, fallback time.Time) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, errors.New(name + " must be a date in YYYY-MM-DD format")
+	}
+	return date, nil
+}
+
+// GetPTOBalance returns an associate's balance for every leave type as of
+// ?as_of= (default today). The top-level pto_* fields describe vacation, the
+// default leave type.
 func (app *Application) GetPTOBalance(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	associateID, err := strconv.Atoi(idStr)
+	associate, ok := app.ptoSubject(w, r)
+	if !ok {
+		return
+	}
+
+	asOf, err := parseDateParam(r, "as_of", calendar.Date(time.Now()))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	// Get associate details
-	associate, err := app.Models.Associates.GetOne(associateID)
+	leaveTypes, err := app.Models.LeaveTypes.GetAll()
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	// Get PTO settings
-	ptoDaysPerYearSetting, _ := app.Models.AppSettings.Get("pto_days_per_year")
-	ptoAccrualMethodSetting, _ := app.Models.AppSettings.Get("pto_accrual_method")
+	cal, err := app.holidayCalendar(associate)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	unit := r.URL.Query().Get("unit")
+	if unit == "" {
+		unit = "days"
+	}
+	if unit != "days" && unit != "hours" {
+		app.errorJSON(w, errors.New("unit must be days or hours"))
+		return
+	}
+	hoursPerDay := app.hoursPerDay()
+
+	response := struct {
+		AsOf          string        `json:"as_of"`
+		Unit          string        `json:"unit"`
+		HoursPerDay   float64       `json:"hours_per_day"`
+		PTOAllocated  float64       `json:"pto_allocated"`
+		PTOUsed       float64       `json:"pto_used"`
+		PTORemaining  float64       `json:"pto_remaining"`
+		AccrualMethod string        `json:"accrual_method"`
+		Balances      []*ptoBalance `json:"balances"`
+	}{
+		AsOf:        asOf.Format("2006-01-02"),
+		Unit:        unit,
+		HoursPerDay: hoursPerDay,
+		Balances:    []*ptoBalance{},
+	}
+
+	for i := range leaveTypes {
+		balance, err := app.calculatePTOBalance(associate, &leaveTypes[i], asOf, cal)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+		if unit == "hours" {
+			balance.toHours(hoursPerDay)
+		}
 
-	ptoDaysPerYear := 15.0 // default
-	if ptoDaysPerYearSetting != nil && ptoDaysPerYearSetting.Value != "" {
-		if val, err := strconv.ParseFloat(ptoDaysPerYearSetting.Value, 64); err == nil {
-			ptoDaysPerYear = val
+		if balance.LeaveType == data.DefaultLeaveType {
+			response.PTOAllocated = balance.Allocated
+			response.PTOUsed = balance.Used
+			response.PTORemaining = balance.Remaining
+			response.AccrualMethod = balance.AccrualMethod
 		}
+		response.Balances = append(response.Balances, balance)
 	}
 
-	accrualMethod := "immediate" // default
-	if ptoAccrualMethodSetting != nil && ptoAccrualMethodSetting.Value != "" {
-		accrualMethod = ptoAccrualMethodSetting.Value
+	out, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+type ptoBalance struct {
+	LeaveType        string  `json:"leave_type"`
+	Name             string  `json:"name"`
+	Year             int     `json:"year"`
+	Allocated        float64 `json:"allocated"`
+	Accrued          float64 `json:"accrued"`
+	CarriedOver      float64 `json:"carried_over"`
+	Adjusted         float64 `json:"adjusted"`
+	Used             float64 `json:"used"`
+	Scheduled        float64 `json:"scheduled"`
+	Expired          float64 `json:"expired"`
+	Balance          float64 `json:"balance"`
+	Remaining        float64 `json:"remaining"`
+	AccrualMethod    string  `json:"accrual_method"`
+	RequiresApproval bool    `json:"requires_approval"`
+	Unlimited        bool    `json:"unlimited"`
+}
+
+// toHours converts a balance kept in days into hours.
+func (b *ptoBalance) toHours(hoursPerDay float64) {
+	for _, f := range []*float64{&b.Allocated, &b.Accrued, &b.CarriedOver, &b.Adjusted, &b.Used, &b.Scheduled, &b.Expired, &b.Balance, &b.Remaining} {
+		*f = math.Round(*f*hoursPerDay*100) / 100
+	}
+}
+
+// calculatePTOBalance reads an associate's balance of one leave type for the
+// year containing asOf, after bringing their ledger up to date. Used includes
+// approved time off still ahead in the year (also reported as Scheduled), so
+// Remaining is what is left to request.
+func (app *Application) calculatePTOBalance(associate *data.Associate, leaveType *data.LeaveType, asOf time.Time, cal *calendar.WorkingCalendar) (*ptoBalance, error) {
+	asOf = calendar.Date(asOf)
+	yearEnd := time.Date(asOf.Year(), 12, 31, 0, 0, 0, 0, time.UTC)
+
+	entries, err := app.syncPTOLedger(associate, leaveType, yearEnd, cal)
+	if err != nil {
+		return nil, err
+	}
+
+	s := pto.Summarize(entries, asOf.Year(), asOf)
+	balance := &ptoBalance{
+		LeaveType:        leaveType.Code,
+		Name:             leaveType.Name,
+		Year:             s.Year,
+		Allocated:        s.Accrued + s.CarriedOver + s.Adjusted,
+		Accrued:          s.Accrued,
+		CarriedOver:      s.CarriedOver,
+		Adjusted:         s.Adjusted,
+		Used:             s.Used + s.Scheduled,
+		Scheduled:        s.Scheduled,
+		Expired:          s.Expired,
+		Balance:          s.Balance,
+		AccrualMethod:    leaveType.AccrualMethod,
+		RequiresApproval: leaveType.RequiresApproval,
+		Unlimited:        leaveType.Unlimited(),
+	}
+	if !balance.Unlimited {
+		balance.Remaining = s.Balance - s.Scheduled
+	}
+
+	return balance, nil
+}
+
+// remainingPTO is what an associate can still request of a leave type in
+// year, judged from today for the current year and from the year's first
+// (or last) day for future (or past) years.
+func (app *Application) remainingPTO(associate *data.Associate, leaveType *data.LeaveType, year int, cal *calendar.WorkingCalendar) (float64, error) {
+	asOf := calendar.Date(time.Now())
+	if yearStart := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC); asOf.Before(yearStart) {
+		asOf = yearStart
+	}
+	if yearEnd := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC); asOf.After(yearEnd) {
+		asOf = yearEnd
 	}
 
-	// Calculate PTO allocated
-	now := time.Now()
-	currentYear := now.Year()
-	yearStart := time.Date(currentYear, 1, 1, 0, 0, 0, 0, time.UTC)
-	
-	var ptoAllocated float64
-	
-	if accrualMethod == "immediate" {
-		// Grant full PTO if hired before or during current year
-		if associate.StartDate.Before(yearStart) || associate.StartDate.Equal(yearStart) {
-			ptoAllocated = ptoDaysPerYear
-		} else if associate.StartDate.Year() == currentYear {
-			ptoAllocated = ptoDaysPerYear
+	balance, err := app.calculatePTOBalance(associate, leaveType, asOf, cal)
+	if err != nil {
+		return 0, err
+	}
+	return balance.Remaining, nil
+}
+
+// syncPTOLedger posts every ledger entry that has come due for an associate
+// and leave type, and returns the ledger projected up to until.
+func (app *Application) syncPTOLedger(associate *data.Associate, leaveType *data.LeaveType, until time.Time, cal *calendar.WorkingCalendar) ([]data.PTOLedgerEntry, error) {
+	today := calendar.Date(time.Now())
+
+	requests, err := app.Models.TimeOffRequests.GetByAssociateID(associate.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	hoursPerDay := app.hoursPerDay()
+	planner := &pto.Planner{
+		AssociateID: associate.ID,
+		StartDate:   associate.StartDate,
+		LeaveType:   *leaveType,
+		Today:       today,
+	}
+	for _, req := range requests {
+		// Days stay booked while a cancellation is pending
+		booked := req.Status == data.TimeOffApproved || req.Status == data.TimeOffCancellationRequested
+		if req.LeaveType != leaveType.Code || !booked {
+			continue
 		}
-	} else {
-		// Accrual method: earn PTO proportionally
-		startDate := yearStart
-		if associate.StartDate.After(yearStart) {
-			startDate = associate.StartDate
+		byYear, err := timeOffDaysByYear(req, cal, hoursPerDay)
+		if err != nil {
+			return nil, err
+		}
+		for year, days := range byYear {
+			date := calendar.Date(req.StartDate)
+			if yearStart := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC); date.Before(yearStart) {
+				date = yearStart
+			}
+			planner.Usage = append(planner.Usage, pto.Usage{RequestID: req.ID, Year: year, Date: date, Days: days})
+		}
+	}
+
+	if until.Before(today) {
+		until = today
+	}
+
+	var entries []data.PTOLedgerEntry
+	err = app.Models.PTOLedger.Sync(associate.ID, leaveType.Code, func(existing []data.PTOLedgerEntry) ([]data.PTOLedgerEntry, error) {
+		entries = planner.Plan(existing, until)
+		return pto.Due(entries, today), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetPTOLedger lists ledger entries dated ?from= to ?to= (default: the
+// current year), optionally for a single ?leave_type=.
+func (app *Application) GetPTOLedger(w http.ResponseWriter, r *http.Request) {
+	associate, ok := app.ptoSubject(w, r)
+	if !ok {
+		return
+	}
+
+	today := calendar.Date(time.Now())
+	from, err := parseDateParam(r, "from", time.Date(today.Year(), 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	to, err := parseDateParam(r, "to", today)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	leaveTypes, err := app.Models.LeaveTypes.GetAll()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	leaveType := r.URL.Query().Get("leave_type")
+	cal, err := app.holidayCalendar(associate)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	for i := range leaveTypes {
+		if leaveType != "" && leaveTypes[i].Code != leaveType {
+			continue
 		}
-		
-		daysInYear := 365.0
-		daysSinceStart := now.Sub(startDate).Hours() / 24
-		
-		if daysSinceStart > 0 {
-			ptoAllocated = (ptoDaysPerYear / daysInYear) * daysSinceStart
+		if _, err := app.syncPTOLedger(associate, &leaveTypes[i], today, cal); err != nil {
+			app.errorJSON(w, err)
+			return
 		}
 	}
 
-	// Calculate PTO used (approved time off in current year)
-	allTimeOff, err := app.Models.TimeOffRequests.GetByAssociateID(associateID)
+	entries, err := app.Models.PTOLedger.GetEntries(associate.ID, leaveType, from, to)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
+	if entries == nil {
+		entries = []data.PTOLedgerEntry{}
+	}
+
+	out, _ := json.Marshal(entries)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
 
-	var ptoUsed float64
-	for _, req := range allTimeOff {
-		if req.Status == "Approved" && req.StartDate.Year() == currentYear {
-			// Calculate business days between start and end
-			days := req.EndDate.Sub(req.StartDate).Hours()/24 + 1
-			ptoUsed += days
+// CreatePTOAdjustment posts a manual credit (positive days) or debit
+// (negative days) to an associate's balance. The amount may be given in
+// hours instead of days.
+func (app *Application) CreatePTOAdjustment(w http.ResponseWriter, r *http.Request) {
+	associateID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	var payload struct {
+		LeaveType     string  `json:"leave_type"`
+		Days          float64 `json:"days"`
+		Hours         float64 `json:"hours"`
+		EffectiveDate string  `json:"effective_date"`
+		Note          string  `json:"note"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if payload.Days == 0 && payload.Hours != 0 {
+		payload.Days = payload.Hours / app.hoursPerDay()
+	}
+	if payload.Days == 0 {
+		app.errorJSON(w, errors.New("days or hours must not be zero"))
+		return
+	}
+	if payload.Note == "" {
+		app.errorJSON(w, errors.New("a note explaining the adjustment is required"))
+		return
+	}
+	if payload.LeaveType == "" {
+		payload.LeaveType = data.DefaultLeaveType
+	}
+	if _, err := app.Models.LeaveTypes.GetByCode(payload.LeaveType); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = errors.New("unknown leave type: " + payload.LeaveType)
 		}
+		app.errorJSON(w, err)
+		return
 	}
 
-	ptoRemaining := ptoAllocated - ptoUsed
+	effectiveDate := calendar.Date(time.Now())
+	if payload.EffectiveDate != "" {
+		effectiveDate, err = time.Parse("2006-01-02", payload.EffectiveDate)
+		if err != nil {
+			app.errorJSON(w, errors.New("effective_date must be a date in YYYY-MM-DD format"))
+			return
+		}
+	}
+
+	currentUser := app.currentUser(r)
+	id, err := app.Models.PTOLedger.Insert(data.PTOLedgerEntry{
+		AssociateID:   associateID,
+		LeaveType:     payload.LeaveType,
+		EntryType:     data.LedgerAdjustment,
+		Days:          payload.Days,
+		PeriodYear:    effectiveDate.Year(),
+		EffectiveDate: effectiveDate,
+		Note:          payload.Note,
+		CreatedBy:     &currentUser.ID,
+	})
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
 
 	response := struct {
-		PTOAllocated  float64 `json:"pto_allocated"`
-		PTOUsed       float64 `json:"pto_used"`
-		PTORemaining  float64 `json:"pto_remaining"`
-		AccrualMethod string  `json:"accrual_method"`
+		ID      int    `json:"id"`
+		Message string `json:"message"`
 	}{
-		PTOAllocated:  ptoAllocated,
-		PTOUsed:       ptoUsed,
-		PTORemaining:  ptoRemaining,
-		AccrualMethod: accrualMethod,
+		ID:      id,
+		Message: "Adjustment recorded",
 	}
 
 	out, _ := json.Marshal(response)
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(http.StatusCreated)
 	w.Write(out)
 }
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"backend/internal/data"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Roles Handlers
+func (app *Application) GetPermissionCatalogue(w http.ResponseWriter, r *http.Request) {
+	out, _ := json.Marshal(data.PermissionCatalogue)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+func (app *Application) GetAllRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := app.Models.Roles.GetAll()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	out, _ := json.Marshal(roles)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+func (app *Application) CreateRole(w http.ResponseWriter, r *http.Request) {
+	var role data.Role
+	err := json.NewDecoder(r.Body).Decode(&role)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if role.Name == "" {
+		app.errorJSON(w, errors.New("role name is required"))
+		return
+	}
+	for _, p := range role.Permissions {
+		if !data.IsKnownPermission(p) {
+			app.errorJSON(w, errors.New("unknown permission: "+p))
+			return
+		}
+	}
+
+	id, err := app.Models.Roles.Insert(role)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	err = app.Models.Roles.SetPermissions(id, role.Permissions)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		ID      int    `json:"id"`
+		Message string `json:"message"`
+	}{
+		ID:      id,
+		Message: "Role created successfully",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(out)
+}
+
+func (app *Application) UpdateRolePermissions(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	var permissions []string
+	err = json.NewDecoder(r.Body).Decode(&permissions)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	for _, p := range permissions {
+		if !data.IsKnownPermission(p) {
+			app.errorJSON(w, errors.New("unknown permission: "+p))
+			return
+		}
+	}
+
+	err = app.Models.Roles.SetPermissions(id, permissions)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Role permissions updated",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+func (app *Application) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	err = app.Models.Roles.Delete(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Role deleted",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+func (app *Application) GetAssociateRoles(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	roles, err := app.Models.Roles.GetByAssociateID(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	out, _ := json.Marshal(roles)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+func (app *Application) AssignAssociateRole(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	var payload struct {
+		RoleID int `json:"role_id"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	err = app.Models.Roles.AssignToAssociate(id, payload.RoleID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	response := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Role assigned",
+	}
+
+	out, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+func (app *Application) RemoveAssociateRole(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	roleID, err := strconv.Atoi(chi.URLParam(r, "roleId"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid role id parameter"))
+		return
+	}
+
+	err = app.Models.Roles.RemoveFromAssociate(id, roleID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Role removed",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"backend/internal/data"
+	"backend/internal/search"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// directoryIndexTTL is how stale the search index may get; changes made
+	// through this process rebuild it sooner
+	directoryIndexTTL = time.Minute
+	// defaultSearchLimit and defaultTypeaheadLimit apply without ?limit=
+	defaultSearchLimit    = 20
+	defaultTypeaheadLimit = 10
+	maxSearchLimit        = 100
+)
+
+// directoryIndex caches the search index over every associate's card.
+type directoryIndex struct {
+	mu      sync.Mutex
+	index   *search.Index
+	cards   map[int]data.AssociateCard
+	builtAt time.Time
+}
+
+// invalidate makes the next search rebuild the index.
+func (d *directoryIndex) invalidate() {
+	d.mu.Lock()
+	d.index = nil
+	d.mu.Unlock()
+}
+
+// directorySearch returns the current index, rebuilding it when it is older
+// than directoryIndexTTL.
+func (app *Application) directorySearch() (*search.Index, map[int]data.AssociateCard, error) {
+	d := &app.directory
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.index != nil && time.Since(d.builtAt) < directoryIndexTTL {
+		return d.index, d.cards, nil
+	}
+
+	cards, err := app.Models.Associates.GetCards()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	docs := make([]search.Document, 0, len(cards))
+	byID := make(map[int]data.AssociateCard, len(cards))
+	for _, c := range cards {
+		var doc search.Document
+		doc.ID = c.ID
+		doc.Fields[search.FieldName] = c.FirstName + " " + c.LastName
+		doc.Fields[search.FieldTitle] = c.Title
+		doc.Fields[search.FieldDepartment] = c.Department
+		doc.Fields[search.FieldOffice] = c.Office
+		doc.Fields[search.FieldEmail] = c.Email
+		doc.Fields[search.FieldPhone] = c.PhoneNumber
+		docs = append(docs, doc)
+		byID[c.ID] = c
+	}
+
+	d.index, d.cards, d.builtAt = search.NewIndex(docs), byID, time.Now()
+	return d.index, d.cards, nil
+}
+
+// searchFields maps the searchable Associate fields to index fields. The
+// name is always searched.
+var searchFields = []struct {
+	field string
+	index search.Field
+}{
+	{"Title", search.FieldTitle},
+	{"Department", search.FieldDepartment},
+	{"Office", search.FieldOffice},
+	{"Email", search.FieldEmail},
+	{"PhoneNumber", search.FieldPhone},
+}
+
+// SearchAssociates finds associates by name, title, department, office,
+// email or phone. Results are ranked and tolerate typos; ?mode=prefix
+// matches the last word as a prefix only, for typeahead. Callers other than
+// HR search only the fields the visibility policy makes public, and results
+// never carry salary, birth date, phone or private contact fields.
+func (app *Application) SearchAssociates(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		app.errorJSON(w, errors.New("q is required"))
+		return
+	}
+
+	prefix := false
+	limit := defaultSearchLimit
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "", "full":
+	case "prefix":
+		prefix, limit = true, defaultTypeaheadLimit
+	default:
+		app.errorJSON(w, errors.New("mode must be full or prefix"))
+		return
+	}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			app.errorJSON(w, errors.New("limit must be between 1 and "+strconv.Itoa(maxSearchLimit)))
+			return
+		}
+	}
+
+	policy := app.fieldVisibility()
+	hr := app.isHR(app.currentUser(r))
+	public := func(field string) bool {
+		return hr || policy.Visible(field, data.Audience{})
+	}
+
+	fields := []search.Field{search.FieldName}
+	for _, f := range searchFields {
+		if public(f.field) {
+			fields = append(fields, f.index)
+		}
+	}
+
+	index, cards, err := app.directorySearch()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	type result struct {
+		ID             int      `json:"id"`
+		FirstName      string   `json:"FirstName"`
+		LastName       string   `json:"LastName"`
+		Title          string   `json:"Title,omitempty"`
+		Department     string   `json:"Department,omitempty"`
+		Office         string   `json:"Office,omitempty"`
+		Email          string   `json:"Email,omitempty"`
+		ProfilePicture string   `json:"profile_picture,omitempty"`
+		Score          float64  `json:"score"`
+		Matched        []string `json:"matched"`
+	}
+
+	results := []result{}
+	for _, hit := range index.Search(q, search.Options{Fields: fields, Prefix: prefix, Limit: limit}) {
+		card := cards[hit.ID]
+		res := result{
+			ID:        card.ID,
+			FirstName: card.FirstName,
+			LastName:  card.LastName,
+			Score:     math.Round(hit.Score*100) / 100,
+			Matched:   hit.Matched,
+		}
+		if public("Title") {
+			res.Title = card.Title
+		}
+		if public("Department") {
+			res.Department = card.Department
+		}
+		if public("Office") {
+			res.Office = card.Office
+		}
+		if public("Email") {
+			res.Email = card.Email
+		}
+		if public("profile_picture") {
+			res.ProfilePicture = card.ProfilePicture
+		}
+		results = append(results, res)
+	}
+
+	out, _ := json.Marshal(results)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"backend/internal/data"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// taskSubject is the associate a task is about, whose manager chain its
+// manager steps follow: the associate a salary increase is for, otherwise
+// the requester.
+func (app *Application) taskSubject(task data.Task, requester *data.Associate) (*data.Associate, error) {
+	if task.TaskName != data.TaskSalaryIncrease || data.SalaryTaskAssociate(task) == requester.ID {
+		return requester, nil
+	}
+	return app.Models.Associates.GetOne(data.SalaryTaskAssociate(task))
+}
+
+// resolveTaskSteps turns the workflow of a task type into the steps of one
+// task. Manager steps follow the chain of the task's subject. Steps that
+// chain cannot fill, and steps that would have the requester or the subject
+// approve the task, are skipped; if nothing is left a task manager decides.
+func (app *Application) resolveTaskSteps(requester, subject *data.Associate, taskName string) ([]data.TaskApprovalStep, error) {
+	workflow, err := app.Models.TaskWorkflows.GetByTaskName(taskName)
+	if err != nil {
+		return nil, err
+	}
+	if workflow == nil || len(workflow.Steps) == 0 {
+		defaultWorkflow := data.DefaultTaskWorkflow(taskName)
+		workflow = &defaultWorkflow
+	}
+
+	var steps []data.TaskApprovalStep
+	open := 0
+	for _, ws := range workflow.Steps {
+		step := data.TaskApprovalStep{
+			Stage:        ws.Stage,
+			Name:         ws.Name,
+			ApproverType: ws.ApproverType,
+			Role:         ws.Role,
+			Permission:   ws.Permission,
+			Status:       data.StepWaiting,
+		}
+
+		switch ws.ApproverType {
+		case data.ApproverManager:
+			step.ApproverID, err = app.managerAbove(subject, *ws.ManagerLevel)
+			if err != nil {
+				return nil, err
+			}
+		case data.ApproverAssociate:
+			step.ApproverID = ws.AssociateID
+		}
+		if (ws.ApproverType == data.ApproverManager || ws.ApproverType == data.ApproverAssociate) &&
+			(step.ApproverID == nil || *step.ApproverID == requester.ID || *step.ApproverID == subject.ID) {
+			step.Status = data.StepSkipped
+		} else {
+			open++
+		}
+
+		steps = append(steps, step)
+	}
+
+	if open == 0 {
+		steps = append(steps, data.TaskApprovalStep{
+			Stage:        1,
+			Name:         "Task manager approval",
+			ApproverType: data.ApproverPermission,
+			Permission:   data.PermTasksManage,
+			Status:       data.StepWaiting,
+		})
+	}
+
+	data.AdvanceSteps(steps)
+	return steps, nil
+}
+
+// managerAbove walks levels up associate's manager chain. It returns nil when
+// the chain is shorter than that.
+func (app *Application) managerAbove(associate *data.Associate, levels int) (*int, error) {
+	current := associate
+	seen := map[int]bool{associate.ID: true}
+	for i := 0; i < levels; i++ {
+		if current.ManagerID == nil || seen[*current.ManagerID] {
+			return nil, nil
+		}
+		seen[*current.ManagerID] = true
+
+		manager, err := app.Models.Associates.GetOne(*current.ManagerID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		current = manager
+	}
+	return &current.ID, nil
+}
+
+// taskUndecided reports whether task is pending with no step decided yet.
+func taskUndecided(task *data.Task) bool {
+	if !strings.EqualFold(task.Status, data.TaskPending) {
+		return false
+	}
+	for _, step := range task.Approvers {
+		if step.DecidedBy != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// inManagerChain reports whether managerID is anywhere above associate in
+// the manager chain.
+func (app *Application) inManagerChain(managerID int, associate *data.Associate) bool {
+	current := associate
+	seen := map[int]bool{associate.ID: true}
+	for current.ManagerID != nil && !seen[*current.ManagerID] {
+		if *current.ManagerID == managerID {
+			return true
+		}
+		seen[*current.ManagerID] = true
+
+		manager, err := app.Models.Associates.GetOne(*current.ManagerID)
+		if err != nil {
+			return false
+		}
+		current = manager
+	}
+	return false
+}
+
+// actionableTaskSteps lists the pending steps of task that user may decide,
+// with whose approval they give as a delegate. Task managers may decide any
+// pending step when override is set. Requesters never decide their own task.
+func (app *Application) actionableTaskSteps(user *data.Associate, task *data.Task, override bool) []data.StepDecision {
+	var decisions []data.StepDecision
+	if user == nil || user.ID == task.RequesterID || !strings.EqualFold(task.Status, data.TaskPending) {
+		return decisions
+	}
+	isTaskManager := override && app.can(user, data.PermTasksManage)
+
+	for _, step := range task.Approvers {
+		if step.Status != data.StepPending {
+			continue
+		}
+
+		var onBehalfOf *int
+		allowed := isTaskManager
+		switch step.ApproverType {
+		case data.ApproverManager, data.ApproverAssociate:
+			if step.ApproverID != nil && *step.ApproverID == user.ID {
+				allowed = true
+			} else if !allowed {
+				onBehalfOf = app.delegatedApprover(user, step.ApproverID, task.RequesterID)
+				allowed = onBehalfOf != nil
+			}
+		case data.ApproverRole:
+			if !allowed {
+				allowed, _ = app.Models.Roles.HasRole(user.ID, step.Role)
+			}
+		case data.ApproverPermission:
+			allowed = allowed || app.can(user, step.Permission)
+		}
+
+		if allowed {
+			decisions = append(decisions, data.StepDecision{StepID: step.ID, OnBehalfOf: onBehalfOf})
+		}
+	}
+	return decisions
+}
+
+// DecideTask approves or rejects the steps of a task waiting on the caller.
+// Without a step_id every such step of the current stage is decided.
+func (app *Application) DecideTask(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	var payload struct {
+		Decision string `json:"decision"`
+		Comment  string `json:"comment"`
+		StepID   *int   `json:"step_id"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	var approve bool
+	switch strings.ToLower(payload.Decision) {
+	case "approve", "approved":
+		approve = true
+	case "reject", "rejected":
+		approve = false
+	default:
+		app.errorJSON(w, errors.New("decision must be approve or reject"))
+		return
+	}
+
+	task, err := app.Models.Tasks.GetOne(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.errorJSON(w, errors.New("task not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+	if !strings.EqualFold(task.Status, data.TaskPending) {
+		app.errorJSON(w, fmt.Errorf("the task is already %s", task.Status), http.StatusConflict)
+		return
+	}
+
+	currentUser := app.currentUser(r)
+	decisions := app.actionableTaskSteps(currentUser, task, true)
+	if payload.StepID != nil {
+		var chosen []data.StepDecision
+		for _, d := range decisions {
+			if d.StepID == *payload.StepID {
+				chosen = append(chosen, d)
+			}
+		}
+		decisions = chosen
+	}
+	if len(decisions) == 0 {
+		app.errorJSON(w, errors.New("forbidden: no step of this task is waiting on you"), http.StatusForbidden)
+		return
+	}
+
+	status, err := app.Models.Tasks.Decide(id, decisions, approve, currentUser.ID, payload.Comment)
+	if err != nil {
+		if errors.Is(err, data.ErrTaskDecided) || errors.Is(err, data.ErrStepDecided) {
+			app.errorJSON(w, err, http.StatusConflict)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	response := struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}{
+		Status:  status,
+		Message: "Decision recorded",
+	}
+
+	out, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+func (app *Application) GetTaskWorkflows(w http.ResponseWriter, r *http.Request) {
+	workflows, err := app.Models.TaskWorkflows.GetAll()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	out, _ := json.Marshal(workflows)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+// validateTaskWorkflow checks a workflow's steps and that the roles and
+// associates they name exist.
+func (app *Application) validateTaskWorkflow(workflow *data.TaskWorkflow) error {
+	workflow.TaskName = strings.TrimSpace(workflow.TaskName)
+	if workflow.TaskName == "" {
+		return errors.New("task_name is required")
+	}
+	if len(workflow.Steps) == 0 {
+		return errors.New("a workflow needs at least one step")
+	}
+
+	roles, err := app.Models.Roles.GetAll()
+	if err != nil {
+		return err
+	}
+
+	for i, step := range workflow.Steps {
+		if err := step.Validate(); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+
+		switch step.ApproverType {
+		case data.ApproverRole:
+			known := false
+			for _, role := range roles {
+				known = known || role.Name == step.Role
+			}
+			if !known {
+				return fmt.Errorf("step %d: unknown role: %s", i+1, step.Role)
+			}
+		case data.ApproverAssociate:
+			if _, err := app.Models.Associates.GetOne(*step.AssociateID); err != nil {
+				return fmt.Errorf("step %d: unknown associate: %d", i+1, *step.AssociateID)
+			}
+		}
+	}
+	return nil
+}
+
+func (app *Application) CreateTaskWorkflow(w http.ResponseWriter, r *http.Request) {
+	var workflow data.TaskWorkflow
+	err := json.NewDecoder(r.Body).Decode(&workflow)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if err := app.validateTaskWorkflow(&workflow); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	existing, err := app.Models.TaskWorkflows.GetByTaskName(workflow.TaskName)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	if existing != nil {
+		app.errorJSON(w, errors.New("a workflow for "+workflow.TaskName+" already exists"), http.StatusConflict)
+		return
+	}
+
+	id, err := app.Models.TaskWorkflows.Insert(workflow)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		ID      int    `json:"id"`
+		Message string `json:"message"`
+	}{
+		ID:      id,
+		Message: "Task workflow created successfully",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(out)
+}
+
+// UpdateTaskWorkflow replaces a workflow's steps. Tasks already filed keep
+// the steps they were given.
+func (app *Application) UpdateTaskWorkflow(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	var workflow data.TaskWorkflow
+	err = json.NewDecoder(r.Body).Decode(&workflow)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if err := app.validateTaskWorkflow(&workflow); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	existing, err := app.Models.TaskWorkflows.GetByTaskName(workflow.TaskName)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	if existing != nil && existing.ID != id {
+		app.errorJSON(w, errors.New("a workflow for "+workflow.TaskName+" already exists"), http.StatusConflict)
+		return
+	}
+
+	err = app.Models.TaskWorkflows.Update(id, workflow)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.errorJSON(w, errors.New("task workflow not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Task workflow updated successfully",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+func (app *Application) DeleteTaskWorkflow(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	err = app.Models.TaskWorkflows.Delete(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	payload := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Task workflow deleted successfully",
+	}
+
+	out, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"backend/internal/calendar"
+	"backend/internal/data"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type timeOffConflicts struct {
+	// Overlapping lists the requester's own active requests for the same days
+	Overlapping []*data.TimeOffRequest `json:"overlapping"`
+	// Teammates lists teammates' active requests for the same days
+	Teammates []*data.TimeOffRequest `json:"teammates"`
+	TeamScope string                 `json:"team_scope"`
+	// MaxOut is the configured limit, nil when the coverage check is off
+	MaxOut *int `json:"max_out"`
+	// PeakOut is the most people out on a single working day, counting the
+	// requester and teammates with approved time off
+	PeakOut      int     `json:"peak_out"`
+	PeakDate     *string `json:"peak_date"`
+	ExceedsLimit bool    `json:"exceeds_limit"`
+}
+
+// coverageSettings reads the team-coverage policy: which associates count as
+// a team (time_off_coverage_scope: manager or department) and how many of
+// them may be out at once (time_off_coverage_max_out; empty disables it).
+func (app *Application) coverageSettings() (string, *int) {
+	scope := data.TeamScopeManager // default
+	scopeSetting, _ := app.Models.AppSettings.Get("time_off_coverage_scope")
+	if scopeSetting != nil && scopeSetting.Value != "" {
+		scope = strings.ToLower(strings.TrimSpace(scopeSetting.Value))
+	}
+
+	var maxOut *int
+	maxOutSetting, _ := app.Models.AppSettings.Get("time_off_coverage_max_out")
+	if maxOutSetting != nil && maxOutSetting.Value != "" {
+		if val, err := strconv.Atoi(strings.TrimSpace(maxOutSetting.Value)); err == nil && val >= 0 {
+			maxOut = &val
+		}
+	}
+
+	return scope, maxOut
+}
+
+// findTimeOffConflicts collects the requester's overlapping requests and, when
+// the coverage check is configured, how many teammates would be out alongside
+// them.
+func (app *Application) findTimeOffConflicts(req *data.TimeOffRequest, requester *data.Associate, cal *calendar.WorkingCalendar) (*timeOffConflicts, error) {
+	overlapping, err := app.Models.TimeOffRequests.GetOverlapping(requester.ID, req.StartDate, req.EndDate, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	overlapping = clashingTimeOff(req, overlapping, app.hoursPerDay())
+
+	scope, maxOut := app.coverageSettings()
+	conflicts := &timeOffConflicts{
+		Overlapping: overlapping,
+		Teammates:   []*data.TimeOffRequest{},
+		TeamScope:   scope,
+		MaxOut:      maxOut,
+	}
+	if conflicts.Overlapping == nil {
+		conflicts.Overlapping = []*data.TimeOffRequest{}
+	}
+	if scope != data.TeamScopeManager && scope != data.TeamScopeDepartment {
+		return conflicts, nil
+	}
+
+	teammates, err := app.Models.TimeOffRequests.GetTeamOverlapping(scope, *requester, req.StartDate, req.EndDate, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if teammates != nil {
+		conflicts.Teammates = teammates
+	}
+
+	// Count the requester plus every teammate with booked time off, day by day
+	start, end := calendar.Date(req.StartDate), calendar.Date(req.EndDate)
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		working, err := cal.IsWorkingDay(day)
+		if err != nil {
+			return nil, err
+		}
+		if !working {
+			continue
+		}
+
+		out := map[int]bool{requester.ID: true}
+		for _, t := range teammates {
+			booked := t.Status == data.TimeOffApproved || t.Status == data.TimeOffCancellationRequested
+			if booked && !day.Before(calendar.Date(t.StartDate)) && !day.After(calendar.Date(t.EndDate)) {
+				out[t.AssociateID] = true
+			}
+		}
+
+		if len(out) > conflicts.PeakOut {
+			date := day.Format("2006-01-02")
+			conflicts.PeakOut = len(out)
+			conflicts.PeakDate = &date
+		}
+	}
+
+	conflicts.ExceedsLimit = maxOut != nil && conflicts.PeakOut > *maxOut
+	return conflicts, nil
+}
+
+// clashingTimeOff narrows a request's date overlaps to those that claim the
+// same time. Partial days on one day fit together (a morning and an
+// afternoon) unless they share a half or add up to more than a day.
+func clashingTimeOff(req *data.TimeOffRequest, overlapping []*data.TimeOffRequest, hoursPerDay float64) []*data.TimeOffRequest {
+	if !req.IsPartialDay() {
+		return overlapping
+	}
+
+	var clashing, partial []*data.TimeOffRequest
+	total := req.DayFraction(hoursPerDay)
+	for _, o := range overlapping {
+		sameHalf := o.DayPortion == req.DayPortion && o.DayPortion != data.DayPortionHours
+		if !o.IsPartialDay() || sameHalf {
+			clashing = append(clashing, o)
+			continue
+		}
+		partial = append(partial, o)
+		total += o.DayFraction(hoursPerDay)
+	}
+
+	if total > 1 {
+		clashing = append(clashing, partial...)
+	}
+	return clashing
+}
+
+// overlapError describes the first of the requester's overlapping requests.
+func overlapError(overlapping []*data.TimeOffRequest) error {
+	o := overlapping[0]
+	return fmt.Errorf("overlaps your %s request #%d from %s to %s", strings.ToLower(o.Status), o.ID,
+		o.StartDate.Format("2006-01-02"), o.EndDate.Format("2006-01-02"))
+}
+
+// GetTimeOffConflicts shows an approver the overlapping requests and team
+// coverage for a request.
+func (app *Application) GetTimeOffConflicts(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, errors.New("invalid id parameter"))
+		return
+	}
+
+	req, err := app.Models.TimeOffRequests.GetOne(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	isApprover := false
+	for _, role := range app.timeOffActorRoles(app.currentUser(r), req) {
+		if role == data.TimeOffActorApprover || role == data.TimeOffActorAdmin {
+			isApprover = true
+		}
+	}
+	if !isApprover {
+		app.errorJSON(w, errors.New("forbidden: only the approver can view this request's conflicts"), http.StatusForbidden)
+		return
+	}
+
+	requester, err := app.Models.Associates.GetOne(req.AssociateID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	cal, err := app.holidayCalendar(requester)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	conflicts, err := app.findTimeOffConflicts(req, requester, cal)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	out, _ := json.Marshal(conflicts)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
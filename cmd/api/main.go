@@ -3,27 +3,46 @@ package main
 import (
 	"backend/internal/data"
 	"backend/internal/driver"
+	"backend/internal/migrate"
+	"backend/migrations"
+	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-    "strings"
+	"strconv"
 )
 
 type Config struct {
-	Port string
+	Port      string
+	JWTSecret string
 }
 
 type Application struct {
 	Config Config
 	DB     *sql.DB
 	Models data.Models
+	// directory is the associate search index, built on first use
+	directory directoryIndex
 }
 
 func main() {
 	var cfg Config
 	cfg.Port = "8080"
+	cfg.JWTSecret = os.Getenv("JWT_SECRET")
+	if cfg.JWTSecret == "" {
+		// Tokens signed with a random secret do not survive a restart and are
+		// not accepted by other replicas, so this is only suitable for local runs.
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatal("Cannot generate token secret! Dying...", err)
+		}
+		cfg.JWTSecret = hex.EncodeToString(secret)
+		log.Println("JWT_SECRET is not set, using a random secret for this process")
+	}
 
 	dbUser := os.Getenv("DB_USER")
 	dbPassword := os.Getenv("DB_PASSWORD")
@@ -40,8 +59,19 @@ func main() {
 	}
 	defer db.SQL.Close()
 
-	// Auto-migration for existing tables
-    migrateDB(db.SQL)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrateCommand(db.SQL, os.Args[2:])
+		return
+	}
+
+	// Bring the schema up to date; replicas starting together serialize on the migration lock
+	applied, err := migrate.New(db.SQL, migrations.FS).Up(context.Background())
+	if err != nil {
+		log.Fatal("Cannot migrate database! Dying...", err)
+	}
+	for _, m := range applied {
+		log.Printf("Applied migration %03d_%s", m.Version, m.Name)
+	}
 
 	app := &Application{
 		Config: cfg,
@@ -49,6 +79,10 @@ func main() {
 		Models: data.New(db.SQL),
 	}
 
+	// Every replica runs the schedulers; named locks let one of them act at a time
+	go app.runEscalations(context.Background())
+	go app.runSalaryChanges(context.Background())
+
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%s", cfg.Port),
 		Handler: app.routes(),
@@ -61,127 +95,61 @@ func main() {
 	}
 }
 
-func migrateDB(db *sql.DB) {
-    // Create tables if they don't exist
-    queries := []string{
-        `CREATE TABLE IF NOT EXISTS Offices (
-            id INT AUTO_INCREMENT PRIMARY KEY,
-            name VARCHAR(255) NOT NULL
-        );`,
-        `CREATE TABLE IF NOT EXISTS Departments (
-            id INT AUTO_INCREMENT PRIMARY KEY,
-            name VARCHAR(255) NOT NULL
-        );`,
-        `CREATE TABLE IF NOT EXISTS Associates (
-            id INT AUTO_INCREMENT PRIMARY KEY,
-            first_name VARCHAR(255) NOT NULL,
-            last_name VARCHAR(255) NOT NULL,
-            title VARCHAR(255),
-            department VARCHAR(255),
-            office VARCHAR(255),
-            status VARCHAR(50),
-            start_date DATETIME,
-            empl_status VARCHAR(50),
-            salary INT,
-            dob DATETIME,
-            profile_picture VARCHAR(255),
-            email VARCHAR(255) UNIQUE,
-            password VARCHAR(255)
-        );`,
-        `CREATE TABLE IF NOT EXISTS Tasks (
-            id INT AUTO_INCREMENT PRIMARY KEY,
-            requester_id INT NOT NULL,
-            task_name VARCHAR(255) NOT NULL,
-            task_value VARCHAR(255) NOT NULL,
-            reason TEXT,
-            status VARCHAR(50) DEFAULT 'pending',
-            target_value INT,
-            approvers JSON,
-            timestamp INT,
-            comments TEXT
-        );`,
-        `CREATE TABLE IF NOT EXISTS Thanks (
-            id INT AUTO_INCREMENT PRIMARY KEY,
-            from_id INT,
-            to_id INT,
-            message TEXT,
-            category VARCHAR(255),
-            timestamp BIGINT,
-            FOREIGN KEY (from_id) REFERENCES Associates(id),
-            FOREIGN KEY (to_id) REFERENCES Associates(id)
-        );`,
-        `CREATE TABLE IF NOT EXISTS time_off_requests (
-            id INT AUTO_INCREMENT PRIMARY KEY,
-            associate_id INT NOT NULL,
-            start_date DATETIME NOT NULL,
-            end_date DATETIME NOT NULL,
-            reason TEXT,
-            status VARCHAR(50) DEFAULT 'Pending',
-            created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-            updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-            FOREIGN KEY (associate_id) REFERENCES Associates(id)
-        );`,
-        `CREATE TABLE IF NOT EXISTS time_entries (
-            id INT AUTO_INCREMENT PRIMARY KEY,
-            associate_id INT NOT NULL,
-            date DATETIME NOT NULL,
-            hours DECIMAL(5, 2) NOT NULL,
-            overtime_hours DECIMAL(5, 2) NOT NULL DEFAULT 0,
-            comments TEXT,
-            status VARCHAR(50) DEFAULT 'Approved',
-            created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-            FOREIGN KEY (associate_id) REFERENCES Associates(id)
-        );`,
-        // Attempt to add status column if it doesn't exist (for existing tables)
-        `ALTER TABLE time_entries ADD COLUMN status VARCHAR(50) DEFAULT 'Approved';`,
-        `CREATE TABLE IF NOT EXISTS menu_permissions (
-            id INT AUTO_INCREMENT PRIMARY KEY,
-            menu_item VARCHAR(255) NOT NULL,
-            permission_type VARCHAR(50) NOT NULL,
-            permission_value VARCHAR(255),
-            created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-        );`,
-        `CREATE TABLE IF NOT EXISTS thanks_categories (
-            id INT AUTO_INCREMENT PRIMARY KEY,
-            name VARCHAR(255) NOT NULL UNIQUE
-        );`,
-        `CREATE TABLE IF NOT EXISTS AppSettings (
-            setting_key VARCHAR(255) PRIMARY KEY,
-            setting_value TEXT
-        );`,
-    }
-
-    for _, query := range queries {
-        _, err := db.Exec(query)
-        if err != nil {
-            if strings.Contains(err.Error(), "Duplicate column name") || strings.Contains(err.Error(), "1060") {
-               log.Printf("Migration warning (safe to ignore): %v", err)
-               continue
-            }
-            log.Printf("Error executing migration query: %v\nQuery: %s", err, query)
-        }
-    }
-    
-    // Seed basic data if empty
-    var count int
-    err := db.QueryRow("SELECT COUNT(*) FROM Offices").Scan(&count)
-    if err == nil && count == 0 {
-         db.Exec("INSERT INTO Offices (name) VALUES ('London'), ('New York'), ('Paris')")
-    }
-    err = db.QueryRow("SELECT COUNT(*) FROM Departments").Scan(&count)
-    if err == nil && count == 0 {
-         db.Exec("INSERT INTO Departments (name) VALUES ('IT'), ('HR'), ('Design'), ('Sales')")
-    }
-
-    // Seed menu permissions for Time Entry if not exists
-    err = db.QueryRow("SELECT COUNT(*) FROM menu_permissions WHERE menu_item = 'Time Entry'").Scan(&count)
-    if err == nil && count == 0 {
-         db.Exec("INSERT INTO menu_permissions (menu_item, permission_type, permission_value) VALUES ('Time Entry', 'everyone', NULL)")
-    }
-
-    // Seed thanks categories if not exists
-    err = db.QueryRow("SELECT COUNT(*) FROM thanks_categories").Scan(&count)
-    if err == nil && count == 0 {
-         db.Exec("INSERT INTO thanks_categories (name) VALUES ('Team Player'), ('Superhero'), ('Thank You!'), ('Knowledge')")
-    }
+// runMigrateCommand implements `api migrate up|down [steps]|status`.
+func runMigrateCommand(db *sql.DB, args []string) {
+	migrator := migrate.New(db, migrations.FS)
+	ctx := context.Background()
+
+	if len(args) == 0 {
+		log.Fatal("usage: migrate up|down [steps]|status")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, m := range applied {
+			fmt.Printf("applied  %03d_%s\n", m.Version, m.Name)
+		}
+		fmt.Printf("%d migration(s) applied\n", len(applied))
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatal("steps must be a positive number")
+			}
+			steps = n
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, m := range reverted {
+			fmt.Printf("reverted %03d_%s\n", m.Version, m.Name)
+		}
+		fmt.Printf("%d migration(s) reverted\n", len(reverted))
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.ChecksumMismatch {
+				state += " (modified since applied)"
+			}
+			fmt.Printf("%03d_%-40s %s\n", s.Version, s.Name, state)
+		}
+
+	default:
+		log.Fatal("usage: migrate up|down [steps]|status")
+	}
 }
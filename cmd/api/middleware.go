@@ -1,14 +1,23 @@
 package main
 
 import (
+	"backend/internal/data"
+	"context"
+	"errors"
+	"log"
 	"net/http"
+	"strings"
 )
 
+type contextKey string
+
+const currentUserKey contextKey = "currentUser"
+
 func (app *Application) enableCORS(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-User-ID")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -19,12 +28,111 @@ func (app *Application) enableCORS(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-func (app *Application) requireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+// authenticate verifies the bearer access token and stores the associate it
+// was issued to in the request context.
+func (app *Application) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		authHeader := r.Header.Get("Authorization")
+		token, found := strings.CutPrefix(authHeader, "Bearer ")
+		if !found || token == "" {
+			app.errorJSON(w, errors.New("user authentication required"), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := app.parseToken(token, tokenTypeAccess)
+		if err != nil {
+			app.errorJSON(w, err, http.StatusUnauthorized)
+			return
+		}
+
+		associate, err := app.Models.Associates.GetOne(claims.Subject)
+		if err != nil {
+			app.errorJSON(w, errors.New("user authentication required"), http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), currentUserKey, associate)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// currentUser returns the authenticated associate for the request, or nil
+// when the route is not behind authenticate.
+func (app *Application) currentUser(r *http.Request) *data.Associate {
+	associate, _ := r.Context().Value(currentUserKey).(*data.Associate)
+	return associate
+}
+
+// can is the single policy decision point: it reports whether the associate
+// holds permission through any of their roles.
+func (app *Application) can(associate *data.Associate, permission string) bool {
+	if associate == nil {
+		return false
+	}
+
+	allowed, err := app.Models.Roles.HasPermission(associate.ID, permission)
+	if err != nil {
+		log.Printf("Permission check failed for associate %d: %v", associate.ID, err)
+		return false
+	}
+	return allowed
+}
+
+func (app *Application) requirePermission(permission string, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		currentUser := app.currentUser(r)
+		if currentUser == nil {
+			app.errorJSON(w, errors.New("user authentication required"), http.StatusUnauthorized)
+			return
+		}
+
+		if !app.can(currentUser, permission) {
+			app.errorJSON(w, errors.New("forbidden: missing permission "+permission), http.StatusForbidden)
+			return
+		}
+
 		next(w, r)
 	}
 }
 
+// requireMenuAccess blocks features whose menu item is hidden from the caller
+// by the menu_permissions rules.
+func (app *Application) requireMenuAccess(menuItem string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			currentUser := app.currentUser(r)
+			if currentUser == nil {
+				app.errorJSON(w, errors.New("user authentication required"), http.StatusUnauthorized)
+				return
+			}
+
+			rules, err := app.Models.MenuPermissions.GetAll()
+			if err != nil {
+				app.errorJSON(w, err, http.StatusInternalServerError)
+				return
+			}
+
+			roles, err := app.roleNames(currentUser.ID)
+			if err != nil {
+				app.errorJSON(w, err, http.StatusInternalServerError)
+				return
+			}
+
+			if !data.CanAccessMenuItem(rules, *currentUser, roles, menuItem) {
+				app.errorJSON(w, errors.New("forbidden: "+menuItem+" is not available to you"), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func (app *Application) statusHandler(getHandler, putHandler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
@@ -1,11 +1,12 @@
 package main
 
 import (
+	"backend/internal/data"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-    "github.com/go-chi/cors"
+	"github.com/go-chi/cors"
 )
 
 func (app *Application) routes() http.Handler {
@@ -15,85 +16,167 @@ func (app *Application) routes() http.Handler {
 	mux.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Cache-Control", "Pragma", "X-User-ID"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Cache-Control", "Pragma"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
 	mux.Get("/", app.Home)
-	mux.Post("/associates", app.CreateAssociate)
-    mux.Put("/associates/{id}", app.UpdateAssociate)
-    mux.Put("/associates/{id}/password", app.ChangePassword)
-    mux.Delete("/associates/{id}", app.DeleteAssociate)
-    mux.Get("/associates/{id}", app.GetAssociate)
-	
-    mux.HandleFunc("/admin/sidebar-order", app.enableCORS(app.requireRole("Admin", app.statusHandler(http.HandlerFunc(app.GetSidebarOrder), app.UpdateSidebarOrder))))
-	mux.HandleFunc("/admin/dashboard-order", app.enableCORS(app.requireRole("Admin", app.statusHandler(http.HandlerFunc(app.GetDashboardOrder), app.UpdateDashboardOrder))))
-
-    mux.Get("/associates", app.GetAllAssociates)
-    
-    mux.Post("/login", app.Login)
-    mux.Post("/register", app.Register)
-
-	mux.Get("/offices", app.GetAllOffices)
-    mux.Post("/offices", app.CreateOffice)
-    mux.Delete("/offices/{id}", app.DeleteOffice)
-
-	mux.Get("/departments", app.GetAllDepartments)
-    mux.Post("/departments", app.CreateDepartment)
-    mux.Delete("/departments/{id}", app.DeleteDepartment)
-
-    mux.Get("/document-categories", app.GetAllDocumentCategories)
-    mux.Post("/document-categories", app.CreateDocumentCategory)
-    mux.Delete("/document-categories/{id}", app.DeleteDocumentCategory)
-
-	mux.Post("/tasks", app.CreateTask)
-	mux.Get("/tasks", app.GetTasks)
-    mux.Get("/tasks/{id}", app.GetTask)
-    mux.Put("/tasks/{id}", app.UpdateTask)
-    mux.Delete("/tasks/{id}", app.DeleteTask)
-
-	mux.Post("/thanks", app.CreateThank)
-	mux.Get("/thanks", app.GetThanks)
-    mux.Get("/thanks/{id}", app.GetThank)
-    mux.Put("/thanks/{id}", app.UpdateThank)
-    mux.Delete("/thanks/{id}", app.DeleteThank)
-	mux.Get("/thanks/{id}/social", app.GetThanksSocialData)
-	mux.Post("/thanks/{id}/like", app.LikeThank)
-	mux.Post("/thanks/{id}/unlike", app.UnlikeThank)
-	mux.Post("/thanks/{id}/comment", app.AddCommentToThank)
-	mux.Delete("/thanks/comment/{id}", app.DeleteComment)
-
-    mux.Post("/time-off", app.CreateTimeOffRequest)
-    mux.Get("/time-off", app.GetAllTimeOffRequests)
-    mux.Get("/time-off/{id}", app.GetTimeOffRequest)
-    mux.Put("/time-off/{id}", app.UpdateTimeOffRequest)
-    mux.Put("/time-off/{id}/status", app.UpdateTimeOffStatus)
-    mux.Delete("/time-off/{id}", app.DeleteTimeOffRequest)
-
-    mux.Get("/menu-permissions", app.GetAllMenuPermissions)
-    mux.Post("/menu-permissions", app.CreateMenuPermission)
-    mux.Delete("/menu-permissions/{id}", app.DeleteMenuPermission)
-
-	mux.Get("/settings/{key}", app.GetSetting)
-	mux.Put("/settings", app.UpdateSetting)
-
-    mux.Post("/time-entry", app.CreateTimeEntry)
-    mux.Get("/time-entry", app.GetTimeEntries)
-    mux.Put("/time-entry/{id}/status", app.ApproveTimeEntry)
-    mux.Delete("/time-entry/{id}", app.DeleteTimeEntry)
-
-    mux.Get("/thanks-categories", app.GetAllThanksCategories)
-    mux.Post("/thanks-categories", app.CreateThanksCategory)
-    mux.Delete("/thanks-categories/{id}", app.DeleteThanksCategory)
-
-    mux.Get("/associates/{id}/pto-balance", app.GetPTOBalance)
-
-    mux.Get("/holidays", app.GetHolidays)
-    mux.Post("/holidays", app.CreateHoliday)
-    mux.Put("/holidays/{id}", app.UpdateHoliday)
-    mux.Delete("/holidays/{id}", app.DeleteHoliday)
+	mux.Post("/login", app.Login)
+	mux.Post("/refresh", app.RefreshToken)
+	mux.Post("/register", app.Register)
+	// Calendar clients cannot send a bearer token; the feed token authenticates
+	mux.Get("/calendar-feeds/{token}.ics", app.GetCalendarFeedICS)
+
+	mux.Group(func(mux chi.Router) {
+		mux.Use(app.authenticate)
+
+		mux.Post("/associates", app.requirePermission(data.PermAssociatesManage, app.CreateAssociate))
+		mux.Post("/associates/import", app.requirePermission(data.PermAssociatesManage, app.ImportAssociates))
+		mux.Put("/associates/{id}", app.UpdateAssociate)
+		mux.Put("/associates/{id}/password", app.ChangePassword)
+		mux.Delete("/associates/{id}", app.requirePermission(data.PermAssociatesManage, app.DeleteAssociate))
+		mux.Get("/associates/{id}", app.GetAssociate)
+
+		// Every client reads the order to render its layout; settings managers change it
+		mux.HandleFunc("/admin/sidebar-order", app.enableCORS(app.statusHandler(http.HandlerFunc(app.GetSidebarOrder), app.requirePermission(data.PermSettingsManage, app.UpdateSidebarOrder))))
+		mux.HandleFunc("/admin/dashboard-order", app.enableCORS(app.statusHandler(http.HandlerFunc(app.GetDashboardOrder), app.requirePermission(data.PermSettingsManage, app.UpdateDashboardOrder))))
+
+		mux.Get("/associates", app.GetAllAssociates)
+		mux.Get("/associates/search", app.SearchAssociates)
+		mux.Get("/associates/export", app.ExportAssociates)
+		mux.Get("/associates/{id}/reports", app.GetAssociateReports)
+		mux.Get("/associates/{id}/chain", app.GetAssociateChain)
+		mux.Get("/org-chart", app.GetOrgChart)
+		mux.Get("/associate-field-visibility", app.GetFieldVisibility)
+		mux.Put("/associate-field-visibility", app.requirePermission(data.PermSettingsManage, app.UpdateFieldVisibility))
+
+		mux.Get("/offices", app.GetAllOffices)
+		mux.Post("/offices", app.requirePermission(data.PermOrgManage, app.CreateOffice))
+		mux.Delete("/offices/{id}", app.requirePermission(data.PermOrgManage, app.DeleteOffice))
+
+		mux.Get("/departments", app.GetAllDepartments)
+		mux.Post("/departments", app.requirePermission(data.PermOrgManage, app.CreateDepartment))
+		mux.Delete("/departments/{id}", app.requirePermission(data.PermOrgManage, app.DeleteDepartment))
+
+		mux.Get("/document-categories", app.GetAllDocumentCategories)
+		mux.Post("/document-categories", app.requirePermission(data.PermOrgManage, app.CreateDocumentCategory))
+		mux.Delete("/document-categories/{id}", app.requirePermission(data.PermOrgManage, app.DeleteDocumentCategory))
+
+		mux.Post("/tasks", app.CreateTask)
+		mux.Get("/tasks", app.GetTasks)
+		mux.Get("/tasks/{id}", app.GetTask)
+		mux.Put("/tasks/{id}", app.UpdateTask)
+		mux.Delete("/tasks/{id}", app.DeleteTask)
+		mux.Post("/tasks/{id}/decision", app.DecideTask)
+		mux.Get("/task-workflows", app.GetTaskWorkflows)
+		mux.Post("/task-workflows", app.requirePermission(data.PermTasksManage, app.CreateTaskWorkflow))
+		mux.Put("/task-workflows/{id}", app.requirePermission(data.PermTasksManage, app.UpdateTaskWorkflow))
+		mux.Delete("/task-workflows/{id}", app.requirePermission(data.PermTasksManage, app.DeleteTaskWorkflow))
+
+		mux.Get("/delegations", app.GetDelegations)
+		mux.Post("/delegations", app.CreateDelegation)
+		mux.Delete("/delegations/{id}", app.DeleteDelegation)
+
+		mux.Group(func(mux chi.Router) {
+			mux.Use(app.requireMenuAccess("thanks"))
+
+			mux.Post("/thanks", app.CreateThank)
+			mux.Get("/thanks", app.GetThanks)
+			mux.Get("/thanks/{id}", app.GetThank)
+			mux.Put("/thanks/{id}", app.UpdateThank)
+			mux.Delete("/thanks/{id}", app.DeleteThank)
+			mux.Get("/thanks/{id}/social", app.GetThanksSocialData)
+			mux.Post("/thanks/{id}/like", app.LikeThank)
+			mux.Post("/thanks/{id}/unlike", app.UnlikeThank)
+			mux.Post("/thanks/{id}/comment", app.AddCommentToThank)
+			mux.Delete("/thanks/comment/{id}", app.DeleteComment)
+		})
+
+		// The menu rules gate filing one's own time off; approvers decide
+		// their reports' requests whatever the menu shows them, and the
+		// handlers check who may see or decide each request
+		mux.Group(func(mux chi.Router) {
+			mux.Use(app.requireMenuAccess("time off"))
+
+			mux.Post("/time-off", app.CreateTimeOffRequest)
+			mux.Put("/time-off/{id}", app.UpdateTimeOffRequest)
+			mux.Delete("/time-off/{id}", app.DeleteTimeOffRequest)
+
+			mux.Get("/calendar/feeds", app.GetCalendarFeeds)
+			mux.Post("/calendar/feeds", app.CreateCalendarFeed)
+			mux.Delete("/calendar/feeds/{id}", app.DeleteCalendarFeed)
+		})
+		mux.Get("/time-off", app.GetAllTimeOffRequests)
+		mux.Get("/time-off/{id}", app.GetTimeOffRequest)
+		mux.Put("/time-off/{id}/status", app.UpdateTimeOffStatus)
+		mux.Get("/time-off/{id}/history", app.GetTimeOffHistory)
+		mux.Get("/time-off/{id}/conflicts", app.GetTimeOffConflicts)
+		mux.Get("/calendar", app.GetCalendar)
+
+		mux.Get("/menu-permissions", app.GetAllMenuPermissions)
+		mux.Post("/menu-permissions", app.requirePermission(data.PermMenuPermissionsManage, app.CreateMenuPermission))
+		mux.Delete("/menu-permissions/{id}", app.requirePermission(data.PermMenuPermissionsManage, app.DeleteMenuPermission))
+		mux.Get("/me/menu", app.GetMyMenu)
+		mux.Get("/associates/{id}/effective-permissions", app.requirePermission(data.PermMenuPermissionsManage, app.GetEffectivePermissions))
+
+		mux.Get("/settings/{key}", app.GetSetting)
+		mux.Put("/settings", app.requirePermission(data.PermSettingsManage, app.UpdateSetting))
+
+		// Likewise, the menu gates logging one's own hours but not approving them
+		mux.Group(func(mux chi.Router) {
+			mux.Use(app.requireMenuAccess("time entry"))
+
+			mux.Post("/time-entry", app.CreateTimeEntry)
+			mux.Delete("/time-entry/{id}", app.DeleteTimeEntry)
+		})
+		mux.Get("/time-entry", app.GetTimeEntries)
+		mux.Put("/time-entry/{id}/status", app.ApproveTimeEntry)
+		mux.Get("/time-entry/{id}/escalations", app.GetTimeEntryEscalations)
+
+		mux.Get("/thanks-categories", app.GetAllThanksCategories)
+		mux.Post("/thanks-categories", app.requirePermission(data.PermTasksManage, app.CreateThanksCategory))
+		mux.Delete("/thanks-categories/{id}", app.requirePermission(data.PermTasksManage, app.DeleteThanksCategory))
+
+		mux.Get("/associates/{id}/compensation", app.GetCompensation)
+		mux.Post("/associates/{id}/compensation", app.requirePermission(data.PermCompensationManage, app.CreateCompensationChange))
+		mux.Post("/associates/{id}/compensation/{changeId}/cancel", app.requirePermission(data.PermCompensationManage, app.CancelSalaryChange))
+		mux.Get("/compensation/compa-ratio", app.requirePermission(data.PermCompensationView, app.GetCompaRatioReport))
+		mux.Get("/salary-ranges", app.requirePermission(data.PermCompensationView, app.GetSalaryRanges))
+		mux.Post("/salary-ranges", app.requirePermission(data.PermCompensationManage, app.CreateSalaryRange))
+		mux.Put("/salary-ranges/{id}", app.requirePermission(data.PermCompensationManage, app.UpdateSalaryRange))
+		mux.Delete("/salary-ranges/{id}", app.requirePermission(data.PermCompensationManage, app.DeleteSalaryRange))
+
+		mux.Get("/associates/{id}/pto-balance", app.GetPTOBalance)
+		mux.Get("/associates/{id}/pto-ledger", app.GetPTOLedger)
+		mux.Post("/associates/{id}/pto-ledger", app.requirePermission(data.PermPTOManage, app.CreatePTOAdjustment))
+
+		mux.Get("/leave-types", app.GetAllLeaveTypes)
+		mux.Post("/leave-types", app.requirePermission(data.PermLeaveTypesManage, app.CreateLeaveType))
+		mux.Put("/leave-types/{id}", app.requirePermission(data.PermLeaveTypesManage, app.UpdateLeaveType))
+		mux.Delete("/leave-types/{id}", app.requirePermission(data.PermLeaveTypesManage, app.DeleteLeaveType))
+
+		mux.Get("/holidays", app.GetHolidays)
+		mux.Get("/holidays/export", app.ExportHolidays)
+		mux.Post("/holidays/import", app.requirePermission(data.PermHolidaysManage, app.ImportHolidays))
+		mux.Post("/holidays", app.requirePermission(data.PermHolidaysManage, app.CreateHoliday))
+		mux.Put("/holidays/{id}", app.requirePermission(data.PermHolidaysManage, app.UpdateHoliday))
+		mux.Delete("/holidays/{id}", app.requirePermission(data.PermHolidaysManage, app.DeleteHoliday))
+		mux.Get("/holiday-rules", app.GetAllHolidayRules)
+		mux.Post("/holiday-rules", app.requirePermission(data.PermHolidaysManage, app.CreateHolidayRule))
+		mux.Put("/holiday-rules/{id}", app.requirePermission(data.PermHolidaysManage, app.UpdateHolidayRule))
+		mux.Delete("/holiday-rules/{id}", app.requirePermission(data.PermHolidaysManage, app.DeleteHolidayRule))
+
+		mux.Get("/permissions", app.requirePermission(data.PermRolesManage, app.GetPermissionCatalogue))
+		mux.Get("/roles", app.requirePermission(data.PermRolesManage, app.GetAllRoles))
+		mux.Post("/roles", app.requirePermission(data.PermRolesManage, app.CreateRole))
+		mux.Put("/roles/{id}/permissions", app.requirePermission(data.PermRolesManage, app.UpdateRolePermissions))
+		mux.Delete("/roles/{id}", app.requirePermission(data.PermRolesManage, app.DeleteRole))
+		mux.Get("/associates/{id}/roles", app.requirePermission(data.PermRolesManage, app.GetAssociateRoles))
+		mux.Post("/associates/{id}/roles", app.requirePermission(data.PermRolesManage, app.AssignAssociateRole))
+		mux.Delete("/associates/{id}/roles/{roleId}", app.requirePermission(data.PermRolesManage, app.RemoveAssociateRole))
+	})
 
 	return mux
 }
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	// salaryChangeInterval is how often the scheduler applies due salary changes
+	salaryChangeInterval = time.Hour
+	// salaryChangeLockName is the MySQL named lock held during a pass
+	salaryChangeLockName = "workops_salary_changes"
+)
+
+// applyDueSalaryChanges runs one pass: every scheduled salary change whose
+// effective date has come is applied. Replicas that find the lock taken skip
+// the pass, and a change is applied at most once even if passes overlap.
+func (app *Application) applyDueSalaryChanges(ctx context.Context) (int, error) {
+	release, err := app.Models.Escalations.TryLock(ctx, salaryChangeLockName)
+	if err != nil || release == nil {
+		return 0, err
+	}
+	defer release()
+
+	due, err := app.Models.SalaryChanges.GetDue(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, change := range due {
+		ok, err := app.Models.SalaryChanges.Apply(change.ID)
+		if err != nil {
+			log.Printf("Cannot apply salary change %d: %v", change.ID, err)
+			continue
+		}
+		if ok {
+			applied++
+		}
+	}
+
+	return applied, nil
+}
+
+// runSalaryChanges applies due salary changes every salaryChangeInterval
+// until ctx is done.
+func (app *Application) runSalaryChanges(ctx context.Context) {
+	ticker := time.NewTicker(salaryChangeInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := app.applyDueSalaryChanges(ctx)
+		if err != nil {
+			log.Printf("Applying salary changes failed: %v", err)
+		} else if n > 0 {
+			log.Printf("Applied %d salary change(s)", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
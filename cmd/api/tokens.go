@@ -0,0 +1,134 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 7 * 24 * time.Hour
+
+	tokenIssuer = "workops-api"
+)
+
+var (
+	errInvalidToken = errors.New("invalid token")
+	errExpiredToken = errors.New("token has expired")
+)
+
+// TokenClaims is the payload carried by access and refresh tokens.
+type TokenClaims struct {
+	Subject   int    `json:"sub"`
+	Type      string `json:"typ"`
+	Issuer    string `json:"iss"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// TokenPair is returned to the client after a successful login or refresh.
+type TokenPair struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+}
+
+// jwtHeader is fixed: tokens are always signed with HMAC-SHA256.
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// generateTokenPair issues a fresh access and refresh token for the associate.
+func (app *Application) generateTokenPair(associateID int) (*TokenPair, error) {
+	now := time.Now()
+
+	access, err := app.signToken(TokenClaims{
+		Subject:   associateID,
+		Type:      tokenTypeAccess,
+		Issuer:    tokenIssuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(accessTokenTTL).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := app.signToken(TokenClaims{
+		Subject:   associateID,
+		Type:      tokenTypeRefresh,
+		Issuer:    tokenIssuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(refreshTokenTTL).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(accessTokenTTL.Seconds()),
+		RefreshExpiresIn: int64(refreshTokenTTL.Seconds()),
+	}, nil
+}
+
+func (app *Application) signToken(claims TokenClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + app.tokenSignature(unsigned), nil
+}
+
+func (app *Application) tokenSignature(unsigned string) string {
+	mac := hmac.New(sha256.New, []byte(app.Config.JWTSecret))
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseToken verifies the signature, expiry and type of a token and returns its claims.
+func (app *Application) parseToken(token string, expectedType string) (*TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, errInvalidToken
+	}
+
+	expected := app.tokenSignature(parts[0] + "." + parts[1])
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return nil, errInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errInvalidToken
+	}
+
+	var claims TokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errInvalidToken
+	}
+
+	if claims.Issuer != tokenIssuer || claims.Subject <= 0 {
+		return nil, errInvalidToken
+	}
+	if claims.Type != expectedType {
+		return nil, fmt.Errorf("%w: expected %s token", errInvalidToken, expectedType)
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, errExpiredToken
+	}
+
+	return &claims, nil
+}
@@ -1,3 +1,6 @@
+-- Bootstraps a fresh Docker database with demo data. The schema itself is
+-- owned by the versioned files in migrations/, which the API applies on start.
+
 CREATE TABLE IF NOT EXISTS Offices (
     id INT AUTO_INCREMENT PRIMARY KEY,
     name VARCHAR(255) NOT NULL
@@ -98,17 +101,6 @@ CREATE TABLE IF NOT EXISTS thanks_comments (
     FOREIGN KEY (associate_id) REFERENCES Associates(id) ON DELETE CASCADE
 );
 
-CREATE TABLE IF NOT EXISTS TimeOffRequests (
-    id INT AUTO_INCREMENT PRIMARY KEY,
-    associate_id INT NOT NULL,
-    type VARCHAR(50) NOT NULL,
-    start_date DATETIME NOT NULL,
-    end_date DATETIME NOT NULL,
-    reason TEXT,
-    status VARCHAR(50) DEFAULT 'Pending',
-    FOREIGN KEY (associate_id) REFERENCES Associates(id)
-);
-
 CREATE TABLE IF NOT EXISTS DocumentCategories (
     id INT AUTO_INCREMENT PRIMARY KEY,
     name VARCHAR(255) NOT NULL
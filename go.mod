@@ -8,9 +8,7 @@ require (
 	github.com/go-chi/chi/v5 v5.2.3
 	github.com/go-chi/cors v1.2.2
 	github.com/go-sql-driver/mysql v1.9.3
+	golang.org/x/crypto v0.46.0
 )
 
-require (
-	filippo.io/edwards25519 v1.1.0 // indirect
-	golang.org/x/crypto v0.46.0 // indirect
-)
+require filippo.io/edwards25519 v1.1.0 // indirect
@@ -0,0 +1,114 @@
+// Package calendar answers working-day questions (weekends and company
+// holidays) for PTO and scheduling logic.
+package calendar
+
+import (
+	"backend/internal/data"
+	"time"
+)
+
+// HolidaySource is the subset of data.HolidayModel the calendar needs.
+type HolidaySource interface {
+	Materialize(year int, officeID *int) ([]data.Holiday, error)
+}
+
+// WorkingCalendar counts working days, treating weekends and holidays as days off.
+type WorkingCalendar struct {
+	Holidays HolidaySource
+	// OfficeID selects the office's holidays; nil uses the company-wide default
+	OfficeID *int
+
+	// holidays caches the resolved holiday dates per year
+	holidays map[int]map[string]data.Holiday
+}
+
+func New(holidays HolidaySource, officeID *int) *WorkingCalendar {
+	return &WorkingCalendar{
+		Holidays: holidays,
+		OfficeID: officeID,
+		holidays: map[int]map[string]data.Holiday{},
+	}
+}
+
+// Date strips the time of day so dates compare by calendar day only.
+func Date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func dateKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// HolidaysForYear returns the holidays observed in year, keyed by date:
+// one-off holidays plus those generated from holiday rules.
+func (c *WorkingCalendar) HolidaysForYear(year int) (map[string]data.Holiday, error) {
+	if days, ok := c.holidays[year]; ok {
+		return days, nil
+	}
+
+	holidays, err := c.Holidays.Materialize(year, c.OfficeID)
+	if err != nil {
+		return nil, err
+	}
+
+	days := map[string]data.Holiday{}
+	for _, h := range holidays {
+		days[dateKey(Date(h.Date))] = h
+	}
+
+	c.holidays[year] = days
+	return days, nil
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// IsWorkingDay reports whether day is neither a weekend nor a holiday.
+func (c *WorkingCalendar) IsWorkingDay(day time.Time) (bool, error) {
+	day = Date(day)
+	if isWeekend(day) {
+		return false, nil
+	}
+
+	holidays, err := c.HolidaysForYear(day.Year())
+	if err != nil {
+		return false, err
+	}
+	_, isHoliday := holidays[dateKey(day)]
+	return !isHoliday, nil
+}
+
+// WorkingDays counts the working days from start to end, both inclusive.
+func (c *WorkingCalendar) WorkingDays(start, end time.Time) (float64, error) {
+	byYear, err := c.WorkingDaysByYear(start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for _, days := range byYear {
+		total += days
+	}
+	return total, nil
+}
+
+// WorkingDaysByYear counts working days from start to end (inclusive), split
+// by the calendar year each day falls in. A Dec 30 - Jan 2 request is charged
+// partly to each year.
+func (c *WorkingCalendar) WorkingDaysByYear(start, end time.Time) (map[int]float64, error) {
+	byYear := map[int]float64{}
+	start, end = Date(start), Date(end)
+
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		working, err := c.IsWorkingDay(day)
+		if err != nil {
+			return nil, err
+		}
+		if working {
+			byYear[day.Year()]++
+		}
+	}
+
+	return byYear, nil
+}
@@ -0,0 +1,110 @@
+package data
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AssociateImport is one associate to create in a bulk import. ManagerRow
+// is the index of their manager when the same import creates them; a
+// manager already on file goes in Associate.ManagerID.
+type AssociateImport struct {
+	Associate  Associate
+	ManagerRow *int
+}
+
+// GetEmailIDs maps every associate's lower-cased email to their id.
+func (m AssociateModel) GetEmailIDs() (map[string]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT id, email FROM Associates WHERE email IS NOT NULL AND email <> ''`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := map[string]int{}
+	for rows.Next() {
+		var id int
+		var email string
+		if err := rows.Scan(&id, &email); err != nil {
+			return nil, err
+		}
+		ids[strings.ToLower(email)] = id
+	}
+
+	return ids, rows.Err()
+}
+
+// ImportMany creates associates in one transaction: all of them or none.
+// Every account gets password, hashed once for the whole import. Managers
+// created by the import are linked once every row exists, and a salary
+// opens the associate's compensation history, as for a single new hire.
+func (m AssociateModel) ImportMany(imports []AssociateImport, password string, createdBy *int) ([]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt := `INSERT INTO Associates (first_name, last_name, title, department, office, status, start_date, empl_status, salary, dob, profile_picture, password, email, phone_number, gender, private_email, manager_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	ids := make([]int, 0, len(imports))
+	for _, imp := range imports {
+		a := imp.Associate
+		result, err := tx.ExecContext(ctx, stmt,
+			a.FirstName, a.LastName, a.Title, a.Department, a.Office, a.Status, a.StartDate, a.EmplStatus, a.Salary, a.DOB,
+			a.ProfilePicture, hashedPassword, a.Email, a.PhoneNumber, a.Gender, a.PrivateEmail, a.ManagerID)
+		if err != nil {
+			return nil, err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, int(id))
+
+		if a.Salary > 0 {
+			startDate := a.StartDate
+			if startDate.IsZero() {
+				startDate = time.Now()
+			}
+			_, err = insertCompensationEntry(ctx, tx, CompensationEntry{
+				AssociateID:   int(id),
+				Amount:        a.Salary,
+				Currency:      DefaultCurrency,
+				EffectiveDate: startDate,
+				Reason:        "Starting salary",
+				CreatedBy:     createdBy,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for i, imp := range imports {
+		if imp.ManagerRow == nil {
+			continue
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE Associates SET manager_id = ? WHERE id = ?`, ids[*imp.ManagerRow], ids[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, tx.Commit()
+}
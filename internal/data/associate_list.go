@@ -0,0 +1,263 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AssociateFilter narrows an associate listing. Empty fields do not filter.
+type AssociateFilter struct {
+	Department string
+	Office     string
+	Status     string
+	EmplStatus string
+	ManagerID  *int
+	// StartFrom and StartTo bound the start date, both inclusive
+	StartFrom *time.Time
+	StartTo   *time.Time
+}
+
+// AssociateSort orders a listing by one field.
+type AssociateSort struct {
+	Field string
+	Desc  bool
+}
+
+// associateSortColumns are the fields a listing may be sorted by. Salary and
+// DOB are left out so the order cannot reveal fields the caller may not see.
+var associateSortColumns = map[string]string{
+	"id":          "id",
+	"first_name":  "first_name",
+	"last_name":   "last_name",
+	"title":       "title",
+	"department":  "department",
+	"office":      "office",
+	"status":      "status",
+	"empl_status": "empl_status",
+	"start_date":  "start_date",
+	"email":       "email",
+}
+
+// ParseAssociateSort reads a comma-separated list of sort fields, each
+// optionally prefixed with - for descending order, e.g. "department,-start_date".
+func ParseAssociateSort(value string) ([]AssociateSort, error) {
+	var sorts []AssociateSort
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		sort := AssociateSort{Field: part}
+		if strings.HasPrefix(part, "-") {
+			sort = AssociateSort{Field: part[1:], Desc: true}
+		}
+		if _, ok := associateSortColumns[sort.Field]; !ok {
+			return nil, errors.New("cannot sort by " + sort.Field)
+		}
+		sorts = append(sorts, sort)
+	}
+	return sorts, nil
+}
+
+// where builds the SQL condition of the filter and its arguments.
+func (f AssociateFilter) where() (string, []any) {
+	conditions := []string{"1 = 1"}
+	var args []any
+
+	for _, c := range []struct {
+		column string
+		value  string
+	}{
+		{"department", f.Department},
+		{"office", f.Office},
+		{"status", f.Status},
+		{"empl_status", f.EmplStatus},
+	} {
+		if c.value != "" {
+			conditions = append(conditions, c.column+" = ?")
+			args = append(args, c.value)
+		}
+	}
+	if f.ManagerID != nil {
+		conditions = append(conditions, "manager_id = ?")
+		args = append(args, *f.ManagerID)
+	}
+	if f.StartFrom != nil {
+		conditions = append(conditions, "start_date >= ?")
+		args = append(args, f.StartFrom.Format("2006-01-02"))
+	}
+	if f.StartTo != nil {
+		// start_date is a DATETIME, so the whole last day counts
+		conditions = append(conditions, "start_date < ?")
+		args = append(args, f.StartTo.AddDate(0, 0, 1).Format("2006-01-02"))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// orderBy builds the ORDER BY clause, by last name when sorts is empty. The
+// id always breaks ties so pages do not overlap.
+func orderBy(sorts []AssociateSort) string {
+	if len(sorts) == 0 {
+		sorts = []AssociateSort{{Field: "last_name"}}
+	}
+
+	var terms []string
+	hasID := false
+	for _, s := range sorts {
+		term := associateSortColumns[s.Field]
+		if s.Desc {
+			term += " DESC"
+		}
+		terms = append(terms, term)
+		hasID = hasID || s.Field == "id"
+	}
+	if !hasID {
+		terms = append(terms, "id")
+	}
+	return strings.Join(terms, ", ")
+}
+
+const associateListColumns = `id, first_name, last_name, title, department, office, status, start_date, empl_status, salary, dob, COALESCE(profile_picture, ''), COALESCE(email, ''), COALESCE(phone_number, ''), COALESCE(gender, ''), COALESCE(private_email, ''), manager_id`
+
+func scanAssociate(rows *sql.Rows) (Associate, error) {
+	var a Associate
+	err := rows.Scan(
+		&a.ID,
+		&a.FirstName,
+		&a.LastName,
+		&a.Title,
+		&a.Department,
+		&a.Office,
+		&a.Status,
+		&a.StartDate,
+		&a.EmplStatus,
+		&a.Salary,
+		&a.DOB,
+		&a.ProfilePicture,
+		&a.Email,
+		&a.PhoneNumber,
+		&a.Gender,
+		&a.PrivateEmail,
+		&a.ManagerID,
+	)
+	return a, err
+}
+
+// listQuery selects one page of the associates matching filter, in sorts
+// order. A limit of 0 selects every match.
+func listQuery(filter AssociateFilter, sorts []AssociateSort, limit, offset int) (string, []any) {
+	where, args := filter.where()
+	query := `SELECT ` + associateListColumns + ` FROM Associates WHERE ` + where + ` ORDER BY ` + orderBy(sorts)
+	if limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d OFFSET %d`, limit, offset)
+	}
+	return query, args
+}
+
+// List returns one page of the associates matching filter, in sorts order,
+// and how many match in all. A limit of 0 returns every match.
+func (m AssociateModel) List(filter AssociateFilter, sorts []AssociateSort, limit, offset int) ([]Associate, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	where, args := filter.where()
+
+	var total int
+	err := m.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM Associates WHERE `+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query, args := listQuery(filter, sorts, limit, offset)
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	associates := []Associate{}
+	for rows.Next() {
+		a, err := scanAssociate(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		associates = append(associates, a)
+	}
+
+	return associates, total, rows.Err()
+}
+
+// Each calls fn with the associates List would return, one row at a time,
+// so a large listing need not be held in memory. It stops at fn's first
+// error and returns it.
+func (m AssociateModel) Each(filter AssociateFilter, sorts []AssociateSort, limit, offset int, fn func(Associate) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	query, args := listQuery(filter, sorts, limit, offset)
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a, err := scanAssociate(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// AssociateCard is the part of an associate the directory search indexes
+// and shows. It leaves out salary, birth date and private contact fields.
+type AssociateCard struct {
+	ID             int
+	FirstName      string
+	LastName       string
+	Title          string
+	Department     string
+	Office         string
+	Email          string
+	PhoneNumber    string
+	ProfilePicture string
+}
+
+// GetCards returns every associate's directory card.
+func (m AssociateModel) GetCards() ([]AssociateCard, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `SELECT id, first_name, last_name, COALESCE(title, ''), COALESCE(department, ''), COALESCE(office, ''),
+	       COALESCE(email, ''), COALESCE(phone_number, ''), COALESCE(profile_picture, '')
+	FROM Associates`
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cards := []AssociateCard{}
+	for rows.Next() {
+		var c AssociateCard
+		err := rows.Scan(&c.ID, &c.FirstName, &c.LastName, &c.Title, &c.Department, &c.Office, &c.Email, &c.PhoneNumber, &c.ProfilePicture)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, c)
+	}
+
+	return cards, rows.Err()
+}
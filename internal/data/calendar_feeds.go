@@ -0,0 +1,112 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Calendar feed scopes
+const (
+	FeedScopeAssociate  = "associate"
+	FeedScopeManager    = "manager"
+	FeedScopeDepartment = "department"
+)
+
+// CalendarFeed is an iCalendar subscription. ScopeValue is the associate ID,
+// the manager ID whose reports are included, or the department name.
+type CalendarFeed struct {
+	ID         int        `json:"id"`
+	OwnerID    int        `json:"owner_id"`
+	Scope      string     `json:"scope"`
+	ScopeValue string     `json:"scope_value"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type CalendarFeedModel struct {
+	DB *sql.DB
+}
+
+func (m CalendarFeedModel) Insert(feed CalendarFeed, tokenHash string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `INSERT INTO calendar_feeds (owner_id, scope, scope_value, token_hash) VALUES (?, ?, ?, ?)`
+	result, err := m.DB.ExecContext(ctx, stmt, feed.OwnerID, feed.Scope, feed.ScopeValue, tokenHash)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (m CalendarFeedModel) GetByOwner(ownerID int) ([]CalendarFeed, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT id, owner_id, scope, scope_value, created_at, last_used_at
+	FROM calendar_feeds WHERE owner_id = ? ORDER BY id`
+
+	rows, err := m.DB.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	feeds := []CalendarFeed{}
+	for rows.Next() {
+		var f CalendarFeed
+		if err := rows.Scan(&f.ID, &f.OwnerID, &f.Scope, &f.ScopeValue, &f.CreatedAt, &f.LastUsedAt); err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, f)
+	}
+
+	return feeds, nil
+}
+
+// GetByTokenHash finds the feed for a subscription token and marks it used.
+func (m CalendarFeedModel) GetByTokenHash(tokenHash string) (*CalendarFeed, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT id, owner_id, scope, scope_value, created_at, last_used_at
+	FROM calendar_feeds WHERE token_hash = ?`
+
+	var f CalendarFeed
+	err := m.DB.QueryRowContext(ctx, query, tokenHash).Scan(&f.ID, &f.OwnerID, &f.Scope, &f.ScopeValue, &f.CreatedAt, &f.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = m.DB.ExecContext(ctx, `UPDATE calendar_feeds SET last_used_at = ? WHERE id = ?`, time.Now(), f.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+// Delete revokes a feed. Only its owner can delete it.
+func (m CalendarFeedModel) Delete(id, ownerID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, `DELETE FROM calendar_feeds WHERE id = ? AND owner_id = ?`, id, ownerID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
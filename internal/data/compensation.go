@@ -0,0 +1,341 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultCurrency is the currency of salaries recorded without one.
+const DefaultCurrency = "USD"
+
+var currencyRX = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// NormalizeCurrency upper-cases an ISO 4217 code, defaulting to
+// DefaultCurrency, and reports whether it is well formed.
+func NormalizeCurrency(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrency, true
+	}
+	return code, currencyRX.MatchString(code)
+}
+
+// CompensationEntry is one salary an associate has had, from EffectiveDate
+// until the next entry. TaskID links entries made by an approved task.
+type CompensationEntry struct {
+	ID             int       `json:"id"`
+	AssociateID    int       `json:"associate_id"`
+	Amount         int       `json:"amount"`
+	Currency       string    `json:"currency"`
+	EffectiveDate  time.Time `json:"effective_date"`
+	Reason         string    `json:"reason"`
+	TaskID         *int      `json:"task_id"`
+	SalaryChangeID *int      `json:"salary_change_id"`
+	CreatedBy      *int      `json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type CompensationModel struct {
+	DB *sql.DB
+}
+
+// GetByAssociate returns an associate's compensation history, oldest first.
+func (m CompensationModel) GetByAssociate(associateID int) ([]CompensationEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT id, associate_id, amount, currency, effective_date, COALESCE(reason, ''), task_id, salary_change_id, created_by, created_at
+	FROM compensation_history WHERE associate_id = ? ORDER BY effective_date, id`
+
+	rows, err := m.DB.QueryContext(ctx, query, associateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []CompensationEntry{}
+	for rows.Next() {
+		var e CompensationEntry
+		err := rows.Scan(&e.ID, &e.AssociateID, &e.Amount, &e.Currency, &e.EffectiveDate, &e.Reason,
+			&e.TaskID, &e.SalaryChangeID, &e.CreatedBy, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// Insert records a starting salary, such as a new hire's, without touching
+// the associate's salary.
+func (m CompensationModel) Insert(e CompensationEntry) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return insertCompensationEntry(ctx, m.DB, e)
+}
+
+func insertCompensationEntry(ctx context.Context, db execer, e CompensationEntry) (int, error) {
+	result, err := db.ExecContext(ctx, `
+		INSERT INTO compensation_history (associate_id, amount, currency, effective_date, reason, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		e.AssociateID, e.Amount, e.Currency, e.EffectiveDate.Format("2006-01-02"), e.Reason, e.CreatedBy, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// CurrentCurrency returns the currency of an associate's salary.
+func (m CompensationModel) CurrentCurrency(associateID int) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return currentCurrency(ctx, m.DB, associateID)
+}
+
+type rowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// currentCurrency is the currency of the associate's latest compensation
+// entry, or DefaultCurrency when they have none.
+func currentCurrency(ctx context.Context, db rowQueryer, associateID int) (string, error) {
+	var currency string
+	err := db.QueryRowContext(ctx, `
+		SELECT currency FROM compensation_history
+		WHERE associate_id = ? AND effective_date <= CURDATE()
+		ORDER BY effective_date DESC, id DESC LIMIT 1`, associateID).Scan(&currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultCurrency, nil
+	}
+	return currency, err
+}
+
+// SalaryRange is the pay band of a job title, in one office or, without an
+// OfficeID, in every office that has no band of its own for the title.
+// Associates have no job level, so one band covers every level of a title.
+type SalaryRange struct {
+	ID         int       `json:"id"`
+	Title      string    `json:"title"`
+	OfficeID   *int      `json:"office_id"`
+	OfficeName string    `json:"office_name,omitempty"`
+	Currency   string    `json:"currency"`
+	MinAmount  int       `json:"min_amount"`
+	MidAmount  int       `json:"mid_amount"`
+	MaxAmount  int       `json:"max_amount"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Validate checks that the band is named and ordered.
+func (r SalaryRange) Validate() error {
+	if strings.TrimSpace(r.Title) == "" {
+		return errors.New("title is required")
+	}
+	if _, ok := NormalizeCurrency(r.Currency); !ok {
+		return errors.New("currency must be a three-letter ISO 4217 code")
+	}
+	if r.MinAmount < 0 || r.MinAmount > r.MidAmount || r.MidAmount > r.MaxAmount {
+		return errors.New("amounts must satisfy 0 <= min_amount <= mid_amount <= max_amount")
+	}
+	if r.MidAmount == 0 {
+		return errors.New("mid_amount must be greater than zero")
+	}
+	return nil
+}
+
+// CompaRatio is salary over the band's midpoint, to two decimals. It is nil
+// when the salary is in another currency.
+func (r SalaryRange) CompaRatio(salary int, currency string) *float64 {
+	if r.MidAmount == 0 || currency != r.Currency {
+		return nil
+	}
+	ratio := math.Round(float64(salary)/float64(r.MidAmount)*100) / 100
+	return &ratio
+}
+
+// MatchSalaryRange picks the band for a title in an office: the office's own
+// band, or else the company-wide one. It returns nil when there is neither.
+func MatchSalaryRange(ranges []SalaryRange, title, office string) *SalaryRange {
+	var fallback *SalaryRange
+	for i := range ranges {
+		r := &ranges[i]
+		if !strings.EqualFold(r.Title, title) {
+			continue
+		}
+		if r.OfficeID == nil {
+			fallback = r
+		} else if office != "" && strings.EqualFold(r.OfficeName, office) {
+			return r
+		}
+	}
+	return fallback
+}
+
+type SalaryRangeModel struct {
+	DB *sql.DB
+}
+
+func (m SalaryRangeModel) query(ctx context.Context, where string, args ...any) ([]SalaryRange, error) {
+	query := `SELECT r.id, r.title, r.office_id, COALESCE(o.name, ''), r.currency, r.min_amount, r.mid_amount, r.max_amount, r.created_at, r.updated_at
+	FROM salary_ranges r
+	LEFT JOIN Offices o ON r.office_id = o.id
+	` + where + `
+	ORDER BY r.title, o.name`
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ranges := []SalaryRange{}
+	for rows.Next() {
+		var r SalaryRange
+		err := rows.Scan(&r.ID, &r.Title, &r.OfficeID, &r.OfficeName, &r.Currency, &r.MinAmount, &r.MidAmount, &r.MaxAmount, &r.CreatedAt, &r.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+
+	return ranges, rows.Err()
+}
+
+func (m SalaryRangeModel) GetAll() ([]SalaryRange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.query(ctx, ``)
+}
+
+func (m SalaryRangeModel) GetOne(id int) (*SalaryRange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	ranges, err := m.query(ctx, `WHERE r.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(ranges) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &ranges[0], nil
+}
+
+// Find returns the band of a title in an office (nil for company-wide), or
+// nil if there is none.
+func (m SalaryRangeModel) Find(title string, officeID *int) (*SalaryRange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	ranges, err := m.query(ctx, `WHERE r.title = ? AND r.office_id <=> ?`, title, officeID)
+	if err != nil || len(ranges) == 0 {
+		return nil, err
+	}
+	return &ranges[0], nil
+}
+
+func (m SalaryRangeModel) Insert(r SalaryRange) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, `
+		INSERT INTO salary_ranges (title, office_id, currency, min_amount, mid_amount, max_amount, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Title, r.OfficeID, r.Currency, r.MinAmount, r.MidAmount, r.MaxAmount, time.Now(), time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+func (m SalaryRangeModel) Update(id int, r SalaryRange) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, `
+		UPDATE salary_ranges SET title = ?, office_id = ?, currency = ?, min_amount = ?, mid_amount = ?, max_amount = ?, updated_at = ?
+		WHERE id = ?`,
+		r.Title, r.OfficeID, r.Currency, r.MinAmount, r.MidAmount, r.MaxAmount, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (m SalaryRangeModel) Delete(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `DELETE FROM salary_ranges WHERE id = ?`, id)
+	return err
+}
+
+// SalaryOnRecord is an associate's current salary with its currency, as the
+// compa-ratio report needs it.
+type SalaryOnRecord struct {
+	AssociateID int
+	Name        string
+	Title       string
+	Department  string
+	Office      string
+	Salary      int
+	Currency    string
+}
+
+// GetSalaries returns the salaries on record, in one department when
+// department is set.
+func (m CompensationModel) GetSalaries(department string) ([]SalaryOnRecord, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `SELECT a.id, CONCAT(a.first_name, ' ', a.last_name), COALESCE(a.title, ''), COALESCE(a.department, ''), COALESCE(a.office, ''),
+	       COALESCE(a.salary, 0),
+	       COALESCE((SELECT h.currency FROM compensation_history h
+	                 WHERE h.associate_id = a.id AND h.effective_date <= CURDATE()
+	                 ORDER BY h.effective_date DESC, h.id DESC LIMIT 1), ?)
+	FROM Associates a
+	WHERE a.salary IS NOT NULL AND (? = '' OR a.department = ?)
+	ORDER BY a.department, a.last_name, a.first_name`
+
+	rows, err := m.DB.QueryContext(ctx, query, DefaultCurrency, department, department)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	salaries := []SalaryOnRecord{}
+	for rows.Next() {
+		var s SalaryOnRecord
+		err := rows.Scan(&s.AssociateID, &s.Name, &s.Title, &s.Department, &s.Office, &s.Salary, &s.Currency)
+		if err != nil {
+			return nil, err
+		}
+		salaries = append(salaries, s)
+	}
+
+	return salaries, rows.Err()
+}
@@ -0,0 +1,165 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Delegation lets DelegateID act on DelegatorID's approvals from StartDate
+// through EndDate, both inclusive. Delegation is not transitive: a delegate
+// acts only for their own delegators, not for whoever those delegated to.
+type Delegation struct {
+	ID            int       `json:"id"`
+	DelegatorID   int       `json:"delegator_id"`
+	DelegatorName string    `json:"delegator_name,omitempty"`
+	DelegateID    int       `json:"delegate_id"`
+	DelegateName  string    `json:"delegate_name,omitempty"`
+	StartDate     time.Time `json:"start_date"`
+	EndDate       time.Time `json:"end_date"`
+	Reason        string    `json:"reason"`
+	CreatedBy     *int      `json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type DelegationModel struct {
+	DB *sql.DB
+}
+
+const delegationQuery = `
+	SELECT d.id, d.delegator_id, COALESCE(CONCAT(delegator.first_name, ' ', delegator.last_name), ''),
+	       d.delegate_id, COALESCE(CONCAT(delegate.first_name, ' ', delegate.last_name), ''),
+	       d.start_date, d.end_date, d.reason, d.created_by, d.created_at
+	FROM approval_delegations d
+	LEFT JOIN Associates delegator ON d.delegator_id = delegator.id
+	LEFT JOIN Associates delegate ON d.delegate_id = delegate.id
+	WHERE `
+
+func (m DelegationModel) query(ctx context.Context, where string, args ...any) ([]Delegation, error) {
+	rows, err := m.DB.QueryContext(ctx, delegationQuery+where+` ORDER BY d.start_date, d.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	delegations := []Delegation{}
+	for rows.Next() {
+		var d Delegation
+		err := rows.Scan(&d.ID, &d.DelegatorID, &d.DelegatorName, &d.DelegateID, &d.DelegateName,
+			&d.StartDate, &d.EndDate, &d.Reason, &d.CreatedBy, &d.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		delegations = append(delegations, d)
+	}
+
+	return delegations, rows.Err()
+}
+
+func (m DelegationModel) Insert(d Delegation) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `INSERT INTO approval_delegations (delegator_id, delegate_id, start_date, end_date, reason, created_by)
+	VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := m.DB.ExecContext(ctx, stmt, d.DelegatorID, d.DelegateID,
+		d.StartDate.Format("2006-01-02"), d.EndDate.Format("2006-01-02"), d.Reason, d.CreatedBy)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (m DelegationModel) GetOne(id int) (*Delegation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	delegations, err := m.query(ctx, `d.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(delegations) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &delegations[0], nil
+}
+
+// GetForAssociate lists the delegations an associate gave or received that
+// have not ended by since.
+func (m DelegationModel) GetForAssociate(associateID int, since time.Time) ([]Delegation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.query(ctx, `(d.delegator_id = ? OR d.delegate_id = ?) AND d.end_date >= ?`,
+		associateID, associateID, since.Format("2006-01-02"))
+}
+
+// GetOverlapping lists a delegator's delegations that share a day with start
+// through end.
+func (m DelegationModel) GetOverlapping(delegatorID int, start, end time.Time) ([]Delegation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.query(ctx, `d.delegator_id = ? AND d.start_date <= ? AND d.end_date >= ?`,
+		delegatorID, end.Format("2006-01-02"), start.Format("2006-01-02"))
+}
+
+// ActiveDelegators returns the associates who have delegated their approvals
+// to delegateID on day.
+func (m DelegationModel) ActiveDelegators(delegateID int, day time.Time) ([]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	delegations, err := m.query(ctx, `d.delegate_id = ? AND d.start_date <= ? AND d.end_date >= ?`,
+		delegateID, day.Format("2006-01-02"), day.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []int{}
+	for _, d := range delegations {
+		ids = append(ids, d.DelegatorID)
+	}
+	return ids, nil
+}
+
+// IsDelegate reports whether delegatorID has delegated to delegateID on day.
+func (m DelegationModel) IsDelegate(delegatorID, delegateID int, day time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var n int
+	query := `SELECT COUNT(*) FROM approval_delegations
+	WHERE delegator_id = ? AND delegate_id = ? AND start_date <= ? AND end_date >= ?`
+	err := m.DB.QueryRowContext(ctx, query, delegatorID, delegateID,
+		day.Format("2006-01-02"), day.Format("2006-01-02")).Scan(&n)
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (m DelegationModel) Delete(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, `DELETE FROM approval_delegations WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
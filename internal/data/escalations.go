@@ -0,0 +1,202 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Kinds of approval that can be escalated
+const (
+	EscalationTimeOff   = "time_off"
+	EscalationTimeEntry = "time_entry"
+)
+
+// Escalation records one step of a stale approval up the chain.
+type Escalation struct {
+	ID             int       `json:"id"`
+	SubjectType    string    `json:"subject_type"`
+	SubjectID      int       `json:"subject_id"`
+	Level          int       `json:"level"`
+	FromApproverID *int      `json:"from_approver_id"`
+	ToApproverID   *int      `json:"to_approver_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// StaleApproval is a pending time-off request or overtime entry that has
+// waited on ApproverID past the SLA. Level counts earlier escalations.
+type StaleApproval struct {
+	SubjectType  string
+	SubjectID    int
+	RequesterID  int
+	ApproverID   *int
+	Level        int
+	PendingSince time.Time
+}
+
+type EscalationModel struct {
+	DB *sql.DB
+}
+
+// escalationState joins each subject's escalation count and latest step
+const escalationState = `
+	LEFT JOIN (
+		SELECT subject_id, COUNT(*) AS level, MAX(created_at) AS last_escalated_at
+		FROM approval_escalations
+		WHERE subject_type = ?
+		GROUP BY subject_id
+	) e ON e.subject_id = `
+
+// GetStale lists pending approvals that have sat with their current approver
+// since before cutoff: since they were filed, or since their last escalation.
+func (m EscalationModel) GetStale(cutoff time.Time) ([]StaleApproval, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	queries := []struct {
+		subjectType string
+		status      string
+		query       string
+	}{
+		{EscalationTimeOff, TimeOffPending, `
+			SELECT t.id, t.associate_id, COALESCE(t.escalated_to, t.approver_id), COALESCE(e.level, 0), COALESCE(e.last_escalated_at, t.created_at)
+			FROM time_off_requests t` + escalationState + `t.id
+			WHERE t.status = ? AND COALESCE(e.last_escalated_at, t.created_at) <= ?
+			ORDER BY t.id`},
+		// Only overtime needs approval
+		{EscalationTimeEntry, TimeEntryPending, `
+			SELECT t.id, t.associate_id, COALESCE(t.escalated_to, a.manager_id), COALESCE(e.level, 0), COALESCE(e.last_escalated_at, t.created_at)
+			FROM time_entries t
+			JOIN Associates a ON t.associate_id = a.id` + escalationState + `t.id
+			WHERE t.status = ? AND COALESCE(e.last_escalated_at, t.created_at) <= ?
+			ORDER BY t.id`},
+	}
+
+	stale := []StaleApproval{}
+	for _, q := range queries {
+		rows, err := m.DB.QueryContext(ctx, q.query, q.subjectType, q.status, cutoff)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			s := StaleApproval{SubjectType: q.subjectType}
+			if err := rows.Scan(&s.SubjectID, &s.RequesterID, &s.ApproverID, &s.Level, &s.PendingSince); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			stale = append(stale, s)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return stale, nil
+}
+
+// Escalate hands a stale approval to toID and records the step. It reports
+// false without changing anything when the approval was decided, reassigned
+// or already escalated, by another replica for instance, since it was read.
+func (m EscalationModel) Escalate(s StaleApproval, toID int, comment string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// The unique level makes a repeated step a no-op
+	result, err := tx.ExecContext(ctx, `
+		INSERT IGNORE INTO approval_escalations (subject_type, subject_id, level, from_approver_id, to_approver_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`, s.SubjectType, s.SubjectID, s.Level+1, s.ApproverID, toID, time.Now())
+	if err != nil {
+		return false, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	switch s.SubjectType {
+	case EscalationTimeOff:
+		result, err = tx.ExecContext(ctx, `
+			UPDATE time_off_requests SET escalated_to = ?, updated_at = ?
+			WHERE id = ? AND status = ? AND COALESCE(escalated_to, approver_id) <=> ?`, toID, time.Now(), s.SubjectID, TimeOffPending, s.ApproverID)
+	case EscalationTimeEntry:
+		result, err = tx.ExecContext(ctx, `
+			UPDATE time_entries t JOIN Associates a ON t.associate_id = a.id
+			SET t.escalated_to = ?
+			WHERE t.id = ? AND t.status = ? AND COALESCE(t.escalated_to, a.manager_id) <=> ?`, toID, s.SubjectID, TimeEntryPending, s.ApproverID)
+	default:
+		return false, fmt.Errorf("unknown escalation subject %q", s.SubjectType)
+	}
+	if err != nil {
+		return false, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	if s.SubjectType == EscalationTimeOff {
+		from := TimeOffPending
+		err = insertTimeOffHistory(ctx, tx, s.SubjectID, &from, TimeOffPending, nil, nil, comment)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+// GetBySubject returns the escalation steps of one approval, oldest first.
+func (m EscalationModel) GetBySubject(subjectType string, subjectID int) ([]Escalation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT id, subject_type, subject_id, level, from_approver_id, to_approver_id, created_at
+	FROM approval_escalations WHERE subject_type = ? AND subject_id = ? ORDER BY level`
+
+	rows, err := m.DB.QueryContext(ctx, query, subjectType, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	escalations := []Escalation{}
+	for rows.Next() {
+		var e Escalation
+		err := rows.Scan(&e.ID, &e.SubjectType, &e.SubjectID, &e.Level, &e.FromApproverID, &e.ToApproverID, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		escalations = append(escalations, e)
+	}
+
+	return escalations, rows.Err()
+}
+
+// TryLock takes the MySQL named lock name without waiting, on a connection of
+// its own. It returns a release function, or nil when another session holds
+// the lock.
+func (m EscalationModel) TryLock(ctx context.Context, name string) (func(), error) {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var got sql.NullInt64
+	err = conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, 0)`, name).Scan(&got)
+	if err != nil || !got.Valid || got.Int64 != 1 {
+		conn.Close()
+		return nil, err
+	}
+
+	return func() {
+		conn.ExecContext(context.Background(), `SELECT RELEASE_LOCK(?)`, name)
+		conn.Close()
+	}, nil
+}
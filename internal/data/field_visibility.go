@@ -0,0 +1,112 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Who may see an associate field. HR, the holders of associates.manage or
+// associates.view_sensitive, see every field.
+const (
+	// VisibilityPublic fields are shown to every signed-in associate
+	VisibilityPublic = "public"
+	// VisibilityManager fields are shown to the associate and anyone above
+	// them in the manager chain
+	VisibilityManager = "manager"
+	// VisibilitySelf fields are shown to the associate only
+	VisibilitySelf = "self"
+	// VisibilityHR fields are shown to HR only
+	VisibilityHR = "hr"
+)
+
+// FieldVisibilitySetting is the AppSettings key holding the policy as a JSON
+// object of field name to visibility.
+const FieldVisibilitySetting = "associate_field_visibility"
+
+// Audience is how a viewer relates to the associate being shown.
+type Audience struct {
+	Self    bool
+	Manager bool
+	HR      bool
+}
+
+// FieldVisibilityPolicy maps Associate JSON field names to their visibility.
+// Fields it does not name are public.
+type FieldVisibilityPolicy map[string]string
+
+// RestrictableAssociateFields are the Associate fields the policy may hide.
+// The id and name always show.
+var RestrictableAssociateFields = []string{
+	"Title", "Department", "Office", "Status", "StartDate", "EmplStatus", "Salary", "DOB",
+	"profile_picture", "Email", "PhoneNumber", "Gender", "PrivateEmail", "manager_id",
+}
+
+// DefaultFieldVisibility applies until the policy is configured.
+func DefaultFieldVisibility() FieldVisibilityPolicy {
+	return FieldVisibilityPolicy{
+		"Salary":       VisibilityManager,
+		"DOB":          VisibilitySelf,
+		"PhoneNumber":  VisibilityManager,
+		"Gender":       VisibilitySelf,
+		"PrivateEmail": VisibilitySelf,
+	}
+}
+
+// ParseFieldVisibility reads a stored policy over the defaults, so fields it
+// leaves out keep their default visibility.
+func ParseFieldVisibility(value string) (FieldVisibilityPolicy, error) {
+	policy := DefaultFieldVisibility()
+	if value == "" {
+		return policy, nil
+	}
+
+	var stored FieldVisibilityPolicy
+	if err := json.Unmarshal([]byte(value), &stored); err != nil {
+		return policy, err
+	}
+	if err := stored.Validate(); err != nil {
+		return policy, err
+	}
+	for field, visibility := range stored {
+		policy[field] = visibility
+	}
+	return policy, nil
+}
+
+// Validate checks that the policy names known fields and visibilities.
+func (p FieldVisibilityPolicy) Validate() error {
+	for field, visibility := range p {
+		known := false
+		for _, f := range RestrictableAssociateFields {
+			known = known || f == field
+		}
+		if !known {
+			return fmt.Errorf("unknown associate field: %s", field)
+		}
+
+		switch visibility {
+		case VisibilityPublic, VisibilityManager, VisibilitySelf, VisibilityHR:
+		default:
+			return fmt.Errorf("%s: visibility must be public, manager, self or hr", field)
+		}
+	}
+	return nil
+}
+
+// Visible reports whether a viewer in audience may see field.
+func (p FieldVisibilityPolicy) Visible(field string, audience Audience) bool {
+	if audience.HR {
+		return true
+	}
+
+	switch p[field] {
+	case VisibilityHR:
+		return false
+	case VisibilitySelf:
+		return audience.Self
+	case VisibilityManager:
+		return audience.Self || audience.Manager
+	default:
+		return true
+	}
+}
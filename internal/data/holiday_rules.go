@@ -0,0 +1,369 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+)
+
+// Holiday rule types
+const (
+	HolidayRuleFixed       = "fixed"        // Month and Day
+	HolidayRuleNthWeekday  = "nth_weekday"  // the Nth Weekday of Month
+	HolidayRuleLastWeekday = "last_weekday" // the last Weekday of Month
+	HolidayRuleEaster      = "easter"       // Easter Sunday plus OffsetDays
+)
+
+// Observed-date policies for holidays that fall on a weekend
+const (
+	ObservedNone           = "none"
+	ObservedNearestWeekday = "nearest_weekday" // Saturday -> Friday, Sunday -> Monday
+	ObservedNextWeekday    = "next_weekday"    // Saturday or Sunday -> Monday
+)
+
+// HolidayRule generates a holiday every year between StartYear and EndYear
+// (either may be open).
+type HolidayRule struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	RuleType string `json:"rule_type"`
+	Month    *int   `json:"month"`
+	Day      *int   `json:"day"`
+	Weekday  *int   `json:"weekday"` // 0 = Sunday ... 6 = Saturday
+	Nth      *int   `json:"nth"`
+	// OffsetDays moves the date, e.g. -2 from Easter for Good Friday or +1
+	// from the fourth Thursday of November for the day after Thanksgiving
+	OffsetDays int    `json:"offset_days"`
+	Observed   string `json:"observed"`
+	StartYear  *int   `json:"start_year"`
+	EndYear    *int   `json:"end_year"`
+	// OfficeIDs lists the offices observing the holiday; empty means it is
+	// company-wide and every office observes it
+	OfficeIDs []int     `json:"office_ids"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate checks that the rule has the fields its type needs.
+func (r HolidayRule) Validate() error {
+	if r.Name == "" {
+		return errors.New("holiday rule name is required")
+	}
+
+	needMonth := func() error {
+		if r.Month == nil || *r.Month < 1 || *r.Month > 12 {
+			return errors.New("month must be between 1 and 12")
+		}
+		return nil
+	}
+	needWeekday := func() error {
+		if r.Weekday == nil || *r.Weekday < 0 || *r.Weekday > 6 {
+			return errors.New("weekday must be between 0 (Sunday) and 6 (Saturday)")
+		}
+		return nil
+	}
+
+	switch r.RuleType {
+	case HolidayRuleFixed:
+		if err := needMonth(); err != nil {
+			return err
+		}
+		// 2024 is a leap year, so Feb 29 is accepted
+		if r.Day == nil || *r.Day < 1 || *r.Day > daysIn(time.Month(*r.Month), 2024) {
+			return errors.New("day is not valid for the month")
+		}
+	case HolidayRuleNthWeekday:
+		if err := needMonth(); err != nil {
+			return err
+		}
+		if err := needWeekday(); err != nil {
+			return err
+		}
+		if r.Nth == nil || *r.Nth < 1 || *r.Nth > 5 {
+			return errors.New("nth must be between 1 and 5")
+		}
+	case HolidayRuleLastWeekday:
+		if err := needMonth(); err != nil {
+			return err
+		}
+		if err := needWeekday(); err != nil {
+			return err
+		}
+	case HolidayRuleEaster:
+	default:
+		return errors.New("rule_type must be fixed, nth_weekday, last_weekday or easter")
+	}
+
+	switch r.Observed {
+	case ObservedNone, ObservedNearestWeekday, ObservedNextWeekday:
+	default:
+		return errors.New("observed must be none, nearest_weekday or next_weekday")
+	}
+
+	if r.StartYear != nil && r.EndYear != nil && *r.EndYear < *r.StartYear {
+		return errors.New("end_year must not be before start_year")
+	}
+	return nil
+}
+
+func daysIn(month time.Month, year int) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// Occurrence returns the date the rule falls on in year, before any
+// observed-date shift. It reports false when the rule is not in effect that
+// year, or the date does not exist (Feb 29, a fifth Monday).
+func (r HolidayRule) Occurrence(year int) (time.Time, bool) {
+	if (r.StartYear != nil && year < *r.StartYear) || (r.EndYear != nil && year > *r.EndYear) {
+		return time.Time{}, false
+	}
+
+	var date time.Time
+	switch r.RuleType {
+	case HolidayRuleFixed:
+		if *r.Day > daysIn(time.Month(*r.Month), year) {
+			return time.Time{}, false
+		}
+		date = time.Date(year, time.Month(*r.Month), *r.Day, 0, 0, 0, 0, time.UTC)
+	case HolidayRuleNthWeekday:
+		first := time.Date(year, time.Month(*r.Month), 1, 0, 0, 0, 0, time.UTC)
+		shift := (*r.Weekday - int(first.Weekday()) + 7) % 7
+		date = first.AddDate(0, 0, shift+7*(*r.Nth-1))
+		if date.Month() != first.Month() {
+			return time.Time{}, false
+		}
+	case HolidayRuleLastWeekday:
+		last := time.Date(year, time.Month(*r.Month)+1, 0, 0, 0, 0, 0, time.UTC)
+		shift := (int(last.Weekday()) - *r.Weekday + 7) % 7
+		date = last.AddDate(0, 0, -shift)
+	case HolidayRuleEaster:
+		date = EasterSunday(year)
+	default:
+		return time.Time{}, false
+	}
+
+	return date.AddDate(0, 0, r.OffsetDays), true
+}
+
+// ObservedDate moves a weekend date to the weekday it is observed on.
+func (r HolidayRule) ObservedDate(date time.Time) time.Time {
+	switch {
+	case r.Observed == ObservedNearestWeekday && date.Weekday() == time.Saturday:
+		return date.AddDate(0, 0, -1)
+	case r.Observed == ObservedNearestWeekday && date.Weekday() == time.Sunday:
+		return date.AddDate(0, 0, 1)
+	case r.Observed == ObservedNextWeekday && date.Weekday() == time.Saturday:
+		return date.AddDate(0, 0, 2)
+	case r.Observed == ObservedNextWeekday && date.Weekday() == time.Sunday:
+		return date.AddDate(0, 0, 1)
+	}
+	return date
+}
+
+// EasterSunday returns the date of Western (Gregorian) Easter, using the
+// anonymous Gregorian algorithm.
+func EasterSunday(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// MaterializeHolidays returns the holidays that fall in year: the one-off
+// holidays entered for it plus every rule's date, shifted to its observed day.
+// A one-off holiday wins over a rule on the same date. When an observed date
+// is already a holiday (Christmas and Boxing Day both on a weekend), it moves
+// on to the next free weekday.
+func MaterializeHolidays(year int, listed []Holiday, rules []HolidayRule) []Holiday {
+	taken := map[string]bool{}
+	var holidays []Holiday
+	for _, h := range listed {
+		key := h.Date.Format("2006-01-02")
+		if !taken[key] {
+			taken[key] = true
+			holidays = append(holidays, h)
+		}
+	}
+
+	type occurrence struct {
+		rule   HolidayRule
+		actual time.Time
+	}
+	var onTheDay, shifted []occurrence
+	// Neighbouring years are included because an observed date can cross
+	// the year boundary (a Saturday Jan 1 is observed on Dec 31)
+	for y := year - 1; y <= year+1; y++ {
+		for _, rule := range rules {
+			actual, ok := rule.Occurrence(y)
+			if !ok {
+				continue
+			}
+			if rule.ObservedDate(actual).Equal(actual) {
+				onTheDay = append(onTheDay, occurrence{rule, actual})
+			} else {
+				shifted = append(shifted, occurrence{rule, actual})
+			}
+		}
+	}
+	sort.SliceStable(shifted, func(i, j int) bool {
+		return shifted[i].actual.Before(shifted[j].actual)
+	})
+
+	// Holidays on their own date claim it before any shifted holiday does
+	for _, o := range append(onTheDay, shifted...) {
+		date := o.rule.ObservedDate(o.actual)
+		if !date.Equal(o.actual) {
+			for taken[date.Format("2006-01-02")] || date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+				date = date.AddDate(0, 0, 1)
+			}
+		}
+
+		key := date.Format("2006-01-02")
+		if taken[key] {
+			continue
+		}
+		taken[key] = true
+		if date.Year() != year {
+			continue
+		}
+
+		ruleID := o.rule.ID
+		h := Holiday{
+			Name:      o.rule.Name,
+			Date:      date,
+			Year:      year,
+			OfficeIDs: o.rule.OfficeIDs,
+			RuleID:    &ruleID,
+		}
+		if !date.Equal(o.actual) {
+			actual := o.actual
+			h.ActualDate = &actual
+			h.Name += " (observed)"
+		}
+		holidays = append(holidays, h)
+	}
+
+	sort.SliceStable(holidays, func(i, j int) bool {
+		return holidays[i].Date.Before(holidays[j].Date)
+	})
+	return holidays
+}
+
+type HolidayRuleModel struct {
+	DB *sql.DB
+}
+
+const holidayRuleColumns = `id, name, rule_type, month, day, weekday, nth, offset_days, observed, start_year, end_year, created_at, updated_at`
+
+func scanHolidayRule(row interface{ Scan(...any) error }) (HolidayRule, error) {
+	var r HolidayRule
+	err := row.Scan(&r.ID, &r.Name, &r.RuleType, &r.Month, &r.Day, &r.Weekday, &r.Nth, &r.OffsetDays, &r.Observed, &r.StartYear, &r.EndYear, &r.CreatedAt, &r.UpdatedAt)
+	return r, err
+}
+
+func (m HolidayRuleModel) GetAll() ([]HolidayRule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + holidayRuleColumns + ` FROM holiday_rules ORDER BY id`
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []HolidayRule
+	for rows.Next() {
+		r, err := scanHolidayRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	offices, err := loadOfficeIDs(m.DB, `SELECT holiday_rule_id, office_id FROM holiday_rule_offices ORDER BY office_id`)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		rules[i].OfficeIDs = offices[rules[i].ID]
+	}
+
+	return rules, nil
+}
+
+func (m HolidayRuleModel) Insert(r HolidayRule) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt := `INSERT INTO holiday_rules (name, rule_type, month, day, weekday, nth, offset_days, observed, start_year, end_year)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := tx.ExecContext(ctx, stmt, r.Name, r.RuleType, r.Month, r.Day, r.Weekday, r.Nth, r.OffsetDays, r.Observed, r.StartYear, r.EndYear)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := setOfficeIDs(tx, "holiday_rule_offices", "holiday_rule_id", int(id), r.OfficeIDs); err != nil {
+		return 0, err
+	}
+
+	return int(id), tx.Commit()
+}
+
+func (m HolidayRuleModel) Update(id int, r HolidayRule) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt := `UPDATE holiday_rules SET name = ?, rule_type = ?, month = ?, day = ?, weekday = ?, nth = ?, offset_days = ?, observed = ?, start_year = ?, end_year = ?
+	WHERE id = ?`
+	_, err = tx.ExecContext(ctx, stmt, r.Name, r.RuleType, r.Month, r.Day, r.Weekday, r.Nth, r.OffsetDays, r.Observed, r.StartYear, r.EndYear, id)
+	if err != nil {
+		return err
+	}
+
+	if err := setOfficeIDs(tx, "holiday_rule_offices", "holiday_rule_id", id, r.OfficeIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m HolidayRuleModel) Delete(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `DELETE FROM holiday_rules WHERE id = ?`
+	_, err := m.DB.ExecContext(ctx, stmt, id)
+	return err
+}
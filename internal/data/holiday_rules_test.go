@@ -0,0 +1,188 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func fixedRule(name string, month, day int, observed string) HolidayRule {
+	return HolidayRule{Name: name, RuleType: HolidayRuleFixed, Month: intPtr(month), Day: intPtr(day), Observed: observed}
+}
+
+func TestEasterSunday(t *testing.T) {
+	tests := []struct {
+		year int
+		want time.Time
+	}{
+		{2000, date(2000, time.April, 23)},
+		{2008, date(2008, time.March, 23)},
+		{2019, date(2019, time.April, 21)},
+		{2024, date(2024, time.March, 31)},
+		{2025, date(2025, time.April, 20)},
+		{2038, date(2038, time.April, 25)},
+	}
+
+	for _, tt := range tests {
+		if got := EasterSunday(tt.year); !got.Equal(tt.want) {
+			t.Errorf("EasterSunday(%d) = %s, want %s", tt.year, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestHolidayRuleOccurrence(t *testing.T) {
+	thanksgiving := HolidayRule{Name: "Thanksgiving", RuleType: HolidayRuleNthWeekday, Month: intPtr(11), Weekday: intPtr(4), Nth: intPtr(4)}
+	dayAfterThanksgiving := thanksgiving
+	dayAfterThanksgiving.OffsetDays = 1
+
+	tests := []struct {
+		name   string
+		rule   HolidayRule
+		year   int
+		want   time.Time
+		wantOK bool
+	}{
+		{"fixed", fixedRule("Independence Day", 7, 4, ObservedNone), 2025, date(2025, time.July, 4), true},
+		{"leap day", fixedRule("Leap Day", 2, 29, ObservedNone), 2024, date(2024, time.February, 29), true},
+		{"leap day in a common year", fixedRule("Leap Day", 2, 29, ObservedNone), 2025, time.Time{}, false},
+		{"nth weekday", thanksgiving, 2025, date(2025, time.November, 27), true},
+		{"nth weekday on the first", HolidayRule{RuleType: HolidayRuleNthWeekday, Month: intPtr(9), Weekday: intPtr(1), Nth: intPtr(1)}, 2025, date(2025, time.September, 1), true},
+		{"missing fifth weekday", HolidayRule{RuleType: HolidayRuleNthWeekday, Month: intPtr(2), Weekday: intPtr(1), Nth: intPtr(5)}, 2025, time.Time{}, false},
+		{"offset", dayAfterThanksgiving, 2024, date(2024, time.November, 29), true},
+		{"last weekday", HolidayRule{RuleType: HolidayRuleLastWeekday, Month: intPtr(5), Weekday: intPtr(1)}, 2025, date(2025, time.May, 26), true},
+		{"last weekday on the last day", HolidayRule{RuleType: HolidayRuleLastWeekday, Month: intPtr(8), Weekday: intPtr(1)}, 2026, date(2026, time.August, 31), true},
+		{"good friday", HolidayRule{RuleType: HolidayRuleEaster, OffsetDays: -2}, 2025, date(2025, time.April, 18), true},
+		{"easter monday", HolidayRule{RuleType: HolidayRuleEaster, OffsetDays: 1}, 2024, date(2024, time.April, 1), true},
+		{"before start year", HolidayRule{RuleType: HolidayRuleEaster, StartYear: intPtr(2026)}, 2025, time.Time{}, false},
+		{"after end year", HolidayRule{RuleType: HolidayRuleEaster, EndYear: intPtr(2024)}, 2025, time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.rule.Occurrence(tt.year)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("Occurrence(%d) = %s, %v, want %s, %v", tt.year, got.Format("2006-01-02"), ok, tt.want.Format("2006-01-02"), tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestHolidayRuleObservedDate(t *testing.T) {
+	saturday := date(2026, time.July, 4)
+	sunday := date(2027, time.July, 4)
+	monday := date(2028, time.July, 3)
+
+	tests := []struct {
+		observed string
+		date     time.Time
+		want     time.Time
+	}{
+		{ObservedNone, saturday, saturday},
+		{ObservedNearestWeekday, saturday, date(2026, time.July, 3)},
+		{ObservedNearestWeekday, sunday, date(2027, time.July, 5)},
+		{ObservedNextWeekday, saturday, date(2026, time.July, 6)},
+		{ObservedNextWeekday, sunday, date(2027, time.July, 5)},
+		{ObservedNextWeekday, monday, monday},
+	}
+
+	for _, tt := range tests {
+		rule := HolidayRule{Observed: tt.observed}
+		if got := rule.ObservedDate(tt.date); !got.Equal(tt.want) {
+			t.Errorf("%s ObservedDate(%s) = %s, want %s", tt.observed, tt.date.Format("Mon 2006-01-02"), got.Format("Mon 2006-01-02"), tt.want.Format("Mon 2006-01-02"))
+		}
+	}
+}
+
+func TestMaterializeHolidays(t *testing.T) {
+	christmas := fixedRule("Christmas Day", 12, 25, ObservedNextWeekday)
+	boxingDay := fixedRule("Boxing Day", 12, 26, ObservedNextWeekday)
+	newYear := fixedRule("New Year's Day", 1, 1, ObservedNearestWeekday)
+	thanksgiving := HolidayRule{Name: "Thanksgiving", RuleType: HolidayRuleNthWeekday, Month: intPtr(11), Weekday: intPtr(4), Nth: intPtr(4), Observed: ObservedNone}
+
+	type holiday struct {
+		name string
+		date time.Time
+	}
+	tests := []struct {
+		name   string
+		year   int
+		listed []Holiday
+		rules  []HolidayRule
+		want   []holiday
+	}{
+		{
+			name:  "on their own dates",
+			year:  2025,
+			rules: []HolidayRule{christmas, boxingDay},
+			want: []holiday{
+				{"Christmas Day", date(2025, time.December, 25)},
+				{"Boxing Day", date(2025, time.December, 26)},
+			},
+		},
+		{
+			name:  "observed day already taken",
+			year:  2022,
+			rules: []HolidayRule{christmas, boxingDay},
+			want: []holiday{
+				{"Boxing Day", date(2022, time.December, 26)},
+				{"Christmas Day (observed)", date(2022, time.December, 27)},
+			},
+		},
+		{
+			name:  "both on a weekend",
+			year:  2021,
+			rules: []HolidayRule{boxingDay, christmas},
+			want: []holiday{
+				{"Christmas Day (observed)", date(2021, time.December, 27)},
+				{"Boxing Day (observed)", date(2021, time.December, 28)},
+			},
+		},
+		{
+			name:  "observed in the previous year",
+			year:  2021,
+			rules: []HolidayRule{newYear},
+			want: []holiday{
+				{"New Year's Day", date(2021, time.January, 1)},
+				{"New Year's Day (observed)", date(2021, time.December, 31)},
+			},
+		},
+		{
+			name:  "observed date moved out of the year",
+			year:  2022,
+			rules: []HolidayRule{newYear},
+			want:  []holiday{},
+		},
+		{
+			name:   "one-off holiday wins on the same date",
+			year:   2025,
+			listed: []Holiday{{Name: "Company Day", Date: date(2025, time.November, 27), Year: 2025}},
+			rules:  []HolidayRule{thanksgiving},
+			want:   []holiday{{"Company Day", date(2025, time.November, 27)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []holiday{}
+			for _, h := range MaterializeHolidays(tt.year, tt.listed, tt.rules) {
+				got = append(got, holiday{h.Name, h.Date})
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("MaterializeHolidays(%d) = %v, want %v", tt.year, got, tt.want)
+			}
+			for i := range got {
+				if got[i].name != tt.want[i].name || !got[i].date.Equal(tt.want[i].date) {
+					t.Errorf("MaterializeHolidays(%d)[%d] = %s on %s, want %s on %s", tt.year, i,
+						got[i].name, got[i].date.Format("2006-01-02"), tt.want[i].name, tt.want[i].date.Format("2006-01-02"))
+				}
+			}
+		})
+	}
+}
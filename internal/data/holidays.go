@@ -6,13 +6,19 @@ import (
 )
 
 type Holiday struct {
-	ID          int       `json:"id"`
-	Name        string    `json:"name"`
-	Date        time.Time `json:"date"`
-	Year        int       `json:"year"`
-	IsRecurring bool      `json:"is_recurring"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID   int       `json:"id"`
+	Name string    `json:"name"`
+	Date time.Time `json:"date"`
+	Year int       `json:"year"`
+	// OfficeIDs lists the offices observing the holiday; empty means it is
+	// company-wide and every office observes it
+	OfficeIDs []int     `json:"office_ids"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// RuleID is set on holidays generated from a rule; they have no ID
+	RuleID *int `json:"rule_id,omitempty"`
+	// ActualDate is the rule's date when the holiday is observed on another day
+	ActualDate *time.Time `json:"actual_date,omitempty"`
 }
 
 type HolidayModel struct {
@@ -21,37 +27,25 @@ type HolidayModel struct {
 
 // GetAll returns all holidays
 func (m *HolidayModel) GetAll() ([]Holiday, error) {
-	query := `SELECT id, name, date, year, is_recurring, created_at, updated_at 
+	query := `SELECT id, name, date, year, created_at, updated_at 
 			  FROM holidays 
 			  ORDER BY date ASC`
 
-	rows, err := m.DB.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var holidays []Holiday
-	for rows.Next() {
-		var h Holiday
-		err := rows.Scan(&h.ID, &h.Name, &h.Date, &h.Year, &h.IsRecurring, &h.CreatedAt, &h.UpdatedAt)
-		if err != nil {
-			return nil, err
-		}
-		holidays = append(holidays, h)
-	}
-
-	return holidays, nil
+	return m.query(query)
 }
 
 // GetByYear returns holidays for a specific year
 func (m *HolidayModel) GetByYear(year int) ([]Holiday, error) {
-	query := `SELECT id, name, date, year, is_recurring, created_at, updated_at 
+	query := `SELECT id, name, date, year, created_at, updated_at 
 			  FROM holidays 
 			  WHERE year = ? 
 			  ORDER BY date ASC`
 
-	rows, err := m.DB.Query(query, year)
+	return m.query(query, year)
+}
+
+func (m *HolidayModel) query(query string, args ...any) ([]Holiday, error) {
+	rows, err := m.DB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -60,22 +54,39 @@ func (m *HolidayModel) GetByYear(year int) ([]Holiday, error) {
 	var holidays []Holiday
 	for rows.Next() {
 		var h Holiday
-		err := rows.Scan(&h.ID, &h.Name, &h.Date, &h.Year, &h.IsRecurring, &h.CreatedAt, &h.UpdatedAt)
+		err := rows.Scan(&h.ID, &h.Name, &h.Date, &h.Year, &h.CreatedAt, &h.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
 		holidays = append(holidays, h)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	offices, err := loadOfficeIDs(m.DB, `SELECT holiday_id, office_id FROM holiday_offices ORDER BY office_id`)
+	if err != nil {
+		return nil, err
+	}
+	for i := range holidays {
+		holidays[i].OfficeIDs = offices[holidays[i].ID]
+	}
 
 	return holidays, nil
 }
 
 // Insert creates a new holiday
 func (m *HolidayModel) Insert(holiday Holiday) (int, error) {
-	query := `INSERT INTO holidays (name, date, year, is_recurring) 
-			  VALUES (?, ?, ?, ?)`
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
 
-	result, err := m.DB.Exec(query, holiday.Name, holiday.Date, holiday.Year, holiday.IsRecurring)
+	query := `INSERT INTO holidays (name, date, year) 
+			  VALUES (?, ?, ?)`
+
+	result, err := tx.Exec(query, holiday.Name, holiday.Date, holiday.Year)
 	if err != nil {
 		return 0, err
 	}
@@ -85,17 +96,67 @@ func (m *HolidayModel) Insert(holiday Holiday) (int, error) {
 		return 0, err
 	}
 
-	return int(id), nil
+	if err := setOfficeIDs(tx, "holiday_offices", "holiday_id", int(id), holiday.OfficeIDs); err != nil {
+		return 0, err
+	}
+
+	return int(id), tx.Commit()
+}
+
+// InsertMany creates holidays in one transaction: all of them or none.
+func (m *HolidayModel) InsertMany(holidays []Holiday) ([]int, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO holidays (name, date, year) 
+			  VALUES (?, ?, ?)`
+
+	ids := make([]int, 0, len(holidays))
+	for _, holiday := range holidays {
+		result, err := tx.Exec(query, holiday.Name, holiday.Date, holiday.Year)
+		if err != nil {
+			return nil, err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := setOfficeIDs(tx, "holiday_offices", "holiday_id", int(id), holiday.OfficeIDs); err != nil {
+			return nil, err
+		}
+		ids = append(ids, int(id))
+	}
+
+	return ids, tx.Commit()
 }
 
-// Update updates an existing holiday
+// Update updates an existing holiday and replaces its offices
 func (m *HolidayModel) Update(id int, holiday Holiday) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `UPDATE holidays 
-			  SET name = ?, date = ?, year = ?, is_recurring = ? 
+			  SET name = ?, date = ?, year = ? 
 			  WHERE id = ?`
 
-	_, err := m.DB.Exec(query, holiday.Name, holiday.Date, holiday.Year, holiday.IsRecurring, id)
-	return err
+	_, err = tx.Exec(query, holiday.Name, holiday.Date, holiday.Year, id)
+	if err != nil {
+		return err
+	}
+
+	if err := setOfficeIDs(tx, "holiday_offices", "holiday_id", id, holiday.OfficeIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // Delete removes a holiday
@@ -104,3 +165,85 @@ func (m *HolidayModel) Delete(id int) error {
 	_, err := m.DB.Exec(query, id)
 	return err
 }
+
+// Materialize returns the holidays observed in year by an office: the
+// company-wide holidays and the ones assigned to it, one-off or generated by
+// holiday rules. A nil officeID selects the company-wide calendar alone.
+func (m *HolidayModel) Materialize(year int, officeID *int) ([]Holiday, error) {
+	listed, err := m.GetByYear(year)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := HolidayRuleModel{DB: m.DB}.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	inCalendar := func(officeIDs []int) bool {
+		if len(officeIDs) == 0 {
+			return true
+		}
+		if officeID == nil {
+			return false
+		}
+		for _, id := range officeIDs {
+			if id == *officeID {
+				return true
+			}
+		}
+		return false
+	}
+
+	var officeListed []Holiday
+	for _, h := range listed {
+		if inCalendar(h.OfficeIDs) {
+			officeListed = append(officeListed, h)
+		}
+	}
+	var officeRules []HolidayRule
+	for _, r := range rules {
+		if inCalendar(r.OfficeIDs) {
+			officeRules = append(officeRules, r)
+		}
+	}
+
+	return MaterializeHolidays(year, officeListed, officeRules), nil
+}
+
+// loadOfficeIDs maps holiday or holiday rule ids to their offices.
+func loadOfficeIDs(db *sql.DB, query string) (map[int][]int, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	offices := map[int][]int{}
+	for rows.Next() {
+		var id, officeID int
+		if err := rows.Scan(&id, &officeID); err != nil {
+			return nil, err
+		}
+		offices[id] = append(offices[id], officeID)
+	}
+
+	return offices, rows.Err()
+}
+
+// setOfficeIDs replaces the offices assigned to a holiday or holiday rule.
+func setOfficeIDs(tx *sql.Tx, table, column string, id int, officeIDs []int) error {
+	_, err := tx.Exec(`DELETE FROM `+table+` WHERE `+column+` = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	for _, officeID := range officeIDs {
+		_, err := tx.Exec(`INSERT IGNORE INTO `+table+` (`+column+`, office_id) VALUES (?, ?)`, id, officeID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
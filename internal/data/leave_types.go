@@ -0,0 +1,113 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DefaultLeaveType is charged when a request does not name a leave type.
+const DefaultLeaveType = "vacation"
+
+type LeaveType struct {
+	ID            int      `json:"id"`
+	Code          string   `json:"code"`
+	Name          string   `json:"name"`
+	DaysPerYear   *float64 `json:"days_per_year"` // NULL for leave that is not limited by a balance
+	AccrualMethod string   `json:"accrual_method"`
+	// CarryOverCap is the most unused days that move into the next year
+	CarryOverCap float64 `json:"carry_over_cap"`
+	// CarryOverExpiryMonths is how long carried days stay usable; NULL keeps them all year
+	CarryOverExpiryMonths *int      `json:"carry_over_expiry_months"`
+	RequiresApproval      bool      `json:"requires_approval"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+// Unlimited reports whether requests of this type are not limited by a balance.
+func (lt LeaveType) Unlimited() bool {
+	return lt.DaysPerYear == nil
+}
+
+type LeaveTypeModel struct {
+	DB *sql.DB
+}
+
+func (m LeaveTypeModel) GetAll() ([]LeaveType, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT id, code, name, days_per_year, accrual_method, carry_over_cap, carry_over_expiry_months, requires_approval, created_at
+	FROM leave_types ORDER BY id`
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var leaveTypes []LeaveType
+	for rows.Next() {
+		var lt LeaveType
+		err := rows.Scan(&lt.ID, &lt.Code, &lt.Name, &lt.DaysPerYear, &lt.AccrualMethod, &lt.CarryOverCap, &lt.CarryOverExpiryMonths, &lt.RequiresApproval, &lt.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		leaveTypes = append(leaveTypes, lt)
+	}
+
+	return leaveTypes, nil
+}
+
+func (m LeaveTypeModel) GetByCode(code string) (*LeaveType, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT id, code, name, days_per_year, accrual_method, carry_over_cap, carry_over_expiry_months, requires_approval, created_at
+	FROM leave_types WHERE code = ?`
+
+	var lt LeaveType
+	err := m.DB.QueryRowContext(ctx, query, code).Scan(&lt.ID, &lt.Code, &lt.Name, &lt.DaysPerYear, &lt.AccrualMethod, &lt.CarryOverCap, &lt.CarryOverExpiryMonths, &lt.RequiresApproval, &lt.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lt, nil
+}
+
+func (m LeaveTypeModel) Insert(lt LeaveType) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `INSERT INTO leave_types (code, name, days_per_year, accrual_method, carry_over_cap, carry_over_expiry_months, requires_approval)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+	result, err := m.DB.ExecContext(ctx, stmt, lt.Code, lt.Name, lt.DaysPerYear, lt.AccrualMethod, lt.CarryOverCap, lt.CarryOverExpiryMonths, lt.RequiresApproval)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (m LeaveTypeModel) Update(id int, lt LeaveType) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE leave_types SET name = ?, days_per_year = ?, accrual_method = ?, carry_over_cap = ?, carry_over_expiry_months = ?, requires_approval = ?
+	WHERE id = ?`
+	_, err := m.DB.ExecContext(ctx, stmt, lt.Name, lt.DaysPerYear, lt.AccrualMethod, lt.CarryOverCap, lt.CarryOverExpiryMonths, lt.RequiresApproval, id)
+	return err
+}
+
+func (m LeaveTypeModel) Delete(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `DELETE FROM leave_types WHERE id = ?`
+	_, err := m.DB.ExecContext(ctx, stmt, id)
+	return err
+}
@@ -3,6 +3,7 @@ package data
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"time"
 )
 
@@ -125,3 +126,75 @@ func (m *MenuPermissionModel) Delete(id int) error {
 	_, err := m.DB.ExecContext(ctx, stmt, id)
 	return err
 }
+
+// Matches reports whether the rule grants access to the associate. Role rules
+// are matched against the names of the roles assigned to the associate.
+func (p *MenuPermission) Matches(associate Associate, roles []string) bool {
+	value := ""
+	if p.PermissionValue != nil {
+		value = strings.TrimSpace(*p.PermissionValue)
+	}
+
+	switch strings.ToLower(p.PermissionType) {
+	case "everyone":
+		return true
+	case "department":
+		return strings.EqualFold(value, associate.Department)
+	case "title":
+		return strings.EqualFold(value, associate.Title)
+	case "role":
+		for _, role := range roles {
+			if strings.EqualFold(value, role) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MenuAccess describes whether a menu item is visible and which rules granted it.
+type MenuAccess struct {
+	MenuItem     string            `json:"menu_item"`
+	Visible      bool              `json:"visible"`
+	MatchedRules []*MenuPermission `json:"matched_rules"`
+}
+
+// EvaluateMenu resolves every configured menu item for the associate. An item
+// is visible when at least one of its rules matches.
+func EvaluateMenu(rules []*MenuPermission, associate Associate, roles []string) []MenuAccess {
+	var items []MenuAccess
+	index := map[string]int{}
+
+	for _, rule := range rules {
+		key := strings.ToLower(rule.MenuItem)
+		i, ok := index[key]
+		if !ok {
+			i = len(items)
+			index[key] = i
+			items = append(items, MenuAccess{MenuItem: rule.MenuItem, MatchedRules: []*MenuPermission{}})
+		}
+
+		if rule.Matches(associate, roles) {
+			items[i].Visible = true
+			items[i].MatchedRules = append(items[i].MatchedRules, rule)
+		}
+	}
+
+	return items
+}
+
+// CanAccessMenuItem reports whether the associate may use menuItem. Items with
+// no configured rules are not restricted.
+func CanAccessMenuItem(rules []*MenuPermission, associate Associate, roles []string, menuItem string) bool {
+	configured := false
+	for _, rule := range rules {
+		if !strings.EqualFold(rule.MenuItem, menuItem) {
+			continue
+		}
+		configured = true
+		if rule.Matches(associate, roles) {
+			return true
+		}
+	}
+	return !configured
+}
@@ -34,6 +34,7 @@ type Models struct {
 	Offices            OfficeModel
 	Departments        DepartmentModel
 	Tasks              TaskModel
+	TaskWorkflows      TaskWorkflowModel
 	Thanks             ThankModel
 	TimeOffRequests    TimeOffRequestModel
 	DocumentCategories DocumentCategoryModel
@@ -42,6 +43,16 @@ type Models struct {
 	TimeEntries        TimeEntryModel
 	ThanksCategories   ThanksCategoryModel
 	Holidays           HolidayModel
+	HolidayRules       HolidayRuleModel
+	Roles              RoleModel
+	LeaveTypes         LeaveTypeModel
+	PTOLedger          PTOLedgerModel
+	CalendarFeeds      CalendarFeedModel
+	Delegations        DelegationModel
+	Escalations        EscalationModel
+	SalaryChanges      SalaryChangeModel
+	Compensation       CompensationModel
+	SalaryRanges       SalaryRangeModel
 }
 
 type AssociateModel struct {
@@ -62,6 +73,7 @@ func New(db *sql.DB) Models {
 		Offices:            OfficeModel{DB: db},
 		Departments:        DepartmentModel{DB: db},
 		Tasks:              TaskModel{DB: db},
+		TaskWorkflows:      TaskWorkflowModel{DB: db},
 		Thanks:             ThankModel{DB: db},
 		TimeOffRequests:    TimeOffRequestModel{DB: db},
 		DocumentCategories: DocumentCategoryModel{DB: db},
@@ -70,6 +82,16 @@ func New(db *sql.DB) Models {
 		TimeEntries:        TimeEntryModel{DB: db},
 		ThanksCategories:   ThanksCategoryModel{DB: db},
 		Holidays:           HolidayModel{DB: db},
+		HolidayRules:       HolidayRuleModel{DB: db},
+		Roles:              RoleModel{DB: db},
+		LeaveTypes:         LeaveTypeModel{DB: db},
+		PTOLedger:          PTOLedgerModel{DB: db},
+		CalendarFeeds:      CalendarFeedModel{DB: db},
+		Delegations:        DelegationModel{DB: db},
+		Escalations:        EscalationModel{DB: db},
+		SalaryChanges:      SalaryChangeModel{DB: db},
+		Compensation:       CompensationModel{DB: db},
+		SalaryRanges:       SalaryRangeModel{DB: db},
 	}
 }
 
@@ -247,6 +269,33 @@ func (m AssociateModel) GetAll() ([]Associate, error) {
 	return associates, nil
 }
 
+// GetManagerLinks maps every associate's id to their manager's.
+func (m AssociateModel) GetManagerLinks() (map[int]*int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT id, manager_id FROM Associates`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := map[int]*int{}
+	for rows.Next() {
+		var id int
+		var managerID *int
+		if err := rows.Scan(&id, &managerID); err != nil {
+			return nil, err
+		}
+		links[id] = managerID
+	}
+
+	return links, rows.Err()
+}
+
+// Update edits an associate's profile. The salary changes only through
+// salary changes, which keep the compensation history. A manager below the
+// associate in the chart is rejected with ErrManagerCycle.
 func (m AssociateModel) Update(id int, associate Associate) error {
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
@@ -259,23 +308,38 @@ func (m AssociateModel) Update(id int, associate Associate) error {
         if err != nil {
             return err
         }
-        query = `UPDATE Associates SET first_name=?, last_name=?, title=?, department=?, office=?, status=?, start_date=?, empl_status=?, salary=?, dob=?, email=?, password=?, phone_number=?, gender=?, private_email=?, manager_id=? WHERE id=?`
+        query = `UPDATE Associates SET first_name=?, last_name=?, title=?, department=?, office=?, status=?, start_date=?, empl_status=?, dob=?, email=?, password=?, phone_number=?, gender=?, private_email=?, manager_id=? WHERE id=?`
         args = []interface{}{
             associate.FirstName, associate.LastName, associate.Title, associate.Department, associate.Office, associate.Status, 
-            associate.StartDate, associate.EmplStatus, associate.Salary, associate.DOB, associate.Email, hashedPassword, 
+            associate.StartDate, associate.EmplStatus, associate.DOB, associate.Email, hashedPassword, 
             associate.PhoneNumber, associate.Gender, associate.PrivateEmail, associate.ManagerID, id,
         }
     } else {
-        query = `UPDATE Associates SET first_name=?, last_name=?, title=?, department=?, office=?, status=?, start_date=?, empl_status=?, salary=?, dob=?, email=?, phone_number=?, gender=?, private_email=?, manager_id=? WHERE id=?`
+        query = `UPDATE Associates SET first_name=?, last_name=?, title=?, department=?, office=?, status=?, start_date=?, empl_status=?, dob=?, email=?, phone_number=?, gender=?, private_email=?, manager_id=? WHERE id=?`
         args = []interface{}{
             associate.FirstName, associate.LastName, associate.Title, associate.Department, associate.Office, associate.Status, 
-            associate.StartDate, associate.EmplStatus, associate.Salary, associate.DOB, associate.Email, 
+            associate.StartDate, associate.EmplStatus, associate.DOB, associate.Email, 
             associate.PhoneNumber, associate.Gender, associate.PrivateEmail, associate.ManagerID, id,
         }
     }
 
-    _, err := m.DB.ExecContext(ctx, query, args...)
-    return err
+    tx, err := m.DB.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if associate.ManagerID != nil {
+        if err := checkManager(ctx, tx, id, *associate.ManagerID); err != nil {
+            return err
+        }
+    }
+
+    _, err = tx.ExecContext(ctx, query, args...)
+    if err != nil {
+        return err
+    }
+    return tx.Commit()
 }
 
 func (m AssociateModel) UpdatePassword(id int, password string) error {
@@ -331,6 +395,18 @@ func (m OfficeModel) GetAll() ([]Office, error) {
 	return offices, nil
 }
 
+// GetByName finds the office an associate's Office field names.
+func (m OfficeModel) GetByName(name string) (*Office, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	query := `SELECT id, name FROM Offices WHERE name = ?`
+	var o Office
+	if err := m.DB.QueryRowContext(ctx, query, name).Scan(&o.ID, &o.Name); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
 func (m OfficeModel) Insert(office Office) (int, error) {
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
@@ -0,0 +1,148 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+)
+
+// ErrManagerCycle rejects a manager who reports to the associate, directly
+// or not, or is the associate.
+var ErrManagerCycle = errors.New("the manager reports to this associate, so they cannot manage them")
+
+// ErrManagerNotFound rejects a manager_id naming no associate.
+var ErrManagerNotFound = errors.New("manager not found")
+
+// OrgChart is the reporting tree built from every associate's manager_id.
+type OrgChart struct {
+	managers map[int]*int
+	reports  map[int][]int
+}
+
+// OrgEntry is someone Level steps below or above an associate: 1 is a
+// direct report or the direct manager.
+type OrgEntry struct {
+	ID    int
+	Level int
+}
+
+// NewOrgChart builds the chart from GetManagerLinks. Reports are in id order.
+func NewOrgChart(links map[int]*int) *OrgChart {
+	chart := &OrgChart{managers: links, reports: map[int][]int{}}
+	for id, manager := range links {
+		if manager != nil {
+			chart.reports[*manager] = append(chart.reports[*manager], id)
+		}
+	}
+	for _, ids := range chart.reports {
+		sort.Ints(ids)
+	}
+	return chart
+}
+
+// Has reports whether id is in the chart.
+func (c *OrgChart) Has(id int) bool {
+	_, ok := c.managers[id]
+	return ok
+}
+
+// Roots are the associates without a manager, in id order. Associates
+// caught in a loop saved before cycles were rejected have none; the lowest
+// id of each loop is a root so nobody drops out of the chart.
+func (c *OrgChart) Roots() []int {
+	var ids []int
+	for id := range c.managers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var roots []int
+	placed := map[int]bool{}
+	place := func(root int) {
+		roots = append(roots, root)
+		placed[root] = true
+		for _, r := range c.Reports(root, 0) {
+			placed[r.ID] = true
+		}
+	}
+	for _, id := range ids {
+		if manager := c.managers[id]; manager == nil || !c.Has(*manager) {
+			place(id)
+		}
+	}
+	for _, id := range ids {
+		if !placed[id] {
+			place(id)
+		}
+	}
+	return roots
+}
+
+// DirectReports are the associates managed by id, in id order.
+func (c *OrgChart) DirectReports(id int) []int {
+	return c.reports[id]
+}
+
+// Reports lists everyone below id, level by level, down to maxDepth levels;
+// 0 means every level.
+func (c *OrgChart) Reports(id, maxDepth int) []OrgEntry {
+	var reports []OrgEntry
+	seen := map[int]bool{id: true}
+	level := []int{id}
+	for depth := 1; len(level) > 0 && (maxDepth == 0 || depth <= maxDepth); depth++ {
+		var next []int
+		for _, manager := range level {
+			for _, report := range c.reports[manager] {
+				if !seen[report] {
+					seen[report] = true
+					reports = append(reports, OrgEntry{ID: report, Level: depth})
+					next = append(next, report)
+				}
+			}
+		}
+		level = next
+	}
+	return reports
+}
+
+// Chain lists id's managers, the direct manager first and the top of the
+// chart last.
+func (c *OrgChart) Chain(id int) []int {
+	var chain []int
+	seen := map[int]bool{id: true}
+	for manager := c.managers[id]; manager != nil && c.Has(*manager) && !seen[*manager]; manager = c.managers[*manager] {
+		seen[*manager] = true
+		chain = append(chain, *manager)
+	}
+	return chain
+}
+
+// checkManager rejects managerID as the manager of id when it is id or
+// someone below them. The chain above managerID is locked so a concurrent
+// change cannot close a loop. A link to an associate who no longer exists
+// ends the chain there.
+func checkManager(ctx context.Context, tx *sql.Tx, id, managerID int) error {
+	seen := map[int]bool{}
+	for current := &managerID; current != nil && !seen[*current]; {
+		if *current == id {
+			return ErrManagerCycle
+		}
+		seen[*current] = true
+
+		var next *int
+		err := tx.QueryRowContext(ctx, `SELECT manager_id FROM Associates WHERE id = ? FOR UPDATE`, *current).Scan(&next)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+			if *current == managerID {
+				return ErrManagerNotFound
+			}
+			// A dangling link higher up is the top of the chain
+			return nil
+		}
+		current = next
+	}
+	return nil
+}
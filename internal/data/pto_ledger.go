@@ -0,0 +1,153 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Ledger entry types
+const (
+	LedgerAccrual    = "accrual"
+	LedgerUsage      = "usage"
+	LedgerAdjustment = "adjustment"
+	LedgerCarryOver  = "carry_over"
+	LedgerExpiry     = "expiry"
+)
+
+// PTOLedgerEntry is one change to an associate's balance of a leave type.
+// Days are positive for credits and negative for debits.
+type PTOLedgerEntry struct {
+	ID               int        `json:"id"`
+	AssociateID      int        `json:"associate_id"`
+	LeaveType        string     `json:"leave_type"`
+	EntryType        string     `json:"entry_type"`
+	Days             float64    `json:"days"`
+	PeriodYear       int        `json:"period_year"`
+	EffectiveDate    time.Time  `json:"effective_date"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	TimeOffRequestID *int       `json:"time_off_request_id,omitempty"`
+	SourceKey        *string    `json:"-"`
+	Note             string     `json:"note"`
+	CreatedBy        *int       `json:"created_by,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+type PTOLedgerModel struct {
+	DB *sql.DB
+}
+
+const ptoLedgerColumns = `id, associate_id, leave_type, entry_type, days, period_year, effective_date, expires_at,
+	time_off_request_id, source_key, COALESCE(note, ''), created_by, created_at`
+
+func scanPTOLedgerEntries(rows *sql.Rows) ([]PTOLedgerEntry, error) {
+	var entries []PTOLedgerEntry
+	for rows.Next() {
+		var e PTOLedgerEntry
+		err := rows.Scan(&e.ID, &e.AssociateID, &e.LeaveType, &e.EntryType, &e.Days, &e.PeriodYear, &e.EffectiveDate, &e.ExpiresAt,
+			&e.TimeOffRequestID, &e.SourceKey, &e.Note, &e.CreatedBy, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// GetEntries returns an associate's ledger rows dated from..to (inclusive),
+// oldest first. An empty leaveType returns every leave type.
+func (m PTOLedgerModel) GetEntries(associateID int, leaveType string, from, to time.Time) ([]PTOLedgerEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + ptoLedgerColumns + `
+	FROM pto_ledger
+	WHERE associate_id = ? AND (? = '' OR leave_type = ?) AND effective_date BETWEEN ? AND ?
+	ORDER BY effective_date, id`
+
+	rows, err := m.DB.QueryContext(ctx, query, associateID, leaveType, leaveType, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPTOLedgerEntries(rows)
+}
+
+// Insert records a single entry, typically a manual adjustment.
+func (m PTOLedgerModel) Insert(e PTOLedgerEntry) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return insertPTOLedgerEntry(ctx, m.DB, e)
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func insertPTOLedgerEntry(ctx context.Context, db execer, e PTOLedgerEntry) (int, error) {
+	stmt := `INSERT INTO pto_ledger (associate_id, leave_type, entry_type, days, period_year, effective_date, expires_at,
+		time_off_request_id, source_key, note, created_by, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := db.ExecContext(ctx, stmt, e.AssociateID, e.LeaveType, e.EntryType, e.Days, e.PeriodYear, e.EffectiveDate, e.ExpiresAt,
+		e.TimeOffRequestID, e.SourceKey, e.Note, e.CreatedBy, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// Sync brings an associate's ledger for one leave type up to date. plan
+// receives every existing row and returns the rows to add. The associate row
+// is locked for the duration, so concurrent syncs (from any replica) see each
+// other's rows instead of posting them twice.
+func (m PTOLedgerModel) Sync(associateID int, leaveType string, plan func(existing []PTOLedgerEntry) ([]PTOLedgerEntry, error)) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var locked int
+	err = tx.QueryRowContext(ctx, `SELECT id FROM Associates WHERE id = ? FOR UPDATE`, associateID).Scan(&locked)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT `+ptoLedgerColumns+`
+	FROM pto_ledger
+	WHERE associate_id = ? AND leave_type = ?
+	ORDER BY effective_date, id`, associateID, leaveType)
+	if err != nil {
+		return err
+	}
+	existing, err := scanPTOLedgerEntries(rows)
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	added, err := plan(existing)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range added {
+		if _, err := insertPTOLedgerEntry(ctx, tx, e); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,307 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Permissions understood by the API. Roles are granted a subset of these.
+const (
+	PermSettingsManage          = "settings.manage"
+	PermRolesManage             = "roles.manage"
+	PermAssociatesManage        = "associates.manage"
+	PermOrgManage               = "org.manage"
+	PermHolidaysManage          = "holidays.manage"
+	PermMenuPermissionsManage   = "menu_permissions.manage"
+	PermTasksManage             = "tasks.manage"
+	PermTimeOffApproveAll       = "timeoff.approve_all"
+	PermTimeOffAutoApprove      = "timeoff.auto_approve"
+	PermTimeEntryApproveAll     = "timeentry.approve_all"
+	PermOvertimeExempt          = "timeentry.overtime_exempt"
+	PermLeaveTypesManage        = "leave_types.manage"
+	PermPTOManage               = "pto.manage"
+	PermDelegationsManage       = "delegations.manage"
+	PermCompensationView        = "compensation.view"
+	PermCompensationManage      = "compensation.manage"
+	PermAssociatesViewSensitive = "associates.view_sensitive"
+)
+
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// PermissionCatalogue lists every permission that can be granted to a role.
+var PermissionCatalogue = []Permission{
+	{Name: PermSettingsManage, Description: "Change application settings, sidebar and dashboard order"},
+	{Name: PermRolesManage, Description: "Create roles and assign them to associates"},
+	{Name: PermAssociatesManage, Description: "Create, delete and edit any associate, including restricted fields"},
+	{Name: PermOrgManage, Description: "Manage offices, departments and document categories"},
+	{Name: PermHolidaysManage, Description: "Create, update and delete holidays"},
+	{Name: PermMenuPermissionsManage, Description: "Manage menu visibility rules"},
+	{Name: PermTasksManage, Description: "Manage all tasks and thanks categories"},
+	{Name: PermTimeOffApproveAll, Description: "Approve any time-off request and receive requests from unmanaged managers"},
+	{Name: PermTimeOffAutoApprove, Description: "Own time-off requests are approved automatically"},
+	{Name: PermTimeEntryApproveAll, Description: "Approve any time entry"},
+	{Name: PermOvertimeExempt, Description: "Overtime does not require approval"},
+	{Name: PermLeaveTypesManage, Description: "Create, update and delete leave types"},
+	{Name: PermPTOManage, Description: "View any associate's PTO ledger, post manual adjustments and request time off for anyone"},
+	{Name: PermDelegationsManage, Description: "Register and revoke approval delegations for any associate"},
+	{Name: PermCompensationView, Description: "View any associate's compensation history, salary ranges and compa-ratio reports"},
+	{Name: PermCompensationManage, Description: "Record salary changes and manage salary ranges"},
+	{Name: PermAssociatesViewSensitive, Description: "See every associate field, whatever the field visibility policy"},
+}
+
+// IsKnownPermission reports whether name is part of the permission catalogue.
+func IsKnownPermission(name string) bool {
+	for _, p := range PermissionCatalogue {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+type Role struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type RoleModel struct {
+	DB *sql.DB
+}
+
+func (m RoleModel) GetAll() ([]Role, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT r.id, r.name, COALESCE(r.description, ''), COALESCE(rp.permission, '')
+	FROM roles r
+	LEFT JOIN role_permissions rp ON rp.role_id = r.id
+	ORDER BY r.name, rp.permission`
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []Role
+	for rows.Next() {
+		var r Role
+		var permission string
+		if err := rows.Scan(&r.ID, &r.Name, &r.Description, &permission); err != nil {
+			return nil, err
+		}
+
+		if len(roles) == 0 || roles[len(roles)-1].ID != r.ID {
+			r.Permissions = []string{}
+			roles = append(roles, r)
+		}
+		if permission != "" {
+			last := &roles[len(roles)-1]
+			last.Permissions = append(last.Permissions, permission)
+		}
+	}
+
+	return roles, nil
+}
+
+func (m RoleModel) Insert(role Role) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `INSERT INTO roles (name, description) VALUES (?, ?)`
+	result, err := m.DB.ExecContext(ctx, stmt, role.Name, role.Description)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (m RoleModel) Delete(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `DELETE FROM roles WHERE id = ?`
+	_, err := m.DB.ExecContext(ctx, stmt, id)
+	return err
+}
+
+// SetPermissions replaces the permissions granted to a role.
+func (m RoleModel) SetPermissions(roleID int, permissions []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE role_id = ?`, roleID)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range permissions {
+		_, err = tx.ExecContext(ctx, `INSERT INTO role_permissions (role_id, permission) VALUES (?, ?)`, roleID, p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetByAssociateID returns the roles assigned to an associate.
+func (m RoleModel) GetByAssociateID(associateID int) ([]Role, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT r.id, r.name, COALESCE(r.description, '')
+	FROM roles r
+	JOIN associate_roles ar ON ar.role_id = r.id
+	WHERE ar.associate_id = ?
+	ORDER BY r.name`
+
+	rows, err := m.DB.QueryContext(ctx, query, associateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []Role
+	for rows.Next() {
+		var r Role
+		if err := rows.Scan(&r.ID, &r.Name, &r.Description); err != nil {
+			return nil, err
+		}
+		roles = append(roles, r)
+	}
+
+	return roles, nil
+}
+
+func (m RoleModel) AssignToAssociate(associateID, roleID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `INSERT IGNORE INTO associate_roles (associate_id, role_id) VALUES (?, ?)`
+	_, err := m.DB.ExecContext(ctx, stmt, associateID, roleID)
+	return err
+}
+
+func (m RoleModel) RemoveFromAssociate(associateID, roleID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `DELETE FROM associate_roles WHERE associate_id = ? AND role_id = ?`
+	_, err := m.DB.ExecContext(ctx, stmt, associateID, roleID)
+	return err
+}
+
+// HasPermission reports whether any role assigned to the associate grants permission.
+func (m RoleModel) HasPermission(associateID int, permission string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT COUNT(*)
+	FROM associate_roles ar
+	JOIN role_permissions rp ON rp.role_id = ar.role_id
+	WHERE ar.associate_id = ? AND rp.permission = ?`
+
+	var count int
+	err := m.DB.QueryRowContext(ctx, query, associateID, permission).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// GetPermissionsForAssociate returns the distinct permissions granted through all of the associate's roles.
+func (m RoleModel) GetPermissionsForAssociate(associateID int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT DISTINCT rp.permission
+	FROM associate_roles ar
+	JOIN role_permissions rp ON rp.role_id = ar.role_id
+	WHERE ar.associate_id = ?
+	ORDER BY rp.permission`
+
+	rows, err := m.DB.QueryContext(ctx, query, associateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := []string{}
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, p)
+	}
+
+	return permissions, nil
+}
+
+// HasRole reports whether the associate has been assigned the named role.
+func (m RoleModel) HasRole(associateID int, roleName string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT COUNT(*)
+	FROM associate_roles ar
+	JOIN roles r ON r.id = ar.role_id
+	WHERE ar.associate_id = ? AND r.name = ?`
+
+	var count int
+	err := m.DB.QueryRowContext(ctx, query, associateID, roleName).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// GetAssociateIDsWithPermission returns every associate holding permission, lowest ID first.
+func (m RoleModel) GetAssociateIDsWithPermission(permission string) ([]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT DISTINCT ar.associate_id
+	FROM associate_roles ar
+	JOIN role_permissions rp ON rp.role_id = ar.role_id
+	WHERE rp.permission = ?
+	ORDER BY ar.associate_id`
+
+	rows, err := m.DB.QueryContext(ctx, query, permission)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
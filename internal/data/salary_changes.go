@@ -0,0 +1,292 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TaskSalaryIncrease is the task type whose approval changes a salary.
+const TaskSalaryIncrease = "Salary Increase"
+
+// Salary change statuses
+const (
+	SalaryChangeScheduled = "scheduled"
+	SalaryChangeApplied   = "applied"
+	SalaryChangeCancelled = "cancelled"
+)
+
+// ErrSalaryChangeNotScheduled is returned when cancelling a change that has
+// already been applied or cancelled.
+var ErrSalaryChangeNotScheduled = errors.New("the salary change is no longer scheduled")
+
+// SalaryChange is the salary an approved task, or a compensation manager,
+// sets from EffectiveDate. OldSalary is read when the change is applied.
+type SalaryChange struct {
+	ID            int        `json:"id"`
+	AssociateID   int        `json:"associate_id"`
+	TaskID        *int       `json:"task_id"`
+	OldSalary     *int       `json:"old_salary"`
+	NewSalary     int        `json:"new_salary"`
+	Currency      string     `json:"currency"`
+	EffectiveDate time.Time  `json:"effective_date"`
+	Reason        string     `json:"reason"`
+	Status        string     `json:"status"`
+	CreatedBy     *int       `json:"created_by"`
+	AppliedAt     *time.Time `json:"applied_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// SalaryTaskAssociate is the associate a salary increase task is for: the
+// associate named by TargetValue, or the requester when it is unset.
+func SalaryTaskAssociate(task Task) int {
+	if task.TargetValue > 0 {
+		return task.TargetValue
+	}
+	return task.RequesterID
+}
+
+type SalaryChangeModel struct {
+	DB *sql.DB
+}
+
+const salaryChangeQuery = `SELECT id, associate_id, task_id, old_salary, new_salary, currency, effective_date,
+	COALESCE(reason, ''), status, created_by, applied_at, created_at
+	FROM salary_changes WHERE `
+
+func (m SalaryChangeModel) query(ctx context.Context, where string, args ...any) ([]SalaryChange, error) {
+	rows, err := m.DB.QueryContext(ctx, salaryChangeQuery+where+` ORDER BY effective_date, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []SalaryChange{}
+	for rows.Next() {
+		var c SalaryChange
+		err := rows.Scan(&c.ID, &c.AssociateID, &c.TaskID, &c.OldSalary, &c.NewSalary, &c.Currency, &c.EffectiveDate,
+			&c.Reason, &c.Status, &c.CreatedBy, &c.AppliedAt, &c.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+
+	return changes, rows.Err()
+}
+
+// GetByTaskID returns the change an approved task made, or nil.
+func (m SalaryChangeModel) GetByTaskID(taskID int) (*SalaryChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	changes, err := m.query(ctx, `task_id = ?`, taskID)
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+	return &changes[0], nil
+}
+
+// GetDue returns the scheduled changes whose effective date has come by day.
+func (m SalaryChangeModel) GetDue(day time.Time) ([]SalaryChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.query(ctx, `status = ? AND effective_date <= ?`, SalaryChangeScheduled, day.Format("2006-01-02"))
+}
+
+// GetScheduled returns an associate's changes that have not taken effect.
+func (m SalaryChangeModel) GetScheduled(associateID int) ([]SalaryChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.query(ctx, `associate_id = ? AND status = ?`, associateID, SalaryChangeScheduled)
+}
+
+// Insert records a salary change entered by hand and applies it at once
+// unless it is dated after today. It reports whether it was applied.
+func (m SalaryChangeModel) Insert(change SalaryChange) (int, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	id, applied, err := insertSalaryChange(ctx, tx, change, time.Now())
+	if err != nil {
+		return 0, false, err
+	}
+
+	return id, applied, tx.Commit()
+}
+
+// Apply sets the associate's salary for a scheduled change, records the
+// salary it replaces and adds the change to the compensation history. It reports false when the change is no longer scheduled.
+func (m SalaryChangeModel) Apply(id int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	applied, err := applySalaryChange(ctx, tx, id)
+	if err != nil || !applied {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// Cancel withdraws one of an associate's changes before it takes effect. The
+// change stays on file as cancelled, and the task that made it, if any, stays
+// approved.
+func (m SalaryChangeModel) Cancel(associateID, id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Locked, as the scheduler locks it to apply it
+	var status string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM salary_changes WHERE id = ? AND associate_id = ? FOR UPDATE`,
+		id, associateID).Scan(&status)
+	if err != nil {
+		return err
+	}
+	if status != SalaryChangeScheduled {
+		return ErrSalaryChangeNotScheduled
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE salary_changes SET status = ? WHERE id = ?`, SalaryChangeCancelled, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// scheduleSalaryChange records the change an approved salary increase task
+// makes, in the associate's current currency.
+func scheduleSalaryChange(ctx context.Context, tx *sql.Tx, task Task, approvedAt time.Time) error {
+	newSalary, err := strconv.Atoi(strings.TrimSpace(task.Value))
+	if err != nil || newSalary < 0 {
+		return fmt.Errorf("invalid salary value %q", task.Value)
+	}
+
+	associateID := SalaryTaskAssociate(task)
+	currency, err := currentCurrency(ctx, tx, associateID)
+	if err != nil {
+		return err
+	}
+
+	change := SalaryChange{
+		AssociateID: associateID,
+		TaskID:      &task.ID,
+		NewSalary:   newSalary,
+		Currency:    currency,
+		Reason:      task.Reason,
+	}
+	if task.EffectiveDate != nil {
+		change.EffectiveDate = *task.EffectiveDate
+	}
+
+	_, _, err = insertSalaryChange(ctx, tx, change, approvedAt)
+	return err
+}
+
+// insertSalaryChange records change, effective on the day of now when it has
+// no date, and applies it at once unless it is dated after that day.
+func insertSalaryChange(ctx context.Context, tx *sql.Tx, change SalaryChange, now time.Time) (int, bool, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	effective := today
+	if !change.EffectiveDate.IsZero() {
+		effective = time.Date(change.EffectiveDate.Year(), change.EffectiveDate.Month(), change.EffectiveDate.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	if change.Currency == "" {
+		change.Currency = DefaultCurrency
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO salary_changes (associate_id, task_id, new_salary, currency, effective_date, reason, status, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		change.AssociateID, change.TaskID, change.NewSalary, change.Currency, effective.Format("2006-01-02"),
+		change.Reason, SalaryChangeScheduled, change.CreatedBy, now)
+	if err != nil {
+		return 0, false, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, false, err
+	}
+	if effective.After(today) {
+		return int(id), false, nil
+	}
+
+	applied, err := applySalaryChange(ctx, tx, int(id))
+	return int(id), applied, err
+}
+
+func applySalaryChange(ctx context.Context, tx *sql.Tx, id int) (bool, error) {
+	var associateID, newSalary int
+	var taskID, createdBy *int
+	var currency, status string
+	var reason sql.NullString
+	var effective time.Time
+	err := tx.QueryRowContext(ctx, `
+		SELECT associate_id, task_id, new_salary, currency, effective_date, reason, status, created_by
+		FROM salary_changes WHERE id = ? FOR UPDATE`, id).
+		Scan(&associateID, &taskID, &newSalary, &currency, &effective, &reason, &status, &createdBy)
+	if err != nil {
+		return false, err
+	}
+	if status != SalaryChangeScheduled {
+		return false, nil
+	}
+
+	var oldSalary sql.NullInt64
+	err = tx.QueryRowContext(ctx, `SELECT salary FROM Associates WHERE id = ? FOR UPDATE`, associateID).Scan(&oldSalary)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE Associates SET salary = ? WHERE id = ?`, newSalary, associateID)
+	if err != nil {
+		return false, err
+	}
+
+	var old *int
+	if oldSalary.Valid {
+		v := int(oldSalary.Int64)
+		old = &v
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE salary_changes SET old_salary = ?, status = ?, applied_at = ? WHERE id = ?`,
+		old, SalaryChangeApplied, time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO compensation_history (associate_id, amount, currency, effective_date, reason, task_id, salary_change_id, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		associateID, newSalary, currency, effective.Format("2006-01-02"), reason, taskID, id, createdBy, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
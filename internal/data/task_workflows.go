@@ -0,0 +1,322 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Task statuses
+const (
+	TaskPending  = "pending"
+	TaskApproved = "approved"
+	TaskRejected = "rejected"
+)
+
+// How a workflow step finds its approver
+const (
+	// ApproverManager is the requester's manager, ManagerLevel levels up
+	ApproverManager = "manager"
+	// ApproverRole is anyone holding the role
+	ApproverRole = "role"
+	// ApproverPermission is anyone granted the permission
+	ApproverPermission = "permission"
+	// ApproverAssociate is one named associate
+	ApproverAssociate = "associate"
+)
+
+// Task approval step statuses. Steps of later stages wait until every step
+// of the earlier stages is approved or skipped.
+const (
+	StepWaiting  = "waiting"
+	StepPending  = "pending"
+	StepApproved = "approved"
+	StepRejected = "rejected"
+	StepSkipped  = "skipped"
+)
+
+// WorkflowStep is one approval a task type requires. Steps that share a
+// Stage are decided in parallel.
+type WorkflowStep struct {
+	ID           int    `json:"id"`
+	Stage        int    `json:"stage"`
+	Name         string `json:"name"`
+	ApproverType string `json:"approver_type"`
+	ManagerLevel *int   `json:"manager_level,omitempty"`
+	Role         string `json:"role,omitempty"`
+	Permission   string `json:"permission,omitempty"`
+	AssociateID  *int   `json:"associate_id,omitempty"`
+}
+
+// Validate checks that the step names an approver its type can resolve.
+func (s WorkflowStep) Validate() error {
+	if s.Stage < 1 {
+		return errors.New("stage must be 1 or more")
+	}
+	if strings.TrimSpace(s.Name) == "" {
+		return errors.New("step name is required")
+	}
+
+	switch s.ApproverType {
+	case ApproverManager:
+		if s.ManagerLevel == nil || *s.ManagerLevel < 1 {
+			return errors.New("manager steps need a manager_level of 1 or more")
+		}
+	case ApproverRole:
+		if s.Role == "" {
+			return errors.New("role steps need a role")
+		}
+	case ApproverPermission:
+		if !IsKnownPermission(s.Permission) {
+			return errors.New("unknown permission: " + s.Permission)
+		}
+	case ApproverAssociate:
+		if s.AssociateID == nil {
+			return errors.New("associate steps need an associate_id")
+		}
+	default:
+		return errors.New("approver_type must be manager, role, permission or associate")
+	}
+	return nil
+}
+
+// TaskWorkflow lists the approval steps of one task type, named by the
+// task's TaskName.
+type TaskWorkflow struct {
+	ID        int            `json:"id"`
+	TaskName  string         `json:"task_name"`
+	Steps     []WorkflowStep `json:"steps"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// DefaultTaskWorkflow applies to task types without a workflow of their own:
+// the requester's manager decides.
+func DefaultTaskWorkflow(taskName string) TaskWorkflow {
+	level := 1
+	return TaskWorkflow{
+		TaskName: taskName,
+		Steps: []WorkflowStep{
+			{Stage: 1, Name: "Manager approval", ApproverType: ApproverManager, ManagerLevel: &level},
+		},
+	}
+}
+
+// TaskApprovalStep is a workflow step resolved for one task, with its
+// decision. ApproverID is set for manager and associate steps.
+type TaskApprovalStep struct {
+	ID           int        `json:"id"`
+	TaskID       int        `json:"task_id"`
+	Stage        int        `json:"stage"`
+	Name         string     `json:"name"`
+	ApproverType string     `json:"approver_type"`
+	ApproverID   *int       `json:"approver_id"`
+	ApproverName string     `json:"approver_name,omitempty"`
+	Role         string     `json:"role,omitempty"`
+	Permission   string     `json:"permission,omitempty"`
+	Status       string     `json:"status"`
+	DecidedBy    *int       `json:"decided_by"`
+	OnBehalfOf   *int       `json:"on_behalf_of"`
+	Comment      string     `json:"comment"`
+	DecidedAt    *time.Time `json:"decided_at"`
+}
+
+// AdvanceSteps moves a task's steps along after a change and returns the
+// task's status. A rejected step rejects the task and skips what is left;
+// otherwise the lowest stage with undecided steps becomes pending, and the
+// task is approved once no such stage remains.
+func AdvanceSteps(steps []TaskApprovalStep) string {
+	for _, s := range steps {
+		if s.Status == StepRejected {
+			for i := range steps {
+				if steps[i].Status == StepWaiting || steps[i].Status == StepPending {
+					steps[i].Status = StepSkipped
+				}
+			}
+			return TaskRejected
+		}
+	}
+
+	current := 0
+	for _, s := range steps {
+		if (s.Status == StepWaiting || s.Status == StepPending) && (current == 0 || s.Stage < current) {
+			current = s.Stage
+		}
+	}
+	if current == 0 {
+		return TaskApproved
+	}
+
+	for i := range steps {
+		if steps[i].Stage == current && steps[i].Status == StepWaiting {
+			steps[i].Status = StepPending
+		}
+	}
+	return TaskPending
+}
+
+type TaskWorkflowModel struct {
+	DB *sql.DB
+}
+
+func (m TaskWorkflowModel) GetAll() ([]TaskWorkflow, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.query(ctx, ``)
+}
+
+// GetByTaskName returns the workflow of a task type, or nil if it has none.
+func (m TaskWorkflowModel) GetByTaskName(taskName string) (*TaskWorkflow, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	workflows, err := m.query(ctx, `WHERE w.task_name = ?`, taskName)
+	if err != nil || len(workflows) == 0 {
+		return nil, err
+	}
+	return &workflows[0], nil
+}
+
+func (m TaskWorkflowModel) GetOne(id int) (*TaskWorkflow, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	workflows, err := m.query(ctx, `WHERE w.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(workflows) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &workflows[0], nil
+}
+
+func (m TaskWorkflowModel) query(ctx context.Context, where string, args ...any) ([]TaskWorkflow, error) {
+	query := `SELECT w.id, w.task_name, w.created_at, w.updated_at,
+	       s.id, s.stage, s.name, s.approver_type, s.manager_level, s.role, s.permission, s.associate_id
+	FROM task_workflows w
+	LEFT JOIN task_workflow_steps s ON s.workflow_id = w.id
+	` + where + `
+	ORDER BY w.task_name, s.stage, s.id`
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workflows := []TaskWorkflow{}
+	for rows.Next() {
+		var w TaskWorkflow
+		var stepID, stage sql.NullInt64
+		var name, approverType, role, permission sql.NullString
+		var s WorkflowStep
+		err := rows.Scan(&w.ID, &w.TaskName, &w.CreatedAt, &w.UpdatedAt,
+			&stepID, &stage, &name, &approverType, &s.ManagerLevel, &role, &permission, &s.AssociateID)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(workflows) == 0 || workflows[len(workflows)-1].ID != w.ID {
+			w.Steps = []WorkflowStep{}
+			workflows = append(workflows, w)
+		}
+		if stepID.Valid {
+			s.ID, s.Stage = int(stepID.Int64), int(stage.Int64)
+			s.Name, s.ApproverType, s.Role, s.Permission = name.String, approverType.String, role.String, permission.String
+			last := &workflows[len(workflows)-1]
+			last.Steps = append(last.Steps, s)
+		}
+	}
+
+	return workflows, rows.Err()
+}
+
+func (m TaskWorkflowModel) Insert(w TaskWorkflow) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `INSERT INTO task_workflows (task_name, created_at, updated_at) VALUES (?, ?, ?)`,
+		w.TaskName, time.Now(), time.Now())
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := setWorkflowSteps(ctx, tx, int(id), w.Steps); err != nil {
+		return 0, err
+	}
+
+	return int(id), tx.Commit()
+}
+
+// Update replaces a workflow's task name and steps. Tasks already filed keep
+// the steps they were given.
+func (m TaskWorkflowModel) Update(id int, w TaskWorkflow) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE task_workflows SET task_name = ?, updated_at = ? WHERE id = ?`,
+		w.TaskName, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	if err := setWorkflowSteps(ctx, tx, id, w.Steps); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m TaskWorkflowModel) Delete(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `DELETE FROM task_workflows WHERE id = ?`, id)
+	return err
+}
+
+func setWorkflowSteps(ctx context.Context, tx *sql.Tx, workflowID int, steps []WorkflowStep) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM task_workflow_steps WHERE workflow_id = ?`, workflowID)
+	if err != nil {
+		return err
+	}
+
+	sorted := append([]WorkflowStep(nil), steps...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Stage < sorted[j].Stage })
+	for _, s := range sorted {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO task_workflow_steps (workflow_id, stage, name, approver_type, manager_level, role, permission, associate_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			workflowID, s.Stage, s.Name, s.ApproverType, s.ManagerLevel, s.Role, s.Permission, s.AssociateID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,7 +3,8 @@ package data
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
+	"errors"
+	"strings"
 	"time"
 )
 
@@ -15,32 +16,51 @@ type Task struct {
 	Reason      string          `json:"Reason"`
 	Status      string          `json:"status"`
 	TargetValue int             `json:"TargetValue"`
-	Approvers   json.RawMessage `json:"approvers"`
+	// Approvers are the task's approval steps, resolved from its workflow
+	Approvers   []TaskApprovalStep `json:"approvers"`
 	Timestamp   int             `json:"timestamp"`
 	Comments    string          `json:"comments"`
+	// DecidedBy took the decision that settled the task; OnBehalfOf is set
+	// when they did so as a delegate
+	DecidedBy  *int       `json:"decided_by"`
+	OnBehalfOf *int       `json:"on_behalf_of"`
+	DecidedAt  *time.Time `json:"decided_at"`
+	// EffectiveDate is when an approved salary increase takes effect; unset
+	// means on approval
+	EffectiveDate *time.Time `json:"effective_date"`
+	// SalaryChange is the change an approved salary increase made or has
+	// scheduled; only GetOne fills it in
+	SalaryChange *SalaryChange `json:"salary_change,omitempty"`
 }
 
 type TaskModel struct {
 	DB *sql.DB
 }
 
+// Insert files a task with its resolved approval steps.
 func (m TaskModel) Insert(task Task) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	stmt := `INSERT INTO Tasks (requester_id, task_name, task_value, reason, status, target_value, approvers, timestamp, comments)
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt := `INSERT INTO Tasks (requester_id, task_name, task_value, reason, status, target_value, timestamp, comments, effective_date)
     VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := m.DB.ExecContext(ctx, stmt,
+	result, err := tx.ExecContext(ctx, stmt,
 		task.RequesterID,
 		task.TaskName,
 		task.Value,
 		task.Reason,
 		task.Status,
 		task.TargetValue,
-		task.Approvers,
 		task.Timestamp,
 		task.Comments,
+		task.EffectiveDate,
 	)
 	if err != nil {
 		return 0, err
@@ -51,14 +71,32 @@ func (m TaskModel) Insert(task Task) (int, error) {
 		return 0, err
 	}
 
-	return int(id), nil
+	if err := insertTaskSteps(ctx, tx, int(id), task.Approvers); err != nil {
+		return 0, err
+	}
+
+	return int(id), tx.Commit()
+}
+
+func insertTaskSteps(ctx context.Context, tx *sql.Tx, taskID int, steps []TaskApprovalStep) error {
+	for _, step := range steps {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO task_approval_steps (task_id, stage, name, approver_type, approver_id, role, permission, status)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			taskID, step.Stage, step.Name, step.ApproverType, step.ApproverID, step.Role, step.Permission, step.Status)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (m TaskModel) GetByUserID(userID int) ([]Task, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `SELECT id, requester_id, task_name, task_value, reason, status, target_value, approvers, timestamp, comments
+	query := `SELECT id, requester_id, task_name, task_value, reason, status, target_value, timestamp, comments,
+    decided_by, decided_on_behalf_of, decided_at, effective_date
     FROM Tasks
     WHERE requester_id = ?
     ORDER BY timestamp DESC`
@@ -72,7 +110,6 @@ func (m TaskModel) GetByUserID(userID int) ([]Task, error) {
 	var tasks []Task
 	for rows.Next() {
 		var t Task
-		var approvers []byte
 		err := rows.Scan(
 			&t.ID,
 			&t.RequesterID,
@@ -81,18 +118,20 @@ func (m TaskModel) GetByUserID(userID int) ([]Task, error) {
 			&t.Reason,
 			&t.Status,
 			&t.TargetValue,
-			&approvers,
 			&t.Timestamp,
 			&t.Comments,
+			&t.DecidedBy,
+			&t.OnBehalfOf,
+			&t.DecidedAt,
+			&t.EffectiveDate,
 		)
 		if err != nil {
 			return nil, err
 		}
-		t.Approvers = json.RawMessage(approvers)
 		tasks = append(tasks, t)
 	}
 
-	return tasks, nil
+	return tasks, attachTaskSteps(ctx, m.DB, tasks)
 }
 
 // GetAll returns all tasks
@@ -100,7 +139,8 @@ func (m TaskModel) GetAll() ([]Task, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `SELECT id, requester_id, task_name, task_value, reason, status, target_value, approvers, timestamp, comments
+	query := `SELECT id, requester_id, task_name, task_value, reason, status, target_value, timestamp, comments,
+    decided_by, decided_on_behalf_of, decided_at, effective_date
     FROM Tasks
     ORDER BY timestamp DESC`
 
@@ -113,7 +153,6 @@ func (m TaskModel) GetAll() ([]Task, error) {
 	var tasks []Task
 	for rows.Next() {
 		var t Task
-		var approvers []byte
 		err := rows.Scan(
 			&t.ID,
 			&t.RequesterID,
@@ -122,29 +161,31 @@ func (m TaskModel) GetAll() ([]Task, error) {
 			&t.Reason,
 			&t.Status,
 			&t.TargetValue,
-			&approvers,
 			&t.Timestamp,
 			&t.Comments,
+			&t.DecidedBy,
+			&t.OnBehalfOf,
+			&t.DecidedAt,
+			&t.EffectiveDate,
 		)
 		if err != nil {
 			return nil, err
 		}
-		t.Approvers = json.RawMessage(approvers)
 		tasks = append(tasks, t)
 	}
 
-	return tasks, nil
+	return tasks, attachTaskSteps(ctx, m.DB, tasks)
 }
 
 func (m TaskModel) GetOne(id int) (*Task, error) {
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
-    query := `SELECT id, requester_id, task_name, task_value, reason, status, target_value, approvers, timestamp, comments
+    query := `SELECT id, requester_id, task_name, task_value, reason, status, target_value, timestamp, comments,
+    decided_by, decided_on_behalf_of, decided_at, effective_date
     FROM Tasks WHERE id = ?`
 
     var t Task
-    var approvers []byte
     row := m.DB.QueryRowContext(ctx, query, id)
     err := row.Scan(
         &t.ID,
@@ -154,43 +195,282 @@ func (m TaskModel) GetOne(id int) (*Task, error) {
         &t.Reason,
         &t.Status,
         &t.TargetValue,
-        &approvers,
         &t.Timestamp,
         &t.Comments,
+        &t.DecidedBy,
+        &t.OnBehalfOf,
+        &t.DecidedAt,
+        &t.EffectiveDate,
     )
 
     if err != nil {
         return nil, err
     }
-    t.Approvers = json.RawMessage(approvers)
-    
+
+    steps, err := loadTaskSteps(ctx, m.DB, `s.task_id = ?`, id)
+    if err != nil {
+        return nil, err
+    }
+    t.Approvers = steps[id]
+    if t.Approvers == nil {
+        t.Approvers = []TaskApprovalStep{}
+    }
+
+    if t.TaskName == TaskSalaryIncrease {
+        t.SalaryChange, err = SalaryChangeModel{DB: m.DB}.GetByTaskID(id)
+        if err != nil {
+            return nil, err
+        }
+    }
+
     return &t, nil
 }
 
+// Update edits a task's content. Its type and status are not touched; those
+// change through Decide. When task.Approvers is set it replaces the task's
+// steps, which is only done before the first decision.
 func (m TaskModel) Update(id int, task Task) error {
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
-    stmt := `UPDATE Tasks SET task_name=?, task_value=?, reason=?, status=?, target_value=?, comments=?, approvers=? WHERE id=?`
+    tx, err := m.DB.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    stmt := `UPDATE Tasks SET task_value=?, reason=?, target_value=?, comments=?, effective_date=? WHERE id=?`
 
-    _, err := m.DB.ExecContext(ctx, stmt,
-        task.TaskName,
+    _, err = tx.ExecContext(ctx, stmt,
         task.Value,
         task.Reason,
-        task.Status,
         task.TargetValue,
         task.Comments,
-        task.Approvers,
+        task.EffectiveDate,
         id,
     )
-    return err
+    if err != nil {
+        return err
+    }
+
+    if task.Approvers != nil {
+        _, err = tx.ExecContext(ctx, `DELETE FROM task_approval_steps WHERE task_id = ?`, id)
+        if err != nil {
+            return err
+        }
+        if err := insertTaskSteps(ctx, tx, id, task.Approvers); err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit()
 }
 
+// Delete removes a task that has not been approved. An approved task stays
+// on file with the salary change it made; a change still to take effect is
+// withdrawn with SalaryChangeModel.Cancel.
 func (m TaskModel) Delete(id int) error {
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
+    tx, err := m.DB.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    // Locked, so an approval cannot land between the check and the delete
+    var status string
+    err = tx.QueryRowContext(ctx, `SELECT status FROM Tasks WHERE id = ? FOR UPDATE`, id).Scan(&status)
+    if err != nil {
+        return err
+    }
+    if strings.EqualFold(status, TaskApproved) {
+        return ErrTaskApproved
+    }
+
     stmt := `DELETE FROM Tasks WHERE id = ?`
-    _, err := m.DB.ExecContext(ctx, stmt, id)
-    return err
+    _, err = tx.ExecContext(ctx, stmt, id)
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+var (
+	// ErrTaskDecided is returned when deciding a task that is no longer pending.
+	ErrTaskDecided = errors.New("the task has already been decided")
+	// ErrStepDecided is returned when a step was decided, or became
+	// undecidable, between reading it and deciding it.
+	ErrStepDecided = errors.New("the approval step has changed, reload and try again")
+	// ErrTaskApproved is returned when deleting an approved task.
+	ErrTaskApproved = errors.New("an approved task cannot be deleted")
+)
+
+// StepDecision names a pending step an approver decides, and whose approval
+// they give when acting as a delegate.
+type StepDecision struct {
+	StepID     int
+	OnBehalfOf *int
+}
+
+// Decide records actorID's approval or rejection of pending steps, advances
+// the workflow and settles the task once it is approved or rejected. An
+// approved salary increase also records its salary change. It returns the
+// task's new status.
+func (m TaskModel) Decide(taskID int, decisions []StepDecision, approve bool, actorID int, comment string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	// Locking the task serializes approvers deciding parallel steps
+	task := Task{ID: taskID}
+	err = tx.QueryRowContext(ctx, `
+		SELECT requester_id, task_name, task_value, status, target_value, effective_date
+		FROM Tasks WHERE id = ? FOR UPDATE`, taskID).
+		Scan(&task.RequesterID, &task.TaskName, &task.Value, &task.Status, &task.TargetValue, &task.EffectiveDate)
+	if err != nil {
+		return "", err
+	}
+	status := task.Status
+	if !strings.EqualFold(status, TaskPending) {
+		return "", ErrTaskDecided
+	}
+
+	byTask, err := loadTaskSteps(ctx, tx, `s.task_id = ?`, taskID)
+	if err != nil {
+		return "", err
+	}
+	steps := byTask[taskID]
+	before := make([]string, len(steps))
+	for i, step := range steps {
+		before[i] = step.Status
+	}
+
+	decided := map[int]bool{}
+	now := time.Now()
+	for _, d := range decisions {
+		found := false
+		for i := range steps {
+			if steps[i].ID != d.StepID {
+				continue
+			}
+			if steps[i].Status != StepPending {
+				return "", ErrStepDecided
+			}
+			steps[i].Status = StepRejected
+			if approve {
+				steps[i].Status = StepApproved
+			}
+			steps[i].DecidedBy, steps[i].OnBehalfOf = &actorID, d.OnBehalfOf
+			steps[i].Comment, steps[i].DecidedAt = comment, &now
+			decided[steps[i].ID] = true
+			found = true
+		}
+		if !found {
+			return "", ErrStepDecided
+		}
+	}
+
+	status = AdvanceSteps(steps)
+	for i, step := range steps {
+		if !decided[step.ID] && step.Status == before[i] {
+			continue
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE task_approval_steps SET status = ?, decided_by = ?, on_behalf_of = ?, comment = ?, decided_at = ?
+			WHERE id = ?`, step.Status, step.DecidedBy, step.OnBehalfOf, step.Comment, step.DecidedAt, step.ID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if status != TaskPending {
+		var onBehalfOf *int
+		if len(decisions) > 0 {
+			onBehalfOf = decisions[0].OnBehalfOf
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE Tasks SET status = ?, decided_by = ?, decided_on_behalf_of = ?, decided_at = ? WHERE id = ?`,
+			status, actorID, onBehalfOf, now, taskID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// An approved salary increase changes the salary in the same transaction,
+	// or schedules the change when it is dated later
+	if status == TaskApproved && task.TaskName == TaskSalaryIncrease {
+		if err := scheduleSalaryChange(ctx, tx, task, now); err != nil {
+			return "", err
+		}
+	}
+
+	return status, tx.Commit()
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// loadTaskSteps returns the approval steps matching where, by task, in
+// stage order.
+func loadTaskSteps(ctx context.Context, db queryer, where string, args ...any) (map[int][]TaskApprovalStep, error) {
+	query := `SELECT s.id, s.task_id, s.stage, s.name, s.approver_type, s.approver_id,
+	       COALESCE(CONCAT(a.first_name, ' ', a.last_name), ''), s.role, s.permission, s.status,
+	       s.decided_by, s.on_behalf_of, COALESCE(s.comment, ''), s.decided_at
+	FROM task_approval_steps s
+	LEFT JOIN Associates a ON s.approver_id = a.id
+	WHERE ` + where + `
+	ORDER BY s.task_id, s.stage, s.id`
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	steps := map[int][]TaskApprovalStep{}
+	for rows.Next() {
+		var s TaskApprovalStep
+		err := rows.Scan(&s.ID, &s.TaskID, &s.Stage, &s.Name, &s.ApproverType, &s.ApproverID, &s.ApproverName,
+			&s.Role, &s.Permission, &s.Status, &s.DecidedBy, &s.OnBehalfOf, &s.Comment, &s.DecidedAt)
+		if err != nil {
+			return nil, err
+		}
+		steps[s.TaskID] = append(steps[s.TaskID], s)
+	}
+
+	return steps, rows.Err()
+}
+
+// attachTaskSteps fills in the approval steps of tasks.
+func attachTaskSteps(ctx context.Context, db queryer, tasks []Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]any, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+
+	steps, err := loadTaskSteps(ctx, db, `s.task_id IN (`+placeholders+`)`, ids...)
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		tasks[i].Approvers = steps[tasks[i].ID]
+		if tasks[i].Approvers == nil {
+			tasks[i].Approvers = []TaskApprovalStep{}
+		}
+	}
+	return nil
 }
@@ -3,9 +3,13 @@ package data
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"time"
 )
 
+// TimeEntryPending is the status of an entry whose overtime awaits approval.
+const TimeEntryPending = "Pending"
+
 type TimeEntry struct {
 	ID            int       `json:"id"`
 	AssociateID   int       `json:"associate_id"`
@@ -17,6 +21,13 @@ type TimeEntry struct {
     FirstName     string    `json:"first_name,omitempty"`
     LastName      string    `json:"last_name,omitempty"`
 	CreatedAt     time.Time `json:"created_at"`
+	// DecidedBy approved or rejected the entry; OnBehalfOf is set when they
+	// did so as a delegate
+	DecidedBy  *int       `json:"decided_by"`
+	OnBehalfOf *int       `json:"on_behalf_of"`
+	DecidedAt  *time.Time `json:"decided_at"`
+	// EscalatedTo is who a stale entry was escalated to, above the manager
+	EscalatedTo *int `json:"escalated_to"`
 }
 
 type TimeEntryModel struct {
@@ -54,7 +65,8 @@ func (m TimeEntryModel) GetByAssociateID(associateID int) ([]TimeEntry, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `SELECT t.id, t.associate_id, t.date, t.hours, t.overtime_hours, t.comments, t.status, t.created_at, a.first_name, a.last_name
+	query := `SELECT t.id, t.associate_id, t.date, t.hours, t.overtime_hours, t.comments, t.status, t.created_at, a.first_name, a.last_name,
+           t.decided_by, t.decided_on_behalf_of, t.decided_at, t.escalated_to
     FROM time_entries t
     JOIN Associates a ON t.associate_id = a.id
     WHERE t.associate_id = ?
@@ -80,6 +92,10 @@ func (m TimeEntryModel) GetByAssociateID(associateID int) ([]TimeEntry, error) {
 			&e.CreatedAt,
             &e.FirstName,
             &e.LastName,
+			&e.DecidedBy,
+			&e.OnBehalfOf,
+			&e.DecidedAt,
+			&e.EscalatedTo,
 		)
 		if err != nil {
 			return nil, err
@@ -90,17 +106,30 @@ func (m TimeEntryModel) GetByAssociateID(associateID int) ([]TimeEntry, error) {
 	return entries, nil
 }
 
-func (m TimeEntryModel) GetByManagerID(managerID int) ([]TimeEntry, error) {
+// GetByManagerIDs returns the entries of the direct reports of any of
+// managerIDs, and those escalated to them, which lets a delegate see the
+// queues of the managers they stand in for.
+func (m TimeEntryModel) GetByManagerIDs(managerIDs []int) ([]TimeEntry, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `SELECT t.id, t.associate_id, t.date, t.hours, t.overtime_hours, t.comments, t.status, t.created_at, a.first_name, a.last_name
+	if len(managerIDs) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(managerIDs)), ", ")
+	args := make([]any, len(managerIDs))
+	for i, id := range managerIDs {
+		args[i] = id
+	}
+
+	query := `SELECT t.id, t.associate_id, t.date, t.hours, t.overtime_hours, t.comments, t.status, t.created_at, a.first_name, a.last_name,
+           t.decided_by, t.decided_on_behalf_of, t.decided_at, t.escalated_to
     FROM time_entries t
     JOIN Associates a ON t.associate_id = a.id
-    WHERE a.manager_id = ?
+    WHERE a.manager_id IN (` + placeholders + `) OR t.escalated_to IN (` + placeholders + `)
     ORDER BY t.date DESC`
 
-	rows, err := m.DB.QueryContext(ctx, query, managerID)
+	rows, err := m.DB.QueryContext(ctx, query, append(args, args...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -120,6 +149,10 @@ func (m TimeEntryModel) GetByManagerID(managerID int) ([]TimeEntry, error) {
 			&e.CreatedAt,
 			&e.FirstName,
 			&e.LastName,
+			&e.DecidedBy,
+			&e.OnBehalfOf,
+			&e.DecidedAt,
+			&e.EscalatedTo,
 		)
 		if err != nil {
 			return nil, err
@@ -134,7 +167,8 @@ func (m TimeEntryModel) GetAll() ([]TimeEntry, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `SELECT t.id, t.associate_id, t.date, t.hours, t.overtime_hours, t.comments, t.status, t.created_at, a.first_name, a.last_name
+	query := `SELECT t.id, t.associate_id, t.date, t.hours, t.overtime_hours, t.comments, t.status, t.created_at, a.first_name, a.last_name,
+           t.decided_by, t.decided_on_behalf_of, t.decided_at, t.escalated_to
     FROM time_entries t
     JOIN Associates a ON t.associate_id = a.id
     ORDER BY t.date DESC`
@@ -159,6 +193,10 @@ func (m TimeEntryModel) GetAll() ([]TimeEntry, error) {
 			&e.CreatedAt,
             &e.FirstName,
             &e.LastName,
+			&e.DecidedBy,
+			&e.OnBehalfOf,
+			&e.DecidedAt,
+			&e.EscalatedTo,
 		)
 		if err != nil {
 			return nil, err
@@ -169,12 +207,14 @@ func (m TimeEntryModel) GetAll() ([]TimeEntry, error) {
 	return entries, nil
 }
 
-func (m TimeEntryModel) UpdateStatus(id int, status string) error {
+// UpdateStatus records an approver's decision on an entry. onBehalfOf is the
+// manager or second approver a delegate decided for, nil otherwise.
+func (m TimeEntryModel) UpdateStatus(id int, status string, deciderID int, onBehalfOf *int) error {
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
-    stmt := `UPDATE time_entries SET status = ? WHERE id = ?`
-    _, err := m.DB.ExecContext(ctx, stmt, status, id)
+    stmt := `UPDATE time_entries SET status = ?, decided_by = ?, decided_on_behalf_of = ?, decided_at = ? WHERE id = ?`
+    _, err := m.DB.ExecContext(ctx, stmt, status, deciderID, onBehalfOf, time.Now(), id)
     return err
 }
 
@@ -190,7 +230,8 @@ func (m TimeEntryModel) Delete(id int) error {
 
 // GetOne retrieves a single time entry by ID
 func (m *TimeEntryModel) GetOne(id int) (*TimeEntry, error) {
-	query := `SELECT id, associate_id, date, hours, overtime_hours, comments, status, created_at
+	query := `SELECT id, associate_id, date, hours, overtime_hours, comments, status, created_at,
+			  decided_by, decided_on_behalf_of, decided_at, escalated_to
 			  FROM time_entries 
 			  WHERE id = ?`
 
@@ -204,6 +245,10 @@ func (m *TimeEntryModel) GetOne(id int) (*TimeEntry, error) {
 		&entry.Comments,
 		&entry.Status,
 		&entry.CreatedAt,
+		&entry.DecidedBy,
+		&entry.OnBehalfOf,
+		&entry.DecidedAt,
+		&entry.EscalatedTo,
 	)
 
 	if err != nil {
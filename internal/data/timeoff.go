@@ -3,63 +3,153 @@ package data
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strings"
 	"time"
 )
 
+// Time-off request statuses
+const (
+	TimeOffPending               = "Pending"
+	TimeOffApproved              = "Approved"
+	TimeOffRejected              = "Rejected"
+	TimeOffWithdrawn             = "Withdrawn"
+	TimeOffCancellationRequested = "CancellationRequested"
+	TimeOffCancelled             = "Cancelled"
+)
+
+// Who may perform a transition
+const (
+	TimeOffActorRequester = "requester"
+	TimeOffActorApprover  = "approver"
+	TimeOffActorAdmin     = "admin"
+)
+
+// TimeOffTransitions maps each status to the statuses it can move to and the
+// actors allowed to make the move. Declining a cancellation returns the
+// request to Approved.
+var TimeOffTransitions = map[string]map[string][]string{
+	TimeOffPending: {
+		TimeOffApproved:  {TimeOffActorApprover, TimeOffActorAdmin},
+		TimeOffRejected:  {TimeOffActorApprover, TimeOffActorAdmin},
+		TimeOffWithdrawn: {TimeOffActorRequester},
+	},
+	TimeOffApproved: {
+		TimeOffCancellationRequested: {TimeOffActorRequester},
+	},
+	TimeOffCancellationRequested: {
+		TimeOffCancelled: {TimeOffActorApprover, TimeOffActorAdmin},
+		TimeOffApproved:  {TimeOffActorApprover, TimeOffActorAdmin},
+	},
+}
+
+// ErrStatusChanged is returned when a request's status changed between
+// reading it and applying a transition.
+var ErrStatusChanged = errors.New("the request's status has changed, reload and try again")
+
+// CanTransitionTimeOff reports whether an actor holding any of actorRoles may
+// move a request from one status to another. The bool is false when the
+// transition does not exist at all.
+func CanTransitionTimeOff(from, to string, actorRoles []string) (allowed bool, exists bool) {
+	allowedActors, exists := TimeOffTransitions[from][to]
+	if !exists {
+		return false, false
+	}
+	for _, actor := range allowedActors {
+		for _, role := range actorRoles {
+			if actor == role {
+				return true, true
+			}
+		}
+	}
+	return false, true
+}
+
+// Parts of a day a request can cover. Partial days are single-day requests.
+const (
+	DayPortionFull  = "full"
+	DayPortionAM    = "am"
+	DayPortionPM    = "pm"
+	DayPortionHours = "hours"
+)
+
 type TimeOffRequest struct {
 	ID           int       `json:"id"`
 	AssociateID  int       `json:"associate_id"`
+	LeaveType    string    `json:"leave_type"`
 	StartDate    time.Time `json:"start_date"`
 	EndDate      time.Time `json:"end_date"`
+	DayPortion   string    `json:"day_portion"`
+	// Hours is the time taken off by an hours request
+	Hours        *float64  `json:"hours"`
 	Reason       string    `json:"reason"`
 	ApproverID   *int      `json:"approver_id"`
+	// EscalatedTo is who a stale request moved up to; the approver may still decide it
+	EscalatedTo  *int      `json:"escalated_to"`
 	Status       string    `json:"status"`
+	// CoverageWarning is set when too many teammates would be out at once
+	CoverageWarning bool `json:"coverage_warning"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 	EmployeeName string `json:"employee_name,omitempty"`
 	ApproverName string `json:"approver_name,omitempty"`
+	// DurationDays and DurationHours are the working time the request
+	// charges, filled in by the API rather than stored
+	DurationDays  *float64 `json:"duration_days,omitempty"`
+	DurationHours *float64 `json:"duration_hours,omitempty"`
 }
 
-type TimeOffRequestModel struct {
-	DB *sql.DB
+// DayFraction is the share of a working day the request takes on each of
+// its days.
+func (r TimeOffRequest) DayFraction(hoursPerDay float64) float64 {
+	switch r.DayPortion {
+	case DayPortionAM, DayPortionPM:
+		return 0.5
+	case DayPortionHours:
+		if r.Hours == nil || hoursPerDay <= 0 || *r.Hours >= hoursPerDay {
+			return 1
+		}
+		return *r.Hours / hoursPerDay
+	}
+	return 1
 }
 
-func (m *TimeOffRequestModel) InitTable() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+// IsPartialDay reports whether the request covers only part of its day.
+func (r TimeOffRequest) IsPartialDay() bool {
+	return r.DayPortion != "" && r.DayPortion != DayPortionFull
+}
 
-	stmt := `
-	CREATE TABLE IF NOT EXISTS time_off_requests (
-		id INT AUTO_INCREMENT PRIMARY KEY,
-		associate_id INT NOT NULL,
-		start_date DATETIME NOT NULL,
-		end_date DATETIME NOT NULL,
-		reason TEXT,
-		status VARCHAR(50) DEFAULT 'Pending',
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-		FOREIGN KEY (associate_id) REFERENCES associates(id)
-	);`
-
-	_, err := m.DB.ExecContext(ctx, stmt)
-	return err
+type TimeOffRequestModel struct {
+	DB *sql.DB
 }
 
-func (m *TimeOffRequestModel) Insert(req TimeOffRequest) (int, error) {
+// Insert files a request and records its initial status in the history,
+// with actorID, the associate who filed it, as the actor.
+func (m *TimeOffRequestModel) Insert(req TimeOffRequest, actorID int, comment string) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
 	stmt := `
-		INSERT INTO time_off_requests (associate_id, start_date, end_date, reason, approver_id, status, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+		INSERT INTO time_off_requests (associate_id, leave_type, start_date, end_date, day_portion, hours, reason, approver_id, status, coverage_warning, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := m.DB.ExecContext(ctx, stmt,
+	result, err := tx.ExecContext(ctx, stmt,
 		req.AssociateID,
+		req.LeaveType,
 		req.StartDate,
 		req.EndDate,
+		req.DayPortion,
+		req.Hours,
 		req.Reason,
 		req.ApproverID,
 		req.Status,
+		req.CoverageWarning,
 		time.Now(),
 		time.Now(),
 	)
@@ -73,6 +163,15 @@ func (m *TimeOffRequestModel) Insert(req TimeOffRequest) (int, error) {
 		return 0, err
 	}
 
+	err = insertTimeOffHistory(ctx, tx, int(id), nil, req.Status, &actorID, nil, comment)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
 	return int(id), nil
 }
 
@@ -82,7 +181,7 @@ func (m *TimeOffRequestModel) GetAll() ([]*TimeOffRequest, error) {
 
 	// Query to get requests and join with associates to get the name and approver name
 	query := `
-		SELECT t.id, t.associate_id, t.start_date, t.end_date, t.reason, t.approver_id, t.status, t.created_at, t.updated_at,
+		SELECT t.id, t.associate_id, t.leave_type, t.start_date, t.end_date, t.day_portion, t.hours, t.reason, t.approver_id, t.escalated_to, t.status, t.coverage_warning, t.created_at, t.updated_at,
 		       COALESCE(a.first_name, 'Unknown') as first_name, COALESCE(a.last_name, '') as last_name,
 		       COALESCE(approver.first_name, '') as approver_first_name, COALESCE(approver.last_name, '') as approver_last_name
 		FROM time_off_requests t
@@ -105,11 +204,16 @@ func (m *TimeOffRequestModel) GetAll() ([]*TimeOffRequest, error) {
 		err := rows.Scan(
 			&req.ID,
 			&req.AssociateID,
+			&req.LeaveType,
 			&req.StartDate,
 			&req.EndDate,
+			&req.DayPortion,
+			&req.Hours,
 			&req.Reason,
 			&req.ApproverID,
+			&req.EscalatedTo,
 			&req.Status,
+			&req.CoverageWarning,
 			&req.CreatedAt,
 			&req.UpdatedAt,
 			&firstName,
@@ -130,21 +234,34 @@ func (m *TimeOffRequestModel) GetAll() ([]*TimeOffRequest, error) {
 	return requests, nil
 }
 
-func (m *TimeOffRequestModel) GetByApproverID(approverID int) ([]*TimeOffRequest, error) {
+// GetByApproverIDs returns the requests routed or escalated to any of
+// approverIDs, which lets a delegate see the queues of the approvers they
+// stand in for.
+func (m *TimeOffRequestModel) GetByApproverIDs(approverIDs []int) ([]*TimeOffRequest, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	if len(approverIDs) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(approverIDs)), ", ")
+	args := make([]any, 0, 2*len(approverIDs))
+	for _, id := range approverIDs {
+		args = append(args, id)
+	}
+	args = append(args, args...)
+
 	query := `
-		SELECT t.id, t.associate_id, t.start_date, t.end_date, t.reason, t.approver_id, t.status, t.created_at, t.updated_at,
+		SELECT t.id, t.associate_id, t.leave_type, t.start_date, t.end_date, t.day_portion, t.hours, t.reason, t.approver_id, t.escalated_to, t.status, t.coverage_warning, t.created_at, t.updated_at,
 		       COALESCE(a.first_name, 'Unknown') as first_name, COALESCE(a.last_name, '') as last_name,
 		       COALESCE(approver.first_name, '') as approver_first_name, COALESCE(approver.last_name, '') as approver_last_name
 		FROM time_off_requests t
 		LEFT JOIN Associates a ON t.associate_id = a.id
 		LEFT JOIN Associates approver ON t.approver_id = approver.id
-		WHERE t.approver_id = ?
+		WHERE t.approver_id IN (` + placeholders + `) OR t.escalated_to IN (` + placeholders + `)
 		ORDER BY t.created_at DESC`
 
-	rows, err := m.DB.QueryContext(ctx, query, approverID)
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -159,11 +276,16 @@ func (m *TimeOffRequestModel) GetByApproverID(approverID int) ([]*TimeOffRequest
 		err := rows.Scan(
 			&req.ID,
 			&req.AssociateID,
+			&req.LeaveType,
 			&req.StartDate,
 			&req.EndDate,
+			&req.DayPortion,
+			&req.Hours,
 			&req.Reason,
 			&req.ApproverID,
+			&req.EscalatedTo,
 			&req.Status,
+			&req.CoverageWarning,
 			&req.CreatedAt,
 			&req.UpdatedAt,
 			&firstName,
@@ -189,7 +311,7 @@ func (m *TimeOffRequestModel) GetByAssociateID(associateID int) ([]*TimeOffReque
 	defer cancel()
 
 	query := `
-		SELECT id, associate_id, start_date, end_date, reason, status, created_at, updated_at
+		SELECT id, associate_id, leave_type, start_date, end_date, day_portion, hours, reason, approver_id, escalated_to, status, coverage_warning, created_at, updated_at
 		FROM time_off_requests
 		WHERE associate_id = ?
 		ORDER BY created_at DESC`
@@ -207,10 +329,16 @@ func (m *TimeOffRequestModel) GetByAssociateID(associateID int) ([]*TimeOffReque
 		err := rows.Scan(
 			&req.ID,
 			&req.AssociateID,
+			&req.LeaveType,
 			&req.StartDate,
 			&req.EndDate,
+			&req.DayPortion,
+			&req.Hours,
 			&req.Reason,
+			&req.ApproverID,
+			&req.EscalatedTo,
 			&req.Status,
+			&req.CoverageWarning,
 			&req.CreatedAt,
 			&req.UpdatedAt,
 		)
@@ -223,17 +351,40 @@ func (m *TimeOffRequestModel) GetByAssociateID(associateID int) ([]*TimeOffReque
 	return requests, nil
 }
 
-func (m *TimeOffRequestModel) UpdateStatus(id int, status string) error {
+// Transition moves a request from one status to another and records who did
+// it, and whose approval they gave when acting as a delegate. It fails with
+// ErrStatusChanged if the request is no longer in from.
+func (m *TimeOffRequestModel) Transition(id int, from, to string, actorID int, onBehalfOf *int, comment string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	stmt := `
 		UPDATE time_off_requests
 		SET status = ?, updated_at = ?
-		WHERE id = ?`
+		WHERE id = ? AND status = ?`
 
-	_, err := m.DB.ExecContext(ctx, stmt, status, time.Now(), id)
-	return err
+	result, err := tx.ExecContext(ctx, stmt, to, time.Now(), id, from)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrStatusChanged
+	}
+
+	err = insertTimeOffHistory(ctx, tx, id, &from, to, &actorID, onBehalfOf, comment)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (m *TimeOffRequestModel) Delete(id int) error {
@@ -251,14 +402,17 @@ func (m *TimeOffRequestModel) Update(id int, req TimeOffRequest) error {
 
     stmt := `
         UPDATE time_off_requests
-        SET start_date = ?, end_date = ?, reason = ?, status = ?, updated_at = ?
+        SET leave_type = ?, start_date = ?, end_date = ?, day_portion = ?, hours = ?, reason = ?, coverage_warning = ?, updated_at = ?
         WHERE id = ?`
 
     _, err := m.DB.ExecContext(ctx, stmt, 
+        req.LeaveType,
         req.StartDate,
         req.EndDate,
+        req.DayPortion,
+        req.Hours,
         req.Reason,
-        req.Status,
+        req.CoverageWarning,
         time.Now(),
         id,
     )
@@ -270,7 +424,7 @@ func (m *TimeOffRequestModel) GetOne(id int) (*TimeOffRequest, error) {
     defer cancel()
 
     query := `
-        SELECT id, associate_id, start_date, end_date, reason, status, created_at, updated_at
+        SELECT id, associate_id, leave_type, start_date, end_date, day_portion, hours, reason, approver_id, escalated_to, status, coverage_warning, created_at, updated_at
         FROM time_off_requests
         WHERE id = ?`
 
@@ -279,10 +433,16 @@ func (m *TimeOffRequestModel) GetOne(id int) (*TimeOffRequest, error) {
     err := row.Scan(
         &req.ID,
         &req.AssociateID,
+        &req.LeaveType,
         &req.StartDate,
         &req.EndDate,
+        &req.DayPortion,
+        &req.Hours,
         &req.Reason,
+        &req.ApproverID,
+        &req.EscalatedTo,
         &req.Status,
+        &req.CoverageWarning,
         &req.CreatedAt,
         &req.UpdatedAt,
     )
@@ -293,3 +453,165 @@ func (m *TimeOffRequestModel) GetOne(id int) (*TimeOffRequest, error) {
 
     return &req, nil
 }
+
+// TimeOffHistoryEntry records one status change of a time-off request.
+type TimeOffHistoryEntry struct {
+	ID               int       `json:"id"`
+	TimeOffRequestID int       `json:"time_off_request_id"`
+	FromStatus       *string   `json:"from_status"`
+	ToStatus         string    `json:"to_status"`
+	ActorID          *int      `json:"actor_id"`
+	ActorName        string    `json:"actor_name,omitempty"`
+	OnBehalfOf       *int      `json:"on_behalf_of"`
+	OnBehalfOfName   string    `json:"on_behalf_of_name,omitempty"`
+	Comment          string    `json:"comment"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func insertTimeOffHistory(ctx context.Context, tx *sql.Tx, requestID int, from *string, to string, actorID, onBehalfOf *int, comment string) error {
+	stmt := `
+		INSERT INTO time_off_request_history (time_off_request_id, from_status, to_status, actor_id, on_behalf_of, comment, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := tx.ExecContext(ctx, stmt, requestID, from, to, actorID, onBehalfOf, comment, time.Now())
+	return err
+}
+
+// GetHistory returns a request's status changes, oldest first.
+func (m *TimeOffRequestModel) GetHistory(id int) ([]TimeOffHistoryEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT h.id, h.time_off_request_id, h.from_status, h.to_status, h.actor_id,
+		       COALESCE(CONCAT(a.first_name, ' ', a.last_name), ''), h.on_behalf_of,
+		       COALESCE(CONCAT(principal.first_name, ' ', principal.last_name), ''), COALESCE(h.comment, ''), h.created_at
+		FROM time_off_request_history h
+		LEFT JOIN Associates a ON h.actor_id = a.id
+		LEFT JOIN Associates principal ON h.on_behalf_of = principal.id
+		WHERE h.time_off_request_id = ?
+		ORDER BY h.created_at, h.id`
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []TimeOffHistoryEntry{}
+	for rows.Next() {
+		var h TimeOffHistoryEntry
+		err := rows.Scan(&h.ID, &h.TimeOffRequestID, &h.FromStatus, &h.ToStatus, &h.ActorID, &h.ActorName, &h.OnBehalfOf, &h.OnBehalfOfName, &h.Comment, &h.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+
+	return history, nil
+}
+
+// ActiveTimeOffStatuses are the statuses of requests that still claim their dates.
+var ActiveTimeOffStatuses = []string{TimeOffPending, TimeOffApproved, TimeOffCancellationRequested}
+
+// Team scopes for coverage checks
+const (
+	TeamScopeManager    = "manager"
+	TeamScopeDepartment = "department"
+)
+
+const timeOffWithEmployeeQuery = `
+		SELECT t.id, t.associate_id, t.leave_type, t.start_date, t.end_date, t.day_portion, t.hours, t.reason, t.approver_id, t.escalated_to, t.status, t.coverage_warning,
+		       t.created_at, t.updated_at, COALESCE(CONCAT(a.first_name, ' ', a.last_name), 'Unknown')
+		FROM time_off_requests t
+		JOIN Associates a ON t.associate_id = a.id
+		WHERE `
+
+func (m *TimeOffRequestModel) queryWithEmployee(ctx context.Context, where string, args ...any) ([]*TimeOffRequest, error) {
+	rows, err := m.DB.QueryContext(ctx, timeOffWithEmployeeQuery+where+` ORDER BY t.start_date, t.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []*TimeOffRequest
+	for rows.Next() {
+		var req TimeOffRequest
+		err := rows.Scan(&req.ID, &req.AssociateID, &req.LeaveType, &req.StartDate, &req.EndDate, &req.DayPortion, &req.Hours, &req.Reason, &req.ApproverID, &req.EscalatedTo,
+			&req.Status, &req.CoverageWarning, &req.CreatedAt, &req.UpdatedAt, &req.EmployeeName)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, &req)
+	}
+
+	return requests, rows.Err()
+}
+
+func (m *TimeOffRequestModel) queryOverlapping(ctx context.Context, filter string, start, end time.Time, excludeID int, args ...any) ([]*TimeOffRequest, error) {
+	queryArgs := []any{ActiveTimeOffStatuses[0], ActiveTimeOffStatuses[1], ActiveTimeOffStatuses[2], end, start, excludeID}
+	queryArgs = append(queryArgs, args...)
+
+	return m.queryWithEmployee(ctx, `t.status IN (?, ?, ?) AND t.start_date <= ? AND t.end_date >= ? AND t.id <> ?`+filter, queryArgs...)
+}
+
+// GetOverlapping returns an associate's active requests that overlap
+// start..end, other than excludeID (0 to exclude nothing).
+func (m *TimeOffRequestModel) GetOverlapping(associateID int, start, end time.Time, excludeID int) ([]*TimeOffRequest, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.queryOverlapping(ctx, ` AND t.associate_id = ?`, start, end, excludeID, associateID)
+}
+
+// GetTeamOverlapping returns the active requests of the requester's teammates
+// that overlap start..end. The team is everyone sharing the requester's
+// manager, or their department, depending on scope.
+func (m *TimeOffRequestModel) GetTeamOverlapping(scope string, requester Associate, start, end time.Time, excludeID int) ([]*TimeOffRequest, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	switch scope {
+	case TeamScopeManager:
+		if requester.ManagerID == nil {
+			return nil, nil
+		}
+		return m.queryOverlapping(ctx, ` AND a.manager_id = ? AND a.id <> ?`, start, end, excludeID, *requester.ManagerID, requester.ID)
+	case TeamScopeDepartment:
+		return m.queryOverlapping(ctx, ` AND a.department = ? AND a.id <> ?`, start, end, excludeID, requester.Department, requester.ID)
+	}
+	return nil, errors.New("unknown team scope: " + scope)
+}
+
+// AbsenceFilter narrows GetBooked to one associate, a manager's direct
+// reports, or a department. Empty fields do not filter.
+type AbsenceFilter struct {
+	AssociateID *int
+	ManagerID   *int
+	Department  string
+}
+
+// GetBooked returns the approved requests overlapping from..to, including
+// those whose cancellation is still pending.
+func (m *TimeOffRequestModel) GetBooked(from, to time.Time, filter AbsenceFilter) ([]*TimeOffRequest, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	where := `t.status IN (?, ?) AND DATE(t.start_date) <= DATE(?) AND DATE(t.end_date) >= DATE(?)`
+	args := []any{TimeOffApproved, TimeOffCancellationRequested, to, from}
+
+	if filter.AssociateID != nil {
+		where += ` AND t.associate_id = ?`
+		args = append(args, *filter.AssociateID)
+	}
+	if filter.ManagerID != nil {
+		where += ` AND a.manager_id = ?`
+		args = append(args, *filter.ManagerID)
+	}
+	if filter.Department != "" {
+		where += ` AND a.department = ?`
+		args = append(args, filter.Department)
+	}
+
+	return m.queryWithEmployee(ctx, where, args...)
+}
@@ -0,0 +1,81 @@
+// Package ical writes and reads all-day events in iCalendar (RFC 5545) format
+// for calendar subscriptions and holiday imports and exports.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// Event is an all-day event covering Start through End, both inclusive.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+	Categories  string
+}
+
+const dateFormat = "20060102"
+
+// Write renders events as a VCALENDAR named name.
+func Write(w io.Writer, name string, events []Event) error {
+	bw := bufio.NewWriter(w)
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	writeLine(bw, "BEGIN:VCALENDAR")
+	writeLine(bw, "VERSION:2.0")
+	writeLine(bw, "PRODID:-//WorkOps//WorkOps API//EN")
+	writeLine(bw, "CALSCALE:GREGORIAN")
+	writeLine(bw, "METHOD:PUBLISH")
+	writeLine(bw, "X-WR-CALNAME:"+escape(name))
+
+	for _, e := range events {
+		writeLine(bw, "BEGIN:VEVENT")
+		writeLine(bw, "UID:"+escape(e.UID))
+		writeLine(bw, "DTSTAMP:"+stamp)
+		writeLine(bw, "DTSTART;VALUE=DATE:"+e.Start.Format(dateFormat))
+		// DTEND is exclusive for all-day events
+		writeLine(bw, "DTEND;VALUE=DATE:"+e.End.AddDate(0, 0, 1).Format(dateFormat))
+		writeLine(bw, "SUMMARY:"+escape(e.Summary))
+		if e.Description != "" {
+			writeLine(bw, "DESCRIPTION:"+escape(e.Description))
+		}
+		if e.Categories != "" {
+			writeLine(bw, "CATEGORIES:"+escape(e.Categories))
+		}
+		writeLine(bw, "TRANSP:TRANSPARENT")
+		writeLine(bw, "END:VEVENT")
+	}
+
+	writeLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// writeLine ends a content line with CRLF, folding it so no physical line is
+// longer than 75 octets.
+func writeLine(w *bufio.Writer, line string) {
+	const limit = 75
+
+	for len(line) > limit {
+		cut := limit
+		// Do not split a multi-byte UTF-8 sequence
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
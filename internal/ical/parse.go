@@ -0,0 +1,138 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ParsedEvent is an event read from a calendar file. Recurring is set when
+// the event carries an RRULE, which Parse does not expand.
+type ParsedEvent struct {
+	Event
+	Recurring bool
+}
+
+// Parse reads the VEVENTs of an iCalendar file as all-day events. Timed
+// events are reduced to the days they touch.
+func Parse(r io.Reader) ([]ParsedEvent, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []ParsedEvent
+	var current *ParsedEvent
+	var allDayEnd bool
+	sawCalendar := false
+
+	for i, line := range lines {
+		name, params, value := splitProperty(line)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VCALENDAR"):
+			sawCalendar = true
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			current = &ParsedEvent{}
+			allDayEnd = false
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if current == nil {
+				return nil, fmt.Errorf("line %d: END:VEVENT without BEGIN:VEVENT", i+1)
+			}
+			if current.Start.IsZero() {
+				return nil, fmt.Errorf("line %d: event %q has no DTSTART", i+1, current.Summary)
+			}
+			if current.End.IsZero() {
+				current.End = current.Start
+			} else if allDayEnd {
+				// DTEND is exclusive for all-day events
+				current.End = current.End.AddDate(0, 0, -1)
+			}
+			if current.End.Before(current.Start) {
+				current.End = current.Start
+			}
+			events = append(events, *current)
+			current = nil
+		case current == nil:
+			continue
+		case name == "UID":
+			current.UID = unescape(value)
+		case name == "SUMMARY":
+			current.Summary = unescape(value)
+		case name == "DESCRIPTION":
+			current.Description = unescape(value)
+		case name == "CATEGORIES":
+			current.Categories = unescape(value)
+		case name == "RRULE":
+			current.Recurring = true
+		case name == "DTSTART" || name == "DTEND":
+			date, err := parseDate(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s: %w", i+1, name, err)
+			}
+			if name == "DTSTART" {
+				current.Start = date
+			} else {
+				current.End = date
+				allDayEnd = strings.Contains(strings.ToUpper(params), "VALUE=DATE") || len(value) == len(dateFormat)
+			}
+		}
+	}
+
+	if !sawCalendar {
+		return nil, errors.New("not an iCalendar file: BEGIN:VCALENDAR is missing")
+	}
+	return events, nil
+}
+
+// unfold joins folded content lines (continuations start with a space or tab).
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// splitProperty splits "NAME;PARAM=X:value" into its name, parameters and value.
+func splitProperty(line string) (string, string, string) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return strings.ToUpper(line), "", ""
+	}
+	head, value := line[:colon], line[colon+1:]
+
+	params := ""
+	if semi := strings.Index(head, ";"); semi >= 0 {
+		head, params = head[:semi], head[semi+1:]
+	}
+	return strings.ToUpper(head), params, value
+}
+
+// parseDate reads a DATE or DATE-TIME value and keeps the calendar day.
+func parseDate(value string) (time.Time, error) {
+	if len(value) < len(dateFormat) {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	date, err := time.Parse(dateFormat, value[:len(dateFormat)])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}
+
+var unescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
@@ -0,0 +1,337 @@
+// Package pto derives PTO ledger entries (accruals, carry-over, expiry and
+// usage) from a leave type's policy and an associate's approved time off.
+//
+// Balances are kept per calendar year: each year opens with the days carried
+// over from the previous one, earns accruals, and is debited by usage and
+// expiry. System-generated entries carry a source key so re-planning never
+// posts them twice; usage is reconciled by posting the difference between
+// what the ledger holds for a request and what the request now costs.
+package pto
+
+import (
+	"backend/internal/calendar"
+	"backend/internal/data"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Usage is the part of an approved request that falls in one calendar year.
+type Usage struct {
+	RequestID int
+	Year      int
+	Date      time.Time // first day of the request within Year
+	Days      float64
+}
+
+// HistoryYears bounds how far back a plan reaches: policy entries are
+// planned from the associate's start date, but no earlier than January 1 of
+// the year this many years before today, so a wrong or placeholder start
+// date cannot post decades of accruals.
+const HistoryYears = 3
+
+type Planner struct {
+	AssociateID int
+	StartDate   time.Time
+	LeaveType   data.LeaveType
+	Usage       []Usage
+	Today       time.Time
+}
+
+// Plan returns existing followed by every entry the policy implies up to
+// until, ordered by date. Entries that are not yet stored have ID 0. An
+// associate without a start date earns nothing until one is set.
+func (p *Planner) Plan(existing []data.PTOLedgerEntry, until time.Time) []data.PTOLedgerEntry {
+	until = calendar.Date(until)
+	entries := append([]data.PTOLedgerEntry{}, existing...)
+
+	keys := map[string]bool{}
+	for _, e := range existing {
+		if e.SourceKey != nil {
+			keys[*e.SourceKey] = true
+		}
+	}
+	add := func(e data.PTOLedgerEntry, key string) {
+		if key != "" {
+			if keys[key] {
+				return
+			}
+			keys[key] = true
+			e.SourceKey = &key
+		}
+		e.AssociateID = p.AssociateID
+		e.LeaveType = p.LeaveType.Code
+		entries = append(entries, e)
+	}
+
+	entries = append(entries, p.reconcileUsage(existing, until)...)
+
+	start := calendar.Date(p.StartDate)
+	first := start.Year()
+	if earliest := p.Today.Year() - HistoryYears; first < earliest {
+		first = earliest
+	}
+	planPolicy := !p.StartDate.IsZero() && !p.LeaveType.Unlimited()
+	for year := first; year <= until.Year() && planPolicy; year++ {
+		yearStart := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		if year > first {
+			p.carryOver(entries, year, add)
+		}
+
+		if p.LeaveType.AccrualMethod == "immediate" {
+			grantDate := yearStart
+			if start.After(grantDate) {
+				grantDate = start
+			}
+			if !grantDate.After(until) {
+				add(data.PTOLedgerEntry{
+					EntryType:     data.LedgerAccrual,
+					Days:          *p.LeaveType.DaysPerYear,
+					PeriodYear:    year,
+					EffectiveDate: grantDate,
+					Note:          fmt.Sprintf("%d allowance", year),
+				}, fmt.Sprintf("accrual:%d", year))
+			}
+		} else {
+			p.accrueMonthly(year, start, until, add)
+		}
+
+		p.expireCarryOver(entries, year, until, add)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].EffectiveDate.Before(entries[j].EffectiveDate)
+	})
+	return entries
+}
+
+// accrueMonthly earns a twelfth of the allowance at the end of each month,
+// prorated for the month the associate started in.
+func (p *Planner) accrueMonthly(year int, start, until time.Time, add func(data.PTOLedgerEntry, string)) {
+	for month := time.January; month <= time.December; month++ {
+		monthStart := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		monthEnd := monthStart.AddDate(0, 1, -1)
+		if monthEnd.Before(start) || monthEnd.After(until) {
+			continue
+		}
+
+		fraction := 1.0
+		if start.After(monthStart) {
+			fraction = float64(monthEnd.Day()-start.Day()+1) / float64(monthEnd.Day())
+		}
+
+		add(data.PTOLedgerEntry{
+			EntryType:     data.LedgerAccrual,
+			Days:          *p.LeaveType.DaysPerYear / 12 * fraction,
+			PeriodYear:    year,
+			EffectiveDate: monthEnd,
+			Note:          monthStart.Format("January 2006") + " accrual",
+		}, fmt.Sprintf("accrual:%d-%02d", year, month))
+	}
+}
+
+// carryOver closes the previous year: up to the cap moves into year, the
+// rest is forfeited. A negative balance is carried in full.
+func (p *Planner) carryOver(entries []data.PTOLedgerEntry, year int, add func(data.PTOLedgerEntry, string)) {
+	key := fmt.Sprintf("carry_over:%d", year)
+	for _, e := range entries {
+		if e.SourceKey != nil && *e.SourceKey == key {
+			return
+		}
+	}
+
+	yearStart := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	closing := periodTotal(entries, year-1, nil)
+
+	carried := closing
+	if carried > p.LeaveType.CarryOverCap {
+		carried = p.LeaveType.CarryOverCap
+	}
+
+	if forfeited := closing - carried; forfeited > 0 {
+		add(data.PTOLedgerEntry{
+			EntryType:     data.LedgerExpiry,
+			Days:          -forfeited,
+			PeriodYear:    year - 1,
+			EffectiveDate: yearStart,
+			Note:          fmt.Sprintf("Unused %d days above the carry-over cap", year-1),
+		}, fmt.Sprintf("expiry:%d", year-1))
+	}
+
+	carry := data.PTOLedgerEntry{
+		EntryType:     data.LedgerCarryOver,
+		Days:          carried,
+		PeriodYear:    year,
+		EffectiveDate: yearStart,
+		Note:          fmt.Sprintf("Carried over from %d", year-1),
+	}
+	if months := p.LeaveType.CarryOverExpiryMonths; months != nil && carried > 0 {
+		expires := yearStart.AddDate(0, *months, -1)
+		carry.ExpiresAt = &expires
+	}
+	add(carry, key)
+}
+
+// expireCarryOver removes the carried days that were not used by their
+// expiry date. Usage draws on carried days first.
+func (p *Planner) expireCarryOver(entries []data.PTOLedgerEntry, year int, until time.Time, add func(data.PTOLedgerEntry, string)) {
+	var carry *data.PTOLedgerEntry
+	for i := range entries {
+		e := &entries[i]
+		if e.EntryType == data.LedgerCarryOver && e.PeriodYear == year && e.ExpiresAt != nil {
+			carry = e
+		}
+	}
+	if carry == nil || carry.Days <= 0 {
+		return
+	}
+
+	expiresOn := carry.ExpiresAt.AddDate(0, 0, 1)
+	if expiresOn.After(until) {
+		return
+	}
+
+	used := -periodTotal(entries, year, func(e data.PTOLedgerEntry) bool {
+		return e.EntryType == data.LedgerUsage && !e.EffectiveDate.After(*carry.ExpiresAt)
+	})
+	if unused := carry.Days - used; unused > 0 {
+		add(data.PTOLedgerEntry{
+			EntryType:     data.LedgerExpiry,
+			Days:          -unused,
+			PeriodYear:    year,
+			EffectiveDate: expiresOn,
+			Note:          fmt.Sprintf("Carried-over days not used by %s", carry.ExpiresAt.Format("2006-01-02")),
+		}, fmt.Sprintf("expiry:%d:carry_over", year))
+	}
+}
+
+// reconcileUsage posts the difference between each request's cost and the
+// usage already in the ledger for it. Corrections to usage already posted are
+// dated today so past balances stay as they were reported.
+func (p *Planner) reconcileUsage(existing []data.PTOLedgerEntry, until time.Time) []data.PTOLedgerEntry {
+	type requestYear struct{ requestID, year int }
+
+	posted := map[requestYear]float64{}
+	postedAny := map[requestYear]bool{}
+	for _, e := range existing {
+		if e.EntryType != data.LedgerUsage || e.TimeOffRequestID == nil {
+			continue
+		}
+		k := requestYear{*e.TimeOffRequestID, e.PeriodYear}
+		posted[k] += e.Days
+		postedAny[k] = true
+	}
+
+	wanted := map[requestYear]Usage{}
+	for _, u := range p.Usage {
+		wanted[requestYear{u.RequestID, u.Year}] = u
+	}
+	for k := range posted {
+		if _, ok := wanted[k]; !ok {
+			wanted[k] = Usage{RequestID: k.requestID, Year: k.year}
+		}
+	}
+
+	var entries []data.PTOLedgerEntry
+	for k, u := range wanted {
+		delta := -u.Days - posted[k]
+		if delta > -0.0001 && delta < 0.0001 {
+			continue
+		}
+
+		date := calendar.Date(u.Date)
+		note := "Time off"
+		if postedAny[k] {
+			note = "Time off correction"
+			if date.Before(p.Today) {
+				date = p.Today
+			}
+		}
+		if date.After(until) {
+			continue
+		}
+
+		requestID := k.requestID
+		entries = append(entries, data.PTOLedgerEntry{
+			AssociateID:      p.AssociateID,
+			LeaveType:        p.LeaveType.Code,
+			EntryType:        data.LedgerUsage,
+			Days:             delta,
+			PeriodYear:       k.year,
+			EffectiveDate:    date,
+			TimeOffRequestID: &requestID,
+			Note:             note,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].EffectiveDate.Before(entries[j].EffectiveDate)
+	})
+	return entries
+}
+
+func periodTotal(entries []data.PTOLedgerEntry, year int, include func(data.PTOLedgerEntry) bool) float64 {
+	var total float64
+	for _, e := range entries {
+		if e.PeriodYear == year && (include == nil || include(e)) {
+			total += e.Days
+		}
+	}
+	return total
+}
+
+// Due returns the planned entries that are not stored yet and whose date has
+// arrived. Future entries stay projections until their day comes.
+func Due(entries []data.PTOLedgerEntry, today time.Time) []data.PTOLedgerEntry {
+	var due []data.PTOLedgerEntry
+	for _, e := range entries {
+		if e.ID == 0 && !e.EffectiveDate.After(today) {
+			due = append(due, e)
+		}
+	}
+	return due
+}
+
+// Summary breaks down one year's balance as of a date.
+type Summary struct {
+	Year        int
+	Accrued     float64
+	CarriedOver float64
+	Adjusted    float64
+	Used        float64 // taken on or before the date
+	Expired     float64
+	Balance     float64
+	Scheduled   float64 // approved usage still ahead in the year
+}
+
+// Summarize reads the balance of year as of asOf from a planned ledger.
+func Summarize(entries []data.PTOLedgerEntry, year int, asOf time.Time) Summary {
+	s := Summary{Year: year}
+	for _, e := range entries {
+		if e.PeriodYear != year {
+			continue
+		}
+		if e.EffectiveDate.After(asOf) {
+			if e.EntryType == data.LedgerUsage {
+				s.Scheduled -= e.Days
+			}
+			continue
+		}
+
+		switch e.EntryType {
+		case data.LedgerAccrual:
+			s.Accrued += e.Days
+		case data.LedgerCarryOver:
+			s.CarriedOver += e.Days
+		case data.LedgerAdjustment:
+			s.Adjusted += e.Days
+		case data.LedgerUsage:
+			s.Used -= e.Days
+		case data.LedgerExpiry:
+			s.Expired -= e.Days
+		}
+		s.Balance += e.Days
+	}
+	return s
+}
@@ -0,0 +1,259 @@
+package pto
+
+import (
+	"backend/internal/data"
+	"math"
+	"testing"
+	"time"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func leaveType(daysPerYear *float64, method string, carryOverCap float64, expiryMonths *int) data.LeaveType {
+	return data.LeaveType{Code: "PTO", DaysPerYear: daysPerYear, AccrualMethod: method, CarryOverCap: carryOverCap, CarryOverExpiryMonths: expiryMonths}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func sameSummary(a, b Summary) bool {
+	near := func(x, y float64) bool { return math.Abs(x-y) < 1e-9 }
+	return a.Year == b.Year && near(a.Accrued, b.Accrued) && near(a.CarriedOver, b.CarriedOver) && near(a.Adjusted, b.Adjusted) &&
+		near(a.Used, b.Used) && near(a.Expired, b.Expired) && near(a.Balance, b.Balance) && near(a.Scheduled, b.Scheduled)
+}
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		planner Planner
+		until   time.Time
+		asOf    time.Time
+		want    Summary
+	}{
+		{
+			name: "immediate allowance",
+			planner: Planner{
+				StartDate: day(2024, time.January, 1),
+				LeaveType: leaveType(ptr(20.0), "immediate", 5, nil),
+				Today:     day(2024, time.June, 15),
+			},
+			until: day(2024, time.December, 31),
+			asOf:  day(2024, time.June, 15),
+			want:  Summary{Year: 2024, Accrued: 20, Balance: 20},
+		},
+		{
+			name: "immediate allowance from a mid-year start date",
+			planner: Planner{
+				StartDate: day(2024, time.April, 8),
+				LeaveType: leaveType(ptr(20.0), "immediate", 5, nil),
+				Today:     day(2024, time.June, 15),
+			},
+			until: day(2024, time.December, 31),
+			asOf:  day(2024, time.April, 7),
+			want:  Summary{Year: 2024},
+		},
+		{
+			name: "monthly accrual prorated in the start month",
+			planner: Planner{
+				StartDate: day(2025, time.March, 16),
+				LeaveType: leaveType(ptr(12.0), "monthly", 5, nil),
+				Today:     day(2025, time.June, 15),
+			},
+			until: day(2025, time.June, 30),
+			asOf:  day(2025, time.June, 30),
+			want:  Summary{Year: 2025, Accrued: 3 + 16.0/31, Balance: 3 + 16.0/31},
+		},
+		{
+			name: "monthly accrual stops at until",
+			planner: Planner{
+				StartDate: day(2025, time.January, 1),
+				LeaveType: leaveType(ptr(12.0), "monthly", 5, nil),
+				Today:     day(2025, time.June, 15),
+			},
+			until: day(2025, time.June, 15),
+			asOf:  day(2025, time.December, 31),
+			want:  Summary{Year: 2025, Accrued: 5, Balance: 5},
+		},
+		{
+			name: "carry-over up to the cap",
+			planner: Planner{
+				StartDate: day(2024, time.January, 1),
+				LeaveType: leaveType(ptr(20.0), "immediate", 5, nil),
+				Today:     day(2025, time.June, 15),
+			},
+			until: day(2025, time.December, 31),
+			asOf:  day(2025, time.June, 15),
+			want:  Summary{Year: 2025, Accrued: 20, CarriedOver: 5, Balance: 25},
+		},
+		{
+			name: "days above the cap expire at year end",
+			planner: Planner{
+				StartDate: day(2024, time.January, 1),
+				LeaveType: leaveType(ptr(20.0), "immediate", 5, nil),
+				Today:     day(2025, time.June, 15),
+			},
+			until: day(2025, time.December, 31),
+			asOf:  day(2025, time.January, 1),
+			want:  Summary{Year: 2024, Accrued: 20, Expired: 15, Balance: 5},
+		},
+		{
+			name: "negative balance carried in full",
+			planner: Planner{
+				StartDate: day(2024, time.January, 1),
+				LeaveType: leaveType(ptr(10.0), "immediate", 5, ptr(3)),
+				Usage:     []Usage{{RequestID: 1, Year: 2024, Date: day(2024, time.August, 5), Days: 12}},
+				Today:     day(2025, time.June, 15),
+			},
+			until: day(2025, time.December, 31),
+			asOf:  day(2025, time.December, 31),
+			want:  Summary{Year: 2025, Accrued: 10, CarriedOver: -2, Balance: 8},
+		},
+		{
+			name: "carried days unused by their expiry date",
+			planner: Planner{
+				StartDate: day(2024, time.January, 1),
+				LeaveType: leaveType(ptr(10.0), "immediate", 5, ptr(3)),
+				Usage: []Usage{
+					{RequestID: 1, Year: 2025, Date: day(2025, time.February, 10), Days: 2},
+					{RequestID: 2, Year: 2025, Date: day(2025, time.May, 5), Days: 1},
+				},
+				Today: day(2025, time.June, 15),
+			},
+			until: day(2025, time.December, 31),
+			asOf:  day(2025, time.June, 15),
+			want:  Summary{Year: 2025, Accrued: 10, CarriedOver: 5, Used: 3, Expired: 3, Balance: 9},
+		},
+		{
+			name: "carried days before their expiry date",
+			planner: Planner{
+				StartDate: day(2024, time.January, 1),
+				LeaveType: leaveType(ptr(10.0), "immediate", 5, ptr(3)),
+				Usage:     []Usage{{RequestID: 1, Year: 2025, Date: day(2025, time.February, 10), Days: 2}},
+				Today:     day(2025, time.June, 15),
+			},
+			until: day(2025, time.December, 31),
+			asOf:  day(2025, time.March, 31),
+			want:  Summary{Year: 2025, Accrued: 10, CarriedOver: 5, Used: 2, Balance: 13},
+		},
+		{
+			name: "scheduled usage",
+			planner: Planner{
+				StartDate: day(2025, time.January, 1),
+				LeaveType: leaveType(ptr(20.0), "immediate", 5, nil),
+				Usage:     []Usage{{RequestID: 1, Year: 2025, Date: day(2025, time.September, 1), Days: 4}},
+				Today:     day(2025, time.June, 15),
+			},
+			until: day(2025, time.December, 31),
+			asOf:  day(2025, time.June, 15),
+			want:  Summary{Year: 2025, Accrued: 20, Balance: 20, Scheduled: 4},
+		},
+		{
+			name: "no start date earns nothing",
+			planner: Planner{
+				LeaveType: leaveType(ptr(20.0), "immediate", 5, nil),
+				Usage:     []Usage{{RequestID: 1, Year: 2025, Date: day(2025, time.March, 3), Days: 1}},
+				Today:     day(2025, time.June, 15),
+			},
+			until: day(2025, time.December, 31),
+			asOf:  day(2025, time.June, 15),
+			want:  Summary{Year: 2025, Used: 1, Balance: -1},
+		},
+		{
+			name: "unlimited leave only records usage",
+			planner: Planner{
+				StartDate: day(2024, time.January, 1),
+				LeaveType: leaveType(nil, "immediate", 0, nil),
+				Usage:     []Usage{{RequestID: 1, Year: 2025, Date: day(2025, time.March, 3), Days: 3}},
+				Today:     day(2025, time.June, 15),
+			},
+			until: day(2025, time.December, 31),
+			asOf:  day(2025, time.June, 15),
+			want:  Summary{Year: 2025, Used: 3, Balance: -3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := tt.planner.Plan(nil, tt.until)
+			if got := Summarize(entries, tt.want.Year, tt.asOf); !sameSummary(got, tt.want) {
+				t.Errorf("Summarize(%d) = %+v, want %+v", tt.want.Year, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanHistoryBound(t *testing.T) {
+	p := Planner{
+		StartDate: day(1900, time.January, 1),
+		LeaveType: leaveType(ptr(20.0), "immediate", 5, nil),
+		Today:     day(2025, time.June, 15),
+	}
+
+	entries := p.Plan(nil, day(2025, time.December, 31))
+	first := p.Today.Year() - HistoryYears
+	for _, e := range entries {
+		if e.PeriodYear < first {
+			t.Fatalf("planned %s for %d, before %d", e.EntryType, e.PeriodYear, first)
+		}
+	}
+	if got := Summarize(entries, first, day(first, time.December, 31)); !sameSummary(got, Summary{Year: first, Accrued: 20, Balance: 20}) {
+		t.Errorf("Summarize(%d) = %+v, want a plain first-year allowance", first, got)
+	}
+}
+
+func TestPlanIsIdempotent(t *testing.T) {
+	p := Planner{
+		StartDate: day(2024, time.March, 16),
+		LeaveType: leaveType(ptr(12.0), "monthly", 5, ptr(3)),
+		Usage:     []Usage{{RequestID: 1, Year: 2025, Date: day(2025, time.February, 10), Days: 2}},
+		Today:     day(2025, time.June, 15),
+	}
+
+	stored := Due(p.Plan(nil, p.Today), p.Today)
+	if len(stored) == 0 {
+		t.Fatal("first plan posted nothing")
+	}
+	for i := range stored {
+		stored[i].ID = i + 1
+	}
+
+	if again := Due(p.Plan(stored, p.Today), p.Today); len(again) != 0 {
+		t.Errorf("second plan posted %d more entries: %+v", len(again), again)
+	}
+}
+
+func TestPlanUsageCorrection(t *testing.T) {
+	p := Planner{
+		StartDate: day(2025, time.January, 1),
+		LeaveType: leaveType(ptr(20.0), "immediate", 5, nil),
+		Usage:     []Usage{{RequestID: 1, Year: 2025, Date: day(2025, time.March, 3), Days: 1}},
+		Today:     day(2025, time.June, 15),
+	}
+	existing := []data.PTOLedgerEntry{{
+		ID:               1,
+		AssociateID:      p.AssociateID,
+		LeaveType:        "PTO",
+		EntryType:        data.LedgerUsage,
+		Days:             -3,
+		PeriodYear:       2025,
+		EffectiveDate:    day(2025, time.March, 3),
+		TimeOffRequestID: ptr(1),
+	}}
+
+	var corrections []data.PTOLedgerEntry
+	for _, e := range p.Plan(existing, p.Today) {
+		if e.ID == 0 && e.EntryType == data.LedgerUsage {
+			corrections = append(corrections, e)
+		}
+	}
+
+	if len(corrections) != 1 {
+		t.Fatalf("got %d usage corrections, want 1", len(corrections))
+	}
+	if c := corrections[0]; c.Days != 2 || !c.EffectiveDate.Equal(p.Today) {
+		t.Errorf("correction = %v days on %s, want 2 days on %s", c.Days, c.EffectiveDate.Format("2006-01-02"), p.Today.Format("2006-01-02"))
+	}
+}
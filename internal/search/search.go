@@ -0,0 +1,335 @@
+// Package search ranks associates for the directory search: typo-tolerant
+// matching across a few fields, and a prefix mode for typeahead.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Field is a searchable part of a document.
+type Field int
+
+const (
+	FieldName Field = iota
+	FieldTitle
+	FieldDepartment
+	FieldOffice
+	FieldEmail
+	FieldPhone
+	numFields
+)
+
+// FieldNames are the names results report matches under.
+var FieldNames = [numFields]string{"name", "title", "department", "office", "email", "phone"}
+
+// fieldWeights rank a match by where it was found.
+var fieldWeights = [numFields]float64{3, 1.5, 1, 1, 2, 2}
+
+// How well a query term matches a document token
+const (
+	exactMatch  = 1.0
+	prefixMatch = 0.7
+	typoMatch   = 0.5
+)
+
+// Document is one associate as the index sees it.
+type Document struct {
+	ID     int
+	Fields [numFields]string
+}
+
+// Result is a matching document, best first.
+type Result struct {
+	ID      int
+	Score   float64
+	Matched []string
+}
+
+type posting struct {
+	doc   int // index into Index.docs
+	field Field
+}
+
+// Index is an immutable inverted index over documents.
+type Index struct {
+	docs []Document
+	// vocabulary is every token, sorted, for prefix lookups by binary search
+	vocabulary []string
+	postings   map[string][]posting
+	// byLength groups the vocabulary by rune count for typo lookups
+	byLength map[int][]string
+}
+
+// NewIndex builds an index over docs.
+func NewIndex(docs []Document) *Index {
+	idx := &Index{
+		docs:     docs,
+		postings: map[string][]posting{},
+		byLength: map[int][]string{},
+	}
+
+	for i, d := range docs {
+		for f := Field(0); f < numFields; f++ {
+			for _, token := range tokenize(d.Fields[f], f) {
+				idx.postings[token] = append(idx.postings[token], posting{doc: i, field: f})
+			}
+		}
+	}
+
+	for token := range idx.postings {
+		idx.vocabulary = append(idx.vocabulary, token)
+		n := len([]rune(token))
+		idx.byLength[n] = append(idx.byLength[n], token)
+	}
+	sort.Strings(idx.vocabulary)
+	return idx
+}
+
+// Len is the number of documents in the index.
+func (idx *Index) Len() int {
+	return len(idx.docs)
+}
+
+// Options narrow a search.
+type Options struct {
+	// Fields to search; none means every field
+	Fields []Field
+	// Prefix matches the last term as a prefix only, for typeahead
+	Prefix bool
+	Limit  int
+}
+
+// Search ranks the documents matching every term of query. Terms match a
+// token exactly, as its prefix, or, outside prefix mode, within one typo
+// (two for long terms).
+func (idx *Index) Search(query string, opts Options) []Result {
+	terms := tokenize(query, FieldName)
+	if len(terms) == 0 {
+		return []Result{}
+	}
+
+	allowed := [numFields]bool{}
+	if len(opts.Fields) == 0 {
+		for f := range allowed {
+			allowed[f] = true
+		}
+	}
+	for _, f := range opts.Fields {
+		allowed[f] = true
+	}
+
+	// Digits-only queries are phone numbers, however they are punctuated
+	if digits := onlyDigits(query); len(digits) >= 4 && len(digits) == len(strings.Join(terms, "")) {
+		terms = []string{digits}
+	}
+
+	type hit struct {
+		score   float64
+		terms   int
+		matched map[Field]bool
+	}
+	hits := map[int]*hit{}
+
+	for i, term := range terms {
+		// best score per document for this term
+		best := map[int]float64{}
+		fields := map[int][]Field{}
+		last := i == len(terms)-1
+
+		for token, quality := range idx.candidates(term, opts.Prefix && last, opts.Prefix) {
+			for _, p := range idx.postings[token] {
+				if !allowed[p.field] {
+					continue
+				}
+				score := quality * fieldWeights[p.field]
+				if score > best[p.doc] {
+					best[p.doc] = score
+				}
+				fields[p.doc] = append(fields[p.doc], p.field)
+			}
+		}
+
+		for doc, score := range best {
+			h := hits[doc]
+			if h == nil {
+				h = &hit{matched: map[Field]bool{}}
+				hits[doc] = h
+			}
+			h.score += score
+			h.terms++
+			for _, f := range fields[doc] {
+				h.matched[f] = true
+			}
+		}
+	}
+
+	results := []Result{}
+	for doc, h := range hits {
+		if h.terms < len(terms) {
+			continue
+		}
+		r := Result{ID: idx.docs[doc].ID, Score: h.score}
+		for f := Field(0); f < numFields; f++ {
+			if h.matched[f] {
+				r.Matched = append(r.Matched, FieldNames[f])
+			}
+		}
+		results = append(results, r)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+	return results
+}
+
+// candidates returns the tokens term matches, with how well. Prefix-only
+// terms match tokens starting with them; typo tolerance is off in prefix
+// mode, which keeps typeahead to binary searches.
+func (idx *Index) candidates(term string, prefixOnly, noTypos bool) map[string]float64 {
+	matches := map[string]float64{}
+
+	start := sort.SearchStrings(idx.vocabulary, term)
+	for i := start; i < len(idx.vocabulary) && strings.HasPrefix(idx.vocabulary[i], term); i++ {
+		token := idx.vocabulary[i]
+		if token == term {
+			matches[token] = exactMatch
+		} else {
+			matches[token] = prefixMatch
+		}
+	}
+	if prefixOnly || noTypos {
+		return matches
+	}
+
+	maxEdits := allowedTypos(term)
+	if maxEdits == 0 {
+		return matches
+	}
+	n := len([]rune(term))
+	for length := n - maxEdits; length <= n+maxEdits; length++ {
+		for _, token := range idx.byLength[length] {
+			if _, ok := matches[token]; ok {
+				continue
+			}
+			if withinDistance(term, token, maxEdits) {
+				matches[token] = typoMatch
+			}
+		}
+	}
+	return matches
+}
+
+// allowedTypos is how many edits a term may be off by: none for short
+// terms, where a typo is as likely to be another word.
+func allowedTypos(term string) int {
+	switch n := len([]rune(term)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// withinDistance reports whether a and b are at most max edits apart,
+// counting an adjacent transposition as one edit.
+func withinDistance(a, b string, max int) bool {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > max || -d > max {
+		return false
+	}
+
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > max {
+			return false
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)] <= max
+}
+
+// tokenize lower-cases text and splits it into words. Phone numbers become
+// their digits and every tail of four digits or more, so any part from the
+// area code on finds them; email addresses also index their local part
+// whole.
+func tokenize(text string, field Field) []string {
+	if field == FieldPhone {
+		digits := onlyDigits(text)
+		var tokens []string
+		for i := 0; i <= len(digits)-4; i++ {
+			tokens = append(tokens, digits[i:])
+		}
+		return tokens
+	}
+
+	text = strings.ToLower(foldAccents(text))
+	tokens := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if field == FieldEmail {
+		if at := strings.IndexByte(text, '@'); at > 0 {
+			tokens = append(tokens, text[:at])
+		}
+	}
+	return tokens
+}
+
+func onlyDigits(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// accents folds the accented Latin letters common in names.
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a", "å", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o", "ø", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ñ", "n", "ç", "c", "ß", "ss",
+	"Á", "A", "À", "A", "Â", "A", "Ä", "A", "Ã", "A", "Å", "A",
+	"É", "E", "È", "E", "Ê", "E", "Ë", "E",
+	"Í", "I", "Ì", "I", "Î", "I", "Ï", "I",
+	"Ó", "O", "Ò", "O", "Ô", "O", "Ö", "O", "Õ", "O", "Ø", "O",
+	"Ú", "U", "Ù", "U", "Û", "U", "Ü", "U",
+	"Ñ", "N", "Ç", "C",
+)
+
+func foldAccents(text string) string {
+	return accents.Replace(text)
+}
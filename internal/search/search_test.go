@@ -0,0 +1,142 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWithinDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		want bool
+	}{
+		{"smith", "smith", 0, true},
+		{"smith", "smyth", 1, true},
+		{"smith", "smiths", 1, true},
+		{"smith", "mith", 1, true},
+		{"smith", "smtih", 1, true},
+		{"ca", "ac", 1, true},
+		{"smith", "smoth", 0, false},
+		{"kitten", "sitting", 2, false},
+		{"kitten", "sitting", 3, true},
+		{"johnson", "jonhsno", 2, true},
+		{"johnson", "jonhsno", 1, false},
+		{"abcdef", "ab", 2, false},
+		{"müller", "muller", 1, true},
+	}
+
+	for _, tt := range tests {
+		if got := withinDistance(tt.a, tt.b, tt.max); got != tt.want {
+			t.Errorf("withinDistance(%q, %q, %d) = %v, want %v", tt.a, tt.b, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestAllowedTypos(t *testing.T) {
+	tests := []struct {
+		term string
+		want int
+	}{
+		{"ann", 0},
+		{"anna", 1},
+		{"jonathan", 2},
+		{"élise", 1},
+	}
+
+	for _, tt := range tests {
+		if got := allowedTypos(tt.term); got != tt.want {
+			t.Errorf("allowedTypos(%q) = %d, want %d", tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		field Field
+		want  []string
+	}{
+		{"phone tails", "+1 (555) 123-4567", FieldPhone, []string{"15551234567", "5551234567", "551234567", "51234567", "1234567", "234567", "34567", "4567"}},
+		{"short phone", "123", FieldPhone, nil},
+		{"accents folded", "Zoë Núñez", FieldName, []string{"zoe", "nunez"}},
+		{"email local part", "mary.jones@example.com", FieldEmail, []string{"mary", "jones", "example", "com", "mary.jones"}},
+		{"punctuation split", "Sales & Marketing", FieldDepartment, []string{"sales", "marketing"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenize(tt.text, tt.field); !slices.Equal(got, tt.want) {
+				t.Errorf("tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func testIndex() *Index {
+	doc := func(id int, name, title, department, office, email, phone string) Document {
+		return Document{ID: id, Fields: [numFields]string{name, title, department, office, email, phone}}
+	}
+	return NewIndex([]Document{
+		doc(1, "John Smith", "Engineer", "Engineering", "New York", "john.smith@example.com", "+1 555 123 4567"),
+		doc(2, "Jane Smithers", "Designer", "Product", "London", "jane.smithers@example.com", "+44 20 7946 0018"),
+		doc(3, "Zoë Núñez", "Account Manager", "Sales", "Paris", "zoe.nunez@example.com", "+33 1 98 76 54 32"),
+		doc(4, "Mark Johnson", "Smith", "Facilities", "New York", "mark.johnson@example.com", ""),
+	})
+}
+
+func resultIDs(results []Result) []int {
+	ids := []int{}
+	for _, r := range results {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	idx := testIndex()
+
+	tests := []struct {
+		name  string
+		query string
+		opts  Options
+		want  []int
+	}{
+		{"name before title", "smith", Options{}, []int{1, 2, 4}},
+		{"typo", "smtih", Options{}, []int{1, 4}},
+		{"transposition", "jonhson", Options{}, []int{4}},
+		{"two typos in a long term", "smtihres", Options{}, []int{2}},
+		{"short terms allow no typo", "jon", Options{}, []int{}},
+		{"every term must match", "jane smith", Options{}, []int{2}},
+		{"accents ignored", "nunez", Options{}, []int{3}},
+		{"field filter", "smith", Options{Fields: []Field{FieldTitle}}, []int{4}},
+		{"limit", "smith", Options{Limit: 1}, []int{1}},
+		{"phone tail", "123-4567", Options{}, []int{1}},
+		{"phone from area code", "(20) 7946 0018", Options{}, []int{2}},
+		{"short phone fragment", "4567", Options{}, []int{1}},
+		{"prefix", "smi", Options{Prefix: true}, []int{1, 2, 4}},
+		{"prefix with earlier terms", "john smi", Options{Prefix: true}, []int{1, 4}},
+		{"no typos in earlier terms", "jhon smi", Options{Prefix: true}, []int{}},
+		{"no typos in prefix mode", "smtih", Options{Prefix: true}, []int{}},
+		{"empty query", " - ", Options{}, []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resultIDs(idx.Search(tt.query, tt.opts)); !slices.Equal(got, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchMatchedFields(t *testing.T) {
+	results := testIndex().Search("smith", Options{})
+	if len(results) == 0 || results[0].ID != 1 {
+		t.Fatalf("Search(smith) = %v, want associate 1 first", results)
+	}
+	if want := []string{"name", "email"}; !slices.Equal(results[0].Matched, want) {
+		t.Errorf("Matched = %q, want %q", results[0].Matched, want)
+	}
+}
@@ -0,0 +1,267 @@
+// Package xlsx reads the first worksheet of Office Open XML (.xlsx)
+// spreadsheets as rows of text, for associate imports, and streams
+// single-sheet workbooks, for exports.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotSpreadsheet is returned for files that are not .xlsx workbooks.
+var ErrNotSpreadsheet = errors.New("the file is not an .xlsx spreadsheet")
+
+// maxPartSize bounds how far one part of the workbook may decompress, so a
+// small upload cannot expand without limit.
+const maxPartSize = 64 << 20
+
+// The size of an Excel worksheet
+const (
+	maxRows    = 1 << 20
+	maxColumns = 1 << 14
+)
+
+// ReadRows returns the cells of the first worksheet as text, one slice per
+// row. Row i of the result is spreadsheet row i+1: rows the sheet skips are
+// empty. Numbers, dates included, are returned as Excel stores them, so a
+// date is its serial day number; see SerialDate.
+func ReadRows(content []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, ErrNotSpreadsheet
+	}
+	files := map[string]*zip.File{}
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	sheet, err := firstSheet(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if shared, err = readSharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+
+	return readSheet(files[sheet], shared)
+}
+
+// firstSheet finds the part holding the workbook's first worksheet.
+func firstSheet(files map[string]*zip.File) (string, error) {
+	var workbook struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	if err := decodePart(files, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", errors.New("the spreadsheet has no worksheets")
+	}
+	if err := decodePart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].ID {
+			continue
+		}
+		// Targets are relative to xl/, or absolute within the package
+		name := path.Join("xl", rel.Target)
+		if strings.HasPrefix(rel.Target, "/") {
+			name = strings.TrimPrefix(rel.Target, "/")
+		}
+		if _, ok := files[name]; ok {
+			return name, nil
+		}
+	}
+	return "", ErrNotSpreadsheet
+}
+
+func decodePart(files map[string]*zip.File, name string, v any) error {
+	f, ok := files[name]
+	if !ok {
+		return ErrNotSpreadsheet
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return ErrNotSpreadsheet
+	}
+	return nil
+}
+
+// richText is a string item or inline string: plain text, or runs of
+// formatted text to join.
+type richText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t richText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.Text)
+	}
+	return b.String()
+}
+
+func readSharedStrings(f *zip.File) ([]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var strs []string
+	decoder := xml.NewDecoder(io.LimitReader(rc, maxPartSize))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return strs, nil
+		}
+		if err != nil {
+			return nil, ErrNotSpreadsheet
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "si" {
+			var item richText
+			if err := decoder.DecodeElement(&item, &start); err != nil {
+				return nil, ErrNotSpreadsheet
+			}
+			strs = append(strs, item.String())
+		}
+	}
+}
+
+type cell struct {
+	Ref    string   `xml:"r,attr"`
+	Type   string   `xml:"t,attr"`
+	Value  string   `xml:"v"`
+	Inline richText `xml:"is"`
+}
+
+// readSheet streams the worksheet's rows, so a large sheet is not held as a
+// document tree.
+func readSheet(f *zip.File, shared []string) ([][]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var rows [][]string
+	decoder := xml.NewDecoder(io.LimitReader(rc, maxPartSize))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, ErrNotSpreadsheet
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var row struct {
+			Number int    `xml:"r,attr"`
+			Cells  []cell `xml:"c"`
+		}
+		if err := decoder.DecodeElement(&row, &start); err != nil {
+			return nil, ErrNotSpreadsheet
+		}
+		if row.Number == 0 {
+			row.Number = len(rows) + 1
+		}
+		if row.Number < 0 || row.Number > maxRows {
+			return nil, ErrNotSpreadsheet
+		}
+		for len(rows) < row.Number {
+			rows = append(rows, nil)
+		}
+
+		var values []string
+		for _, c := range row.Cells {
+			col := len(values)
+			if c.Ref != "" {
+				if col, ok = columnIndex(c.Ref); !ok || col >= maxColumns {
+					return nil, ErrNotSpreadsheet
+				}
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+			values[col] = c.text(shared)
+		}
+		rows[row.Number-1] = values
+	}
+}
+
+func (c cell) text(shared []string) string {
+	switch c.Type {
+	case "s":
+		i, err := strconv.Atoi(c.Value)
+		if err != nil || i < 0 || i >= len(shared) {
+			return ""
+		}
+		return shared[i]
+	case "inlineStr":
+		return c.Inline.String()
+	case "b":
+		if c.Value == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	default:
+		return c.Value
+	}
+}
+
+// columnIndex reads the zero-based column of a cell reference such as "AB12".
+func columnIndex(ref string) (int, bool) {
+	col := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A'+1)
+	}
+	return col - 1, i > 0
+}
+
+// SerialDate reads a date stored as an Excel serial day number, counted
+// from 1899-12-30. Any time of day is dropped.
+func SerialDate(value string) (time.Time, bool) {
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil || serial < 1 || serial > 2958465 {
+		return time.Time{}, false
+	}
+	return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(serial)), true
+}
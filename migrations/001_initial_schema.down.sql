@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS AppSettings;
+DROP TABLE IF EXISTS DocumentCategories;
+DROP TABLE IF EXISTS thanks_categories;
+DROP TABLE IF EXISTS menu_permissions;
+DROP TABLE IF EXISTS time_entries;
+DROP TABLE IF EXISTS time_off_requests;
+DROP TABLE IF EXISTS thanks_comments;
+DROP TABLE IF EXISTS thanks_likes;
+DROP TABLE IF EXISTS Thanks;
+DROP TABLE IF EXISTS Tasks;
+DROP TABLE IF EXISTS Associates;
+DROP TABLE IF EXISTS Departments;
+DROP TABLE IF EXISTS Offices;
@@ -0,0 +1,154 @@
+-- Baseline schema shared by every environment
+CREATE TABLE IF NOT EXISTS Offices (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS Departments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS Associates (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    first_name VARCHAR(255) NOT NULL,
+    last_name VARCHAR(255) NOT NULL,
+    title VARCHAR(255),
+    department VARCHAR(255),
+    office VARCHAR(255),
+    status VARCHAR(50),
+    start_date DATETIME,
+    empl_status VARCHAR(50),
+    salary INT,
+    dob DATETIME,
+    profile_picture VARCHAR(255),
+    email VARCHAR(255) UNIQUE,
+    password VARCHAR(255),
+    phone_number VARCHAR(50),
+    gender VARCHAR(50),
+    private_email VARCHAR(255),
+    manager_id INT,
+    FOREIGN KEY (manager_id) REFERENCES Associates(id)
+);
+
+CREATE TABLE IF NOT EXISTS Tasks (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    requester_id INT NOT NULL,
+    task_name VARCHAR(255) NOT NULL,
+    task_value VARCHAR(255) NOT NULL,
+    reason TEXT,
+    status VARCHAR(50) DEFAULT 'pending',
+    target_value INT,
+    approvers JSON,
+    timestamp INT,
+    comments TEXT
+);
+
+CREATE TABLE IF NOT EXISTS Thanks (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    from_id INT,
+    to_id INT,
+    message TEXT,
+    category VARCHAR(255),
+    timestamp BIGINT,
+    FOREIGN KEY (from_id) REFERENCES Associates(id),
+    FOREIGN KEY (to_id) REFERENCES Associates(id)
+);
+
+CREATE TABLE IF NOT EXISTS thanks_likes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    thank_id INT NOT NULL,
+    associate_id INT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY unique_like (thank_id, associate_id),
+    FOREIGN KEY (thank_id) REFERENCES Thanks(id) ON DELETE CASCADE,
+    FOREIGN KEY (associate_id) REFERENCES Associates(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS thanks_comments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    thank_id INT NOT NULL,
+    associate_id INT NOT NULL,
+    comment TEXT NOT NULL,
+    timestamp BIGINT NOT NULL,
+    FOREIGN KEY (thank_id) REFERENCES Thanks(id) ON DELETE CASCADE,
+    FOREIGN KEY (associate_id) REFERENCES Associates(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS time_off_requests (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    associate_id INT NOT NULL,
+    start_date DATETIME NOT NULL,
+    end_date DATETIME NOT NULL,
+    reason TEXT,
+    approver_id INT,
+    status VARCHAR(50) DEFAULT 'Pending',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (associate_id) REFERENCES Associates(id),
+    FOREIGN KEY (approver_id) REFERENCES Associates(id)
+);
+
+CREATE TABLE IF NOT EXISTS time_entries (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    associate_id INT NOT NULL,
+    date DATETIME NOT NULL,
+    hours DECIMAL(5, 2) NOT NULL,
+    overtime_hours DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    comments TEXT,
+    status VARCHAR(50) DEFAULT 'Approved',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (associate_id) REFERENCES Associates(id)
+);
+
+CREATE TABLE IF NOT EXISTS menu_permissions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    menu_item VARCHAR(100) NOT NULL,
+    permission_type VARCHAR(50) NOT NULL,
+    permission_value VARCHAR(100),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY unique_permission (menu_item, permission_type, permission_value)
+);
+
+CREATE TABLE IF NOT EXISTS thanks_categories (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS DocumentCategories (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS AppSettings (
+    setting_key VARCHAR(255) PRIMARY KEY,
+    setting_value TEXT
+);
+
+-- Databases created by the old in-process migration lack these columns
+SET @stmt = IF(
+    (SELECT COUNT(*) FROM information_schema.columns
+     WHERE table_schema = DATABASE() AND table_name = 'time_off_requests' AND column_name = 'approver_id') = 0,
+    'ALTER TABLE time_off_requests ADD COLUMN approver_id INT NULL AFTER reason, ADD FOREIGN KEY (approver_id) REFERENCES Associates(id)',
+    'SELECT 1');
+PREPARE migration_stmt FROM @stmt;
+EXECUTE migration_stmt;
+DEALLOCATE PREPARE migration_stmt;
+
+SET @stmt = IF(
+    (SELECT COUNT(*) FROM information_schema.columns
+     WHERE table_schema = DATABASE() AND table_name = 'time_entries' AND column_name = 'status') = 0,
+    'ALTER TABLE time_entries ADD COLUMN status VARCHAR(50) DEFAULT ''Approved''',
+    'SELECT 1');
+PREPARE migration_stmt FROM @stmt;
+EXECUTE migration_stmt;
+DEALLOCATE PREPARE migration_stmt;
+
+SET @stmt = IF(
+    (SELECT COUNT(*) FROM information_schema.columns
+     WHERE table_schema = DATABASE() AND table_name = 'Associates' AND column_name = 'manager_id') = 0,
+    'ALTER TABLE Associates ADD COLUMN phone_number VARCHAR(50), ADD COLUMN gender VARCHAR(50), ADD COLUMN private_email VARCHAR(255), ADD COLUMN manager_id INT, ADD FOREIGN KEY (manager_id) REFERENCES Associates(id)',
+    'SELECT 1');
+PREPARE migration_stmt FROM @stmt;
+EXECUTE migration_stmt;
+DEALLOCATE PREPARE migration_stmt;
@@ -0,0 +1,20 @@
+-- Move the merged rows, those with a legacy_type, back into TimeOffRequests
+CREATE TABLE IF NOT EXISTS TimeOffRequests (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    associate_id INT NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    start_date DATETIME NOT NULL,
+    end_date DATETIME NOT NULL,
+    reason TEXT,
+    status VARCHAR(50) DEFAULT 'Pending',
+    FOREIGN KEY (associate_id) REFERENCES Associates(id)
+);
+
+INSERT INTO TimeOffRequests (associate_id, type, start_date, end_date, reason, status)
+SELECT associate_id, legacy_type, start_date, end_date, reason, status
+FROM time_off_requests
+WHERE legacy_type IS NOT NULL;
+
+DELETE FROM time_off_requests WHERE legacy_type IS NOT NULL;
+
+ALTER TABLE time_off_requests DROP COLUMN legacy_type;
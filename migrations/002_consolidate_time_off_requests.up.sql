@@ -0,0 +1,16 @@
+-- init.sql used to create a second TimeOffRequests table that the API never
+-- read. Move any rows it holds into time_off_requests and drop it. Their
+-- type is kept in legacy_type until leave types exist to map it to.
+ALTER TABLE time_off_requests
+    ADD COLUMN legacy_type VARCHAR(50) NULL;
+
+SET @stmt = IF(
+    (SELECT COUNT(*) FROM information_schema.tables
+     WHERE table_schema = DATABASE() AND table_name = 'TimeOffRequests') > 0,
+    'INSERT INTO time_off_requests (associate_id, start_date, end_date, reason, status, legacy_type) SELECT associate_id, start_date, end_date, reason, status, type FROM TimeOffRequests',
+    'SELECT 1');
+PREPARE migration_stmt FROM @stmt;
+EXECUTE migration_stmt;
+DEALLOCATE PREPARE migration_stmt;
+
+DROP TABLE IF EXISTS TimeOffRequests;
@@ -0,0 +1,2 @@
+-- Reference data may have been edited since it was seeded, so it is kept
+SELECT 1;
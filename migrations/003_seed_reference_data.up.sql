@@ -0,0 +1,13 @@
+INSERT INTO Offices (name)
+SELECT name FROM (SELECT 'London' AS name UNION ALL SELECT 'New York' UNION ALL SELECT 'Paris') seed
+WHERE NOT EXISTS (SELECT 1 FROM Offices);
+
+INSERT INTO Departments (name)
+SELECT name FROM (SELECT 'IT' AS name UNION ALL SELECT 'HR' UNION ALL SELECT 'Design' UNION ALL SELECT 'Sales') seed
+WHERE NOT EXISTS (SELECT 1 FROM Departments);
+
+INSERT INTO menu_permissions (menu_item, permission_type, permission_value)
+SELECT 'Time Entry', 'everyone', NULL FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM menu_permissions WHERE menu_item = 'Time Entry');
+
+INSERT IGNORE INTO thanks_categories (name) VALUES ('Team Player'), ('Superhero'), ('Thank You!'), ('Knowledge');
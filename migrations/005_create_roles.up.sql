@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS roles (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description VARCHAR(255)
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id INT NOT NULL,
+    permission VARCHAR(100) NOT NULL,
+    PRIMARY KEY (role_id, permission),
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS associate_roles (
+    associate_id INT NOT NULL,
+    role_id INT NOT NULL,
+    PRIMARY KEY (associate_id, role_id),
+    FOREIGN KEY (associate_id) REFERENCES Associates(id) ON DELETE CASCADE,
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
+
+-- The Admin role replaces the titles that used to be hard-coded as admins
+INSERT IGNORE INTO roles (name, description) VALUES ('Admin', 'Full administrative access');
+
+INSERT IGNORE INTO role_permissions (role_id, permission)
+SELECT r.id, p.permission
+FROM roles r
+CROSS JOIN (
+    SELECT 'settings.manage' AS permission
+    UNION ALL SELECT 'roles.manage'
+    UNION ALL SELECT 'associates.manage'
+    UNION ALL SELECT 'org.manage'
+    UNION ALL SELECT 'holidays.manage'
+    UNION ALL SELECT 'menu_permissions.manage'
+    UNION ALL SELECT 'tasks.manage'
+    UNION ALL SELECT 'timeoff.approve_all'
+    UNION ALL SELECT 'timeoff.auto_approve'
+    UNION ALL SELECT 'timeentry.approve_all'
+    UNION ALL SELECT 'timeentry.overtime_exempt'
+) p
+WHERE r.name = 'Admin';
+
+INSERT IGNORE INTO associate_roles (associate_id, role_id)
+SELECT a.id, r.id
+FROM Associates a
+JOIN roles r ON r.name = 'Admin'
+WHERE a.title IN ('CEO', 'Head of People');
@@ -0,0 +1,10 @@
+DELETE FROM role_permissions WHERE permission = 'leave_types.manage';
+
+-- Migration 002 drops legacy_type on the way down. Which requests it merged
+-- is no longer known, so they stay in time_off_requests.
+ALTER TABLE time_off_requests ADD COLUMN legacy_type VARCHAR(50) NULL;
+
+ALTER TABLE time_off_requests DROP FOREIGN KEY fk_time_off_requests_leave_type;
+ALTER TABLE time_off_requests DROP COLUMN leave_type;
+
+DROP TABLE IF EXISTS leave_types;
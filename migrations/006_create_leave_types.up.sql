@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS leave_types (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    days_per_year DECIMAL(6, 2) NULL,
+    accrual_method VARCHAR(50) NOT NULL DEFAULT 'immediate',
+    requires_approval BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Vacation inherits the allowance and accrual method from the old global PTO settings.
+-- A NULL days_per_year means the leave type is not limited by a balance.
+INSERT IGNORE INTO leave_types (code, name, days_per_year, accrual_method, requires_approval)
+SELECT 'vacation', 'Vacation',
+    COALESCE((SELECT CAST(setting_value AS DECIMAL(6, 2)) FROM AppSettings WHERE setting_key = 'pto_days_per_year' AND setting_value <> ''), 15),
+    COALESCE((SELECT setting_value FROM AppSettings WHERE setting_key = 'pto_accrual_method' AND setting_value <> ''), 'immediate'),
+    TRUE
+FROM DUAL;
+
+INSERT IGNORE INTO leave_types (code, name, days_per_year, accrual_method, requires_approval) VALUES
+('sick', 'Sick Leave', 10, 'immediate', FALSE),
+('parental', 'Parental Leave', 60, 'immediate', TRUE),
+('unpaid', 'Unpaid Leave', NULL, 'immediate', TRUE),
+('bereavement', 'Bereavement Leave', 5, 'immediate', TRUE);
+
+ALTER TABLE time_off_requests
+    ADD COLUMN leave_type VARCHAR(50) NOT NULL DEFAULT 'vacation' AFTER associate_id,
+    ADD CONSTRAINT fk_time_off_requests_leave_type FOREIGN KEY (leave_type) REFERENCES leave_types(code) ON UPDATE CASCADE;
+
+-- Requests merged from TimeOffRequests take the leave type whose code or name
+-- matches their old type; types without one stay vacation.
+UPDATE time_off_requests t
+JOIN leave_types lt ON LOWER(TRIM(t.legacy_type)) IN (LOWER(lt.code), LOWER(lt.name))
+SET t.leave_type = lt.code
+WHERE t.legacy_type IS NOT NULL;
+
+ALTER TABLE time_off_requests DROP COLUMN legacy_type;
+
+INSERT IGNORE INTO role_permissions (role_id, permission)
+SELECT id, 'leave_types.manage' FROM roles WHERE name = 'Admin';
@@ -0,0 +1,7 @@
+DELETE FROM role_permissions WHERE permission = 'pto.manage';
+
+DROP TABLE IF EXISTS pto_ledger;
+
+ALTER TABLE leave_types
+    DROP COLUMN carry_over_expiry_months,
+    DROP COLUMN carry_over_cap;
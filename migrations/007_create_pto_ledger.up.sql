@@ -0,0 +1,33 @@
+-- Carry-over policy per leave type. A cap of 0 forfeits every unused day at
+-- year end; a NULL expiry keeps carried days for the whole following year.
+ALTER TABLE leave_types
+    ADD COLUMN carry_over_cap DECIMAL(6, 2) NOT NULL DEFAULT 0 AFTER accrual_method,
+    ADD COLUMN carry_over_expiry_months INT NULL AFTER carry_over_cap;
+
+-- Every change to a balance is a row. Balances belong to a calendar year
+-- (period_year): the balance on a date is the sum of that year's rows dated
+-- on or before it. source_key makes system-generated rows idempotent.
+CREATE TABLE IF NOT EXISTS pto_ledger (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    associate_id INT NOT NULL,
+    leave_type VARCHAR(50) NOT NULL,
+    entry_type VARCHAR(20) NOT NULL,
+    days DECIMAL(10, 4) NOT NULL,
+    period_year INT NOT NULL,
+    effective_date DATE NOT NULL,
+    expires_at DATE NULL,
+    time_off_request_id INT NULL,
+    source_key VARCHAR(100) NULL,
+    note VARCHAR(255),
+    created_by INT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_pto_ledger_source (associate_id, leave_type, source_key),
+    INDEX idx_pto_ledger_period (associate_id, leave_type, period_year, effective_date),
+    FOREIGN KEY (associate_id) REFERENCES Associates(id) ON DELETE CASCADE,
+    FOREIGN KEY (leave_type) REFERENCES leave_types(code) ON UPDATE CASCADE,
+    FOREIGN KEY (time_off_request_id) REFERENCES time_off_requests(id) ON DELETE SET NULL,
+    FOREIGN KEY (created_by) REFERENCES Associates(id) ON DELETE SET NULL
+);
+
+INSERT IGNORE INTO role_permissions (role_id, permission)
+SELECT id, 'pto.manage' FROM roles WHERE name = 'Admin';
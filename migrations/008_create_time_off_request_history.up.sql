@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS time_off_request_history (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    time_off_request_id INT NOT NULL,
+    from_status VARCHAR(50) NULL,
+    to_status VARCHAR(50) NOT NULL,
+    actor_id INT NULL,
+    comment TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_time_off_request_history_request (time_off_request_id, created_at),
+    FOREIGN KEY (time_off_request_id) REFERENCES time_off_requests(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES Associates(id) ON DELETE SET NULL
+);
+
+-- Requests filed before the history existed start with their current status
+INSERT INTO time_off_request_history (time_off_request_id, from_status, to_status, actor_id, comment, created_at)
+SELECT t.id, NULL, COALESCE(t.status, 'Pending'), NULL, 'Recorded when history tracking was introduced', t.created_at
+FROM time_off_requests t
+WHERE NOT EXISTS (SELECT 1 FROM time_off_request_history h WHERE h.time_off_request_id = t.id);
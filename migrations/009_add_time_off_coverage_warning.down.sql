@@ -0,0 +1,3 @@
+ALTER TABLE time_off_requests
+    DROP INDEX idx_time_off_requests_dates,
+    DROP COLUMN coverage_warning;
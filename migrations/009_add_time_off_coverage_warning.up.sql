@@ -0,0 +1,3 @@
+ALTER TABLE time_off_requests
+    ADD COLUMN coverage_warning BOOLEAN NOT NULL DEFAULT FALSE AFTER status,
+    ADD INDEX idx_time_off_requests_dates (start_date, end_date);
@@ -0,0 +1,12 @@
+-- Subscription URLs for the iCalendar feeds. Only a SHA-256 hash of each
+-- token is stored; the token itself is shown once, when the feed is created.
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    owner_id INT NOT NULL,
+    scope VARCHAR(20) NOT NULL,
+    scope_value VARCHAR(255) NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_used_at DATETIME NULL,
+    FOREIGN KEY (owner_id) REFERENCES Associates(id) ON DELETE CASCADE
+);
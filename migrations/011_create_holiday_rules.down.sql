@@ -0,0 +1,9 @@
+ALTER TABLE holidays ADD COLUMN is_recurring BOOLEAN DEFAULT FALSE AFTER year;
+
+-- Only fixed-date rules can be expressed as recurring holidays; the rest are dropped
+INSERT INTO holidays (name, date, year, is_recurring)
+SELECT name, STR_TO_DATE(CONCAT(COALESCE(start_year, YEAR(CURDATE())), '-', month, '-', day), '%Y-%c-%e'), COALESCE(start_year, YEAR(CURDATE())), TRUE
+FROM holiday_rules
+WHERE rule_type = 'fixed' AND offset_days = 0;
+
+DROP TABLE IF EXISTS holiday_rules;
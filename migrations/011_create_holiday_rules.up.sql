@@ -0,0 +1,44 @@
+-- Recurring holidays are defined by rules and materialized per year
+CREATE TABLE IF NOT EXISTS holiday_rules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    -- fixed (month/day), nth_weekday (nth weekday of month), last_weekday, easter
+    rule_type VARCHAR(20) NOT NULL,
+    month TINYINT NULL,
+    day TINYINT NULL,
+    -- 0 = Sunday ... 6 = Saturday
+    weekday TINYINT NULL,
+    nth TINYINT NULL,
+    offset_days INT NOT NULL DEFAULT 0,
+    -- none, nearest_weekday (Sat -> Fri, Sun -> Mon) or next_weekday (Sat/Sun -> Mon)
+    observed VARCHAR(20) NOT NULL DEFAULT 'none',
+    start_year INT NULL,
+    end_year INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- Holidays flagged as recurring become fixed-date rules
+INSERT INTO holiday_rules (name, rule_type, month, day, start_year)
+SELECT name, 'fixed', MONTH(date), DAY(date), MIN(year)
+FROM holidays
+WHERE is_recurring = TRUE
+GROUP BY name, MONTH(date), DAY(date);
+
+-- The seeded floating US holidays become weekday rules
+INSERT INTO holiday_rules (name, rule_type, month, weekday, nth, start_year)
+SELECT h.name, seed.rule_type, seed.month, seed.weekday, seed.nth, h.year FROM (
+    SELECT 'Martin Luther King Jr. Day' AS name, '2026-01-19' AS date, 'nth_weekday' AS rule_type, 1 AS month, 1 AS weekday, 3 AS nth
+    UNION ALL SELECT 'Presidents'' Day', '2026-02-16', 'nth_weekday', 2, 1, 3
+    UNION ALL SELECT 'Memorial Day', '2026-05-25', 'last_weekday', 5, 1, NULL
+    UNION ALL SELECT 'Labor Day', '2026-09-07', 'nth_weekday', 9, 1, 1
+    UNION ALL SELECT 'Columbus Day', '2026-10-12', 'nth_weekday', 10, 1, 2
+    UNION ALL SELECT 'Thanksgiving', '2026-11-26', 'nth_weekday', 11, 4, 4
+) seed
+JOIN holidays h ON h.name = seed.name AND h.date = seed.date AND h.is_recurring = FALSE;
+
+-- The rules now produce those dates, so the one-off rows would duplicate them
+DELETE h FROM holidays h
+JOIN holiday_rules r ON r.name = h.name AND r.start_year = h.year;
+
+ALTER TABLE holidays DROP COLUMN is_recurring;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS holiday_rule_offices;
+DROP TABLE IF EXISTS holiday_offices;
@@ -0,0 +1,34 @@
+-- Holidays and holiday rules assigned to offices. Those without an office
+-- form the company-wide calendar, which every office observes along with
+-- its own.
+CREATE TABLE IF NOT EXISTS holiday_offices (
+    holiday_id INT NOT NULL,
+    office_id INT NOT NULL,
+    PRIMARY KEY (holiday_id, office_id),
+    CONSTRAINT fk_holiday_offices_holiday FOREIGN KEY (holiday_id) REFERENCES holidays(id) ON DELETE CASCADE,
+    CONSTRAINT fk_holiday_offices_office FOREIGN KEY (office_id) REFERENCES Offices(id)
+);
+
+CREATE TABLE IF NOT EXISTS holiday_rule_offices (
+    holiday_rule_id INT NOT NULL,
+    office_id INT NOT NULL,
+    PRIMARY KEY (holiday_rule_id, office_id),
+    CONSTRAINT fk_holiday_rule_offices_rule FOREIGN KEY (holiday_rule_id) REFERENCES holiday_rules(id) ON DELETE CASCADE,
+    CONSTRAINT fk_holiday_rule_offices_office FOREIGN KEY (office_id) REFERENCES Offices(id)
+);
+
+-- The seeded US federal holidays belong to New York; New Year's Day and
+-- Christmas stay company-wide
+INSERT IGNORE INTO holiday_rule_offices (holiday_rule_id, office_id)
+SELECT r.id, o.id
+FROM holiday_rules r
+JOIN Offices o ON o.name = 'New York'
+WHERE r.name IN ('Martin Luther King Jr. Day', 'Presidents'' Day', 'Memorial Day', 'Independence Day',
+                 'Labor Day', 'Columbus Day', 'Veterans Day', 'Thanksgiving');
+
+INSERT IGNORE INTO holiday_offices (holiday_id, office_id)
+SELECT h.id, o.id
+FROM holidays h
+JOIN Offices o ON o.name = 'New York'
+WHERE h.name IN ('Martin Luther King Jr. Day', 'Presidents'' Day', 'Memorial Day', 'Independence Day',
+                 'Labor Day', 'Columbus Day', 'Veterans Day', 'Thanksgiving');
@@ -0,0 +1,3 @@
+ALTER TABLE time_off_requests
+    DROP COLUMN hours,
+    DROP COLUMN day_portion;
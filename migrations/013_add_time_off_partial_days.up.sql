@@ -0,0 +1,4 @@
+-- Requests can cover a morning, an afternoon or a number of hours of one day
+ALTER TABLE time_off_requests
+    ADD COLUMN day_portion VARCHAR(10) NOT NULL DEFAULT 'full' AFTER end_date,
+    ADD COLUMN hours DECIMAL(5,2) NULL AFTER day_portion;
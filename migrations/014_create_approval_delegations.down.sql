@@ -0,0 +1,16 @@
+DELETE FROM role_permissions WHERE permission = 'delegations.manage';
+
+ALTER TABLE Tasks
+    DROP COLUMN decided_at,
+    DROP COLUMN decided_on_behalf_of,
+    DROP COLUMN decided_by;
+
+ALTER TABLE time_entries
+    DROP COLUMN decided_at,
+    DROP COLUMN decided_on_behalf_of,
+    DROP COLUMN decided_by;
+
+ALTER TABLE time_off_request_history DROP FOREIGN KEY fk_time_off_request_history_on_behalf_of;
+ALTER TABLE time_off_request_history DROP COLUMN on_behalf_of;
+
+DROP TABLE IF EXISTS approval_delegations;
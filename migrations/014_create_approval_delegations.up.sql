@@ -0,0 +1,34 @@
+-- An approver can hand their approvals to a delegate for a date range
+CREATE TABLE IF NOT EXISTS approval_delegations (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    delegator_id INT NOT NULL,
+    delegate_id INT NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_by INT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_approval_delegations_delegator (delegator_id, start_date, end_date),
+    INDEX idx_approval_delegations_delegate (delegate_id, start_date, end_date),
+    FOREIGN KEY (delegator_id) REFERENCES Associates(id) ON DELETE CASCADE,
+    FOREIGN KEY (delegate_id) REFERENCES Associates(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES Associates(id) ON DELETE SET NULL
+);
+
+-- Decisions taken by a delegate record whose approval they stood in for
+ALTER TABLE time_off_request_history
+    ADD COLUMN on_behalf_of INT NULL AFTER actor_id,
+    ADD CONSTRAINT fk_time_off_request_history_on_behalf_of FOREIGN KEY (on_behalf_of) REFERENCES Associates(id) ON DELETE SET NULL;
+
+ALTER TABLE time_entries
+    ADD COLUMN decided_by INT NULL,
+    ADD COLUMN decided_on_behalf_of INT NULL,
+    ADD COLUMN decided_at DATETIME NULL;
+
+ALTER TABLE Tasks
+    ADD COLUMN decided_by INT NULL,
+    ADD COLUMN decided_on_behalf_of INT NULL,
+    ADD COLUMN decided_at DATETIME NULL;
+
+INSERT IGNORE INTO role_permissions (role_id, permission)
+SELECT id, 'delegations.manage' FROM roles WHERE name = 'Admin';
@@ -0,0 +1,4 @@
+ALTER TABLE time_entries
+    DROP COLUMN escalated_to;
+
+DROP TABLE IF EXISTS approval_escalations;
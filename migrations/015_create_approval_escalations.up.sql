@@ -0,0 +1,18 @@
+-- Approvals left pending past the SLA move up the management chain. Each
+-- step is recorded once: the unique level keeps replicas from repeating it.
+CREATE TABLE IF NOT EXISTS approval_escalations (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    subject_type VARCHAR(20) NOT NULL,
+    subject_id INT NOT NULL,
+    level INT NOT NULL,
+    from_approver_id INT NULL,
+    to_approver_id INT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_approval_escalations_level (subject_type, subject_id, level),
+    FOREIGN KEY (from_approver_id) REFERENCES Associates(id) ON DELETE SET NULL,
+    FOREIGN KEY (to_approver_id) REFERENCES Associates(id) ON DELETE SET NULL
+);
+
+-- Time entries have no approver column; an escalated entry names who it went to
+ALTER TABLE time_entries
+    ADD COLUMN escalated_to INT NULL;
@@ -0,0 +1,13 @@
+ALTER TABLE Tasks ADD COLUMN approvers JSON;
+
+-- Each task's steps go back into its approvers list, in stage order
+UPDATE Tasks t
+SET t.approvers = (
+    SELECT JSON_ARRAYAGG(JSON_OBJECT('approver_id', ordered.approver_id, 'status', ordered.status))
+    FROM (SELECT task_id, approver_id, status FROM task_approval_steps ORDER BY stage, id) ordered
+    WHERE ordered.task_id = t.id
+);
+
+DROP TABLE IF EXISTS task_approval_steps;
+DROP TABLE IF EXISTS task_workflow_steps;
+DROP TABLE IF EXISTS task_workflows;
@@ -0,0 +1,106 @@
+-- Each task type (Tasks.task_name) can define approval steps. Steps sharing
+-- a stage run in parallel; stages run in ascending order.
+CREATE TABLE IF NOT EXISTS task_workflows (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    task_name VARCHAR(255) NOT NULL UNIQUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS task_workflow_steps (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    workflow_id INT NOT NULL,
+    stage INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    approver_type VARCHAR(20) NOT NULL,
+    manager_level INT NULL,
+    role VARCHAR(100) NOT NULL DEFAULT '',
+    permission VARCHAR(100) NOT NULL DEFAULT '',
+    associate_id INT NULL,
+    INDEX idx_task_workflow_steps_workflow (workflow_id, stage),
+    FOREIGN KEY (workflow_id) REFERENCES task_workflows(id) ON DELETE CASCADE,
+    FOREIGN KEY (associate_id) REFERENCES Associates(id) ON DELETE CASCADE
+);
+
+-- The steps of each task, resolved when it is filed, with their decisions
+CREATE TABLE IF NOT EXISTS task_approval_steps (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    task_id INT NOT NULL,
+    stage INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    approver_type VARCHAR(20) NOT NULL,
+    approver_id INT NULL,
+    role VARCHAR(100) NOT NULL DEFAULT '',
+    permission VARCHAR(100) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL,
+    decided_by INT NULL,
+    on_behalf_of INT NULL,
+    comment TEXT,
+    decided_at DATETIME NULL,
+    INDEX idx_task_approval_steps_task (task_id, stage),
+    FOREIGN KEY (task_id) REFERENCES Tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (approver_id) REFERENCES Associates(id) ON DELETE SET NULL,
+    FOREIGN KEY (decided_by) REFERENCES Associates(id) ON DELETE SET NULL,
+    FOREIGN KEY (on_behalf_of) REFERENCES Associates(id) ON DELETE SET NULL
+);
+
+-- Salary increases go to the manager, then to someone who manages associates
+INSERT IGNORE INTO task_workflows (task_name) VALUES ('Salary Increase');
+
+INSERT INTO task_workflow_steps (workflow_id, stage, name, approver_type, manager_level)
+SELECT id, 1, 'Manager approval', 'manager', 1 FROM task_workflows
+WHERE task_name = 'Salary Increase'
+  AND NOT EXISTS (SELECT 1 FROM task_workflow_steps s JOIN task_workflows w ON s.workflow_id = w.id WHERE w.task_name = 'Salary Increase');
+
+INSERT INTO task_workflow_steps (workflow_id, stage, name, approver_type, permission)
+SELECT id, 2, 'HR approval', 'permission', 'associates.manage' FROM task_workflows
+WHERE task_name = 'Salary Increase'
+  AND NOT EXISTS (SELECT 1 FROM task_workflow_steps s JOIN task_workflows w ON s.workflow_id = w.id WHERE w.task_name = 'Salary Increase' AND s.stage = 2);
+
+-- The approvers list each task was filed with becomes its steps, one stage
+-- per approver in list order. An entry is an associate id, or an object with
+-- an id, associate_id or approver_id and optionally its own status. Decided
+-- tasks keep the approvers they had; entries without a status of their own
+-- take the task's. Pending tasks wait on their first undecided approver.
+INSERT INTO task_approval_steps (task_id, stage, name, approver_type, approver_id, status)
+SELECT t.id, j.ord, 'Approval', 'associate', a.id,
+       CASE
+           WHEN LOWER(j.status) IN ('approved', 'rejected') THEN LOWER(j.status)
+           WHEN LOWER(t.status) IN ('approved', 'rejected') THEN LOWER(t.status)
+           ELSE 'waiting'
+       END
+FROM Tasks t
+JOIN JSON_TABLE(t.approvers, '$[*]' COLUMNS (
+    ord FOR ORDINALITY,
+    plain_id INT PATH '$' NULL ON ERROR,
+    id_field INT PATH '$.id' NULL ON ERROR,
+    associate_field INT PATH '$.associate_id' NULL ON ERROR,
+    approver_field INT PATH '$.approver_id' NULL ON ERROR,
+    status VARCHAR(20) PATH '$.status' NULL ON ERROR
+)) j
+LEFT JOIN Associates a ON a.id = COALESCE(j.plain_id, j.approver_field, j.associate_field, j.id_field)
+WHERE JSON_TYPE(t.approvers) = 'ARRAY'
+  AND NOT EXISTS (SELECT 1 FROM task_approval_steps s WHERE s.task_id = t.id);
+
+UPDATE task_approval_steps s
+JOIN (SELECT task_id, MIN(stage) AS stage FROM task_approval_steps WHERE status = 'waiting' GROUP BY task_id) first_open
+    ON s.task_id = first_open.task_id AND s.stage = first_open.stage
+SET s.status = 'pending';
+
+-- Pending tasks filed without approvers wait on the requester's manager, or
+-- on a task manager
+INSERT INTO task_approval_steps (task_id, stage, name, approver_type, approver_id, permission, status)
+SELECT t.id, 1,
+       IF(a.manager_id IS NULL, 'Task manager approval', 'Manager approval'),
+       IF(a.manager_id IS NULL, 'permission', 'manager'),
+       a.manager_id,
+       IF(a.manager_id IS NULL, 'tasks.manage', ''),
+       'pending'
+FROM Tasks t
+LEFT JOIN Associates a ON t.requester_id = a.id
+WHERE LOWER(t.status) = 'pending'
+  AND NOT EXISTS (SELECT 1 FROM task_approval_steps s WHERE s.task_id = t.id);
+
+UPDATE Tasks SET status = LOWER(status) WHERE LOWER(status) IN ('pending', 'approved', 'rejected');
+
+ALTER TABLE Tasks DROP COLUMN approvers;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS salary_changes;
+
+ALTER TABLE Tasks
+    DROP COLUMN effective_date;
@@ -0,0 +1,21 @@
+-- A salary increase takes effect on its effective date (default: when approved)
+ALTER TABLE Tasks
+    ADD COLUMN effective_date DATE NULL;
+
+-- Salary changes from approved tasks. Future-dated changes stay scheduled
+-- until their date; old_salary is read when the change is applied.
+CREATE TABLE IF NOT EXISTS salary_changes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    associate_id INT NOT NULL,
+    task_id INT NULL UNIQUE,
+    old_salary INT NULL,
+    new_salary INT NOT NULL,
+    effective_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    applied_at DATETIME NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_salary_changes_due (status, effective_date),
+    INDEX idx_salary_changes_associate (associate_id, effective_date),
+    FOREIGN KEY (associate_id) REFERENCES Associates(id) ON DELETE CASCADE,
+    FOREIGN KEY (task_id) REFERENCES Tasks(id) ON DELETE SET NULL
+);
@@ -0,0 +1,9 @@
+DELETE FROM role_permissions WHERE permission IN ('compensation.view', 'compensation.manage');
+
+DROP TABLE IF EXISTS salary_ranges;
+DROP TABLE IF EXISTS compensation_history;
+
+ALTER TABLE salary_changes
+    DROP COLUMN created_by,
+    DROP COLUMN reason,
+    DROP COLUMN currency;
@@ -0,0 +1,68 @@
+-- Salary changes carry a currency and a reason, and may be entered by hand
+ALTER TABLE salary_changes
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD',
+    ADD COLUMN reason VARCHAR(255) NULL,
+    ADD COLUMN created_by INT NULL;
+
+-- Every salary an associate has had, from its effective date. A row is
+-- written whenever a salary change is applied.
+CREATE TABLE IF NOT EXISTS compensation_history (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    associate_id INT NOT NULL,
+    amount INT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    effective_date DATE NOT NULL,
+    reason VARCHAR(255) NULL,
+    task_id INT NULL,
+    salary_change_id INT NULL UNIQUE,
+    created_by INT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_compensation_history_associate (associate_id, effective_date),
+    FOREIGN KEY (associate_id) REFERENCES Associates(id) ON DELETE CASCADE,
+    FOREIGN KEY (task_id) REFERENCES Tasks(id) ON DELETE SET NULL,
+    FOREIGN KEY (salary_change_id) REFERENCES salary_changes(id) ON DELETE SET NULL,
+    FOREIGN KEY (created_by) REFERENCES Associates(id) ON DELETE SET NULL
+);
+
+-- Pay bands per job title. Ranges without an office apply wherever the
+-- office has none of its own. Associates carry no job level, so a band
+-- covers every level of its title. office_key stands in for a missing
+-- office, since a unique key treats NULLs as distinct and would allow two
+-- company-wide bands for one title.
+CREATE TABLE IF NOT EXISTS salary_ranges (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    office_id INT NULL,
+    office_key INT AS (COALESCE(office_id, 0)) VIRTUAL,
+    currency CHAR(3) NOT NULL,
+    min_amount INT NOT NULL,
+    mid_amount INT NOT NULL,
+    max_amount INT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_salary_ranges_title_office (title, office_key),
+    FOREIGN KEY (office_id) REFERENCES Offices(id) ON DELETE CASCADE
+);
+
+-- Opening entry: the salary before the first applied change, or the current
+-- one, from the start date
+INSERT INTO compensation_history (associate_id, amount, currency, effective_date, reason)
+SELECT a.id,
+       COALESCE((SELECT sc.old_salary FROM salary_changes sc
+                 WHERE sc.associate_id = a.id AND sc.status = 'applied' AND sc.old_salary IS NOT NULL
+                 ORDER BY sc.applied_at, sc.id LIMIT 1), a.salary),
+       'USD', COALESCE(a.start_date, CURDATE()), 'Salary on record'
+FROM Associates a
+WHERE a.salary IS NOT NULL;
+
+INSERT INTO compensation_history (associate_id, amount, currency, effective_date, reason, task_id, salary_change_id)
+SELECT sc.associate_id, sc.new_salary, sc.currency, sc.effective_date, t.reason, sc.task_id, sc.id
+FROM salary_changes sc
+LEFT JOIN Tasks t ON sc.task_id = t.id
+WHERE sc.status = 'applied';
+
+INSERT IGNORE INTO role_permissions (role_id, permission)
+SELECT id, 'compensation.view' FROM roles WHERE name = 'Admin';
+
+INSERT IGNORE INTO role_permissions (role_id, permission)
+SELECT id, 'compensation.manage' FROM roles WHERE name = 'Admin';
@@ -0,0 +1,3 @@
+-- HR sees every associate field whatever the field visibility policy says
+INSERT IGNORE INTO role_permissions (role_id, permission)
+SELECT id, 'associates.view_sensitive' FROM roles WHERE name = 'Admin';
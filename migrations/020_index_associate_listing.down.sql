@@ -0,0 +1,6 @@
+ALTER TABLE Associates
+    DROP INDEX idx_associates_last_name,
+    DROP INDEX idx_associates_start_date,
+    DROP INDEX idx_associates_status,
+    DROP INDEX idx_associates_office,
+    DROP INDEX idx_associates_department;
@@ -0,0 +1,7 @@
+-- Associate listings filter and sort on these columns
+ALTER TABLE Associates
+    ADD INDEX idx_associates_department (department, last_name),
+    ADD INDEX idx_associates_office (office, last_name),
+    ADD INDEX idx_associates_status (status, empl_status),
+    ADD INDEX idx_associates_start_date (start_date),
+    ADD INDEX idx_associates_last_name (last_name, first_name);
@@ -0,0 +1,3 @@
+ALTER TABLE time_off_requests
+    DROP FOREIGN KEY fk_time_off_requests_escalated_to,
+    DROP COLUMN escalated_to;
@@ -0,0 +1,5 @@
+-- An escalated time-off request keeps its approver, like a time entry, and
+-- names who it went to; both may decide it
+ALTER TABLE time_off_requests
+    ADD COLUMN escalated_to INT NULL,
+    ADD CONSTRAINT fk_time_off_requests_escalated_to FOREIGN KEY (escalated_to) REFERENCES Associates(id) ON DELETE SET NULL;
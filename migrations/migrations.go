@@ -0,0 +1,10 @@
+// Package migrations holds the versioned SQL files applied by internal/migrate.
+//
+// Files are named NNN_description.up.sql and NNN_description.down.sql and are
+// applied in ascending NNN order.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS